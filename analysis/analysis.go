@@ -0,0 +1,151 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package analysis assesses the winner of an experiment from the observations of its reward metric.
+// The Bayesian method computes the probability that each version is the best version;
+// the Frequentist method tests whether each version is better than every other version.
+// Allocate splits traffic among the versions using a multi-armed bandit algorithm.
+package analysis
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// Sample summarizes the observations of the reward metric for a version
+type Sample struct {
+	// Version is the name of the version
+	Version string
+
+	// Value is the mean of the observations; for the Beta model, it is the fraction of successful trials
+	Value float64
+
+	// StdDev is the standard deviation of the observations; it is not used by the Beta model
+	StdDev float64
+
+	// Size is the number of observations
+	Size int64
+}
+
+// Options configure the analysis
+type Options struct {
+	// Method is the method of analysis
+	Method v2alpha2.AnalysisMethodType
+
+	// Model is the model of the reward metric
+	Model v2alpha2.RewardModelType
+
+	// Confidence is the confidence required to declare a winner
+	Confidence float64
+
+	// MinSampleSize is the sample size required of every version to declare a winner
+	MinSampleSize int64
+
+	// HigherIsBetter indicates that higher values of the reward are better
+	HigherIsBetter bool
+
+	// Looks is the number of times the analysis is planned to be repeated during the experiment;
+	// the Frequentist method corrects its tests for repetition. If less than 1, 1 is used.
+	Looks int
+
+	// Seed seeds the random numbers used by the Bayesian method
+	Seed uint64
+}
+
+// Assessment is the outcome of the analysis for a version
+type Assessment struct {
+	// Confidence is the probability that the version is the best version (Bayesian)
+	// or the confidence that it is better than every other version (Frequentist)
+	Confidence float64
+
+	// Lower is the lower bound of the interval that contains the reward with the required confidence
+	Lower float64
+
+	// Upper is the upper bound of the interval that contains the reward with the required confidence
+	Upper float64
+}
+
+// Result is the outcome of the analysis
+type Result struct {
+	// Assessments are the assessments of the versions, by name
+	Assessments map[string]Assessment
+
+	// Winner is the name of the winning version, if any
+	Winner *string
+}
+
+// Assess assesses the versions
+// A winner is declared only if every version has the required sample size and the version with
+// the highest confidence has the required confidence.
+func Assess(samples []Sample, opts Options) (*Result, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no versions to assess")
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		return nil, fmt.Errorf("confidence must be between 0 and 1; found %v", opts.Confidence)
+	}
+	for _, s := range samples {
+		if err := validSample(s, opts.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	var result *Result
+	switch opts.Method {
+	case v2alpha2.AnalysisMethodBayesian:
+		result = bayesian(samples, opts)
+	case v2alpha2.AnalysisMethodFrequentist:
+		result = frequentist(samples, opts)
+	default:
+		return nil, fmt.Errorf("unsupported analysis method %s", opts.Method)
+	}
+
+	for _, s := range samples {
+		if s.Size < opts.MinSampleSize {
+			return result, nil
+		}
+	}
+	best := samples[0].Version
+	for _, s := range samples[1:] {
+		if result.Assessments[s.Version].Confidence > result.Assessments[best].Confidence {
+			best = s.Version
+		}
+	}
+	if result.Assessments[best].Confidence >= opts.Confidence {
+		result.Winner = &best
+	}
+	return result, nil
+}
+
+// validSample returns an error if the sample cannot be analyzed using the model
+func validSample(s Sample, model v2alpha2.RewardModelType) error {
+	if s.Size < 1 {
+		return fmt.Errorf("version %s has no observations", s.Version)
+	}
+	switch model {
+	case v2alpha2.RewardModelBeta:
+		if s.Value < 0 || s.Value > 1 {
+			return fmt.Errorf("reward of version %s is not a rate between 0 and 1: %v", s.Version, s.Value)
+		}
+	case v2alpha2.RewardModelNormal:
+		if s.StdDev < 0 {
+			return fmt.Errorf("standard deviation of version %s is negative: %v", s.Version, s.StdDev)
+		}
+	default:
+		return fmt.Errorf("unsupported reward model %s", model)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func options(method v2alpha2.AnalysisMethodType, model v2alpha2.RewardModelType) Options {
+	return Options{
+		Method:         method,
+		Model:          model,
+		Confidence:     0.95,
+		MinSampleSize:  10,
+		HigherIsBetter: true,
+		Looks:          1,
+	}
+}
+
+func TestBayesianBeta(t *testing.T) {
+	samples := []Sample{
+		{Version: "v1", Value: 0.10, Size: 2000},
+		{Version: "v2", Value: 0.15, Size: 2000},
+	}
+	result, err := Assess(samples, options(v2alpha2.AnalysisMethodBayesian, v2alpha2.RewardModelBeta))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", *result.Winner)
+	assert.Greater(t, result.Assessments["v2"].Confidence, 0.99)
+	assert.InDelta(t, 1, result.Assessments["v1"].Confidence+result.Assessments["v2"].Confidence, 1e-9)
+	assert.Less(t, result.Assessments["v2"].Lower, 0.15)
+	assert.Greater(t, result.Assessments["v2"].Upper, 0.15)
+	assert.Greater(t, result.Assessments["v2"].Lower, 0.13)
+}
+
+func TestBayesianNormalLowerIsBetter(t *testing.T) {
+	samples := []Sample{
+		{Version: "v1", Value: 100, StdDev: 20, Size: 50},
+		{Version: "v2", Value: 80, StdDev: 20, Size: 50},
+		{Version: "v3", Value: 120, StdDev: 20, Size: 50},
+	}
+	opts := options(v2alpha2.AnalysisMethodBayesian, v2alpha2.RewardModelNormal)
+	opts.HigherIsBetter = false
+	result, err := Assess(samples, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", *result.Winner)
+
+	// the same seed gives the same result
+	again, _ := Assess(samples, opts)
+	assert.Equal(t, result, again)
+}
+
+func TestNoWinnerWithoutConfidence(t *testing.T) {
+	samples := []Sample{
+		{Version: "v1", Value: 100, StdDev: 20, Size: 50},
+		{Version: "v2", Value: 101, StdDev: 20, Size: 50},
+	}
+	for _, method := range []v2alpha2.AnalysisMethodType{v2alpha2.AnalysisMethodBayesian, v2alpha2.AnalysisMethodFrequentist} {
+		result, err := Assess(samples, options(method, v2alpha2.RewardModelNormal))
+		assert.NoError(t, err)
+		assert.Nil(t, result.Winner, method)
+	}
+}
+
+func TestNoWinnerWithoutSampleSize(t *testing.T) {
+	samples := []Sample{
+		{Version: "v1", Value: 0.1, Size: 5},
+		{Version: "v2", Value: 0.9, Size: 5},
+	}
+	result, err := Assess(samples, options(v2alpha2.AnalysisMethodBayesian, v2alpha2.RewardModelBeta))
+	assert.NoError(t, err)
+	assert.Nil(t, result.Winner)
+	assert.Greater(t, result.Assessments["v2"].Confidence, 0.95)
+}
+
+func TestFrequentist(t *testing.T) {
+	samples := []Sample{
+		{Version: "v1", Value: 100, StdDev: 20, Size: 50},
+		{Version: "v2", Value: 115, StdDev: 20, Size: 50},
+	}
+	opts := options(v2alpha2.AnalysisMethodFrequentist, v2alpha2.RewardModelNormal)
+	result, err := Assess(samples, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", *result.Winner)
+	assert.Greater(t, result.Assessments["v2"].Confidence, 0.99)
+	assert.Less(t, result.Assessments["v1"].Confidence, 0.01)
+	// 95% confidence interval of the mean: 115 +/- t(0.975, 49) * 20 / sqrt(50)
+	assert.InDelta(t, 115-5.684, result.Assessments["v2"].Lower, 0.01)
+	assert.InDelta(t, 115+5.684, result.Assessments["v2"].Upper, 0.01)
+
+	// a difference that is significant once is not significant when the analysis is repeated many times
+	samples[1].Value = 109
+	result, _ = Assess(samples, opts)
+	assert.Equal(t, "v2", *result.Winner)
+	opts.Looks = 20
+	result, _ = Assess(samples, opts)
+	assert.Nil(t, result.Winner)
+}
+
+func TestFrequentistBeta(t *testing.T) {
+	samples := []Sample{
+		{Version: "v1", Value: 0.10, Size: 2000},
+		{Version: "v2", Value: 0.13, Size: 2000},
+	}
+	result, err := Assess(samples, options(v2alpha2.AnalysisMethodFrequentist, v2alpha2.RewardModelBeta))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", *result.Winner)
+}
+
+func TestInvalidSamples(t *testing.T) {
+	_, err := Assess(nil, options(v2alpha2.AnalysisMethodBayesian, v2alpha2.RewardModelBeta))
+	assert.Error(t, err)
+	_, err = Assess([]Sample{{Version: "v1", Value: 2, Size: 10}}, options(v2alpha2.AnalysisMethodBayesian, v2alpha2.RewardModelBeta))
+	assert.EqualError(t, err, "reward of version v1 is not a rate between 0 and 1: 2")
+	_, err = Assess([]Sample{{Version: "v1", Value: 2}}, options(v2alpha2.AnalysisMethodBayesian, v2alpha2.RewardModelNormal))
+	assert.EqualError(t, err, "version v1 has no observations")
+}
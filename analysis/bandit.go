@@ -0,0 +1,142 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// BanditOptions configure a multi-armed bandit
+type BanditOptions struct {
+	// Algorithm is the bandit algorithm
+	Algorithm v2alpha2.BanditAlgorithmType
+
+	// Model is the model of the reward metric
+	Model v2alpha2.RewardModelType
+
+	// HigherIsBetter indicates that higher values of the reward are better
+	HigherIsBetter bool
+
+	// Epsilon is the fraction of traffic split evenly among the versions by the EpsilonGreedy algorithm
+	Epsilon float64
+
+	// Seed seeds the random numbers used by the ThompsonSampling algorithm
+	Seed uint64
+}
+
+// Allocation is the share of traffic a bandit allocates to a version
+type Allocation struct {
+	// Score is the score of the version: the probability that it is the best version (ThompsonSampling),
+	// the optimistic bound of its reward (UCB), or its reward (EpsilonGreedy)
+	Score float64
+
+	// Fraction is the fraction of traffic allocated to the version
+	Fraction float64
+}
+
+// Allocate splits traffic among the versions using the bandit algorithm
+// The fractions of the allocations, by name of version, sum to 1.
+func Allocate(samples []Sample, opts BanditOptions) (map[string]Allocation, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no versions to allocate")
+	}
+	for _, s := range samples {
+		if err := validSample(s, opts.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	switch opts.Algorithm {
+	case v2alpha2.BanditAlgorithmThompsonSampling:
+		return thompsonSampling(samples, opts), nil
+	case v2alpha2.BanditAlgorithmUCB:
+		return ucb(samples, opts), nil
+	case v2alpha2.BanditAlgorithmEpsilonGreedy:
+		if opts.Epsilon < 0 || opts.Epsilon > 1 {
+			return nil, fmt.Errorf("epsilon must be between 0 and 1; found %v", opts.Epsilon)
+		}
+		return epsilonGreedy(samples, opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported bandit algorithm %s", opts.Algorithm)
+	}
+}
+
+// thompsonSampling allocates to each version the probability that it is the best version
+func thompsonSampling(samples []Sample, opts BanditOptions) map[string]Allocation {
+	result := bayesian(samples, Options{Model: opts.Model, Confidence: 0.95, HigherIsBetter: opts.HigherIsBetter, Seed: opts.Seed})
+	allocations := map[string]Allocation{}
+	for _, s := range samples {
+		confidence := result.Assessments[s.Version].Confidence
+		allocations[s.Version] = Allocation{Score: confidence, Fraction: confidence}
+	}
+	return allocations
+}
+
+// ucb allocates all traffic to the version with the most optimistic bound of its reward
+// The bound is the reward improved by sqrt(2 ln N / n) times the range of the reward (Beta)
+// or its standard deviation (Normal), where N is the total and n the version's number of observations.
+func ucb(samples []Sample, opts BanditOptions) map[string]Allocation {
+	var total int64
+	for _, s := range samples {
+		total += s.Size
+	}
+	scores := make([]float64, len(samples))
+	for i, s := range samples {
+		scale := 1.0
+		if opts.Model == v2alpha2.RewardModelNormal {
+			scale = s.StdDev
+		}
+		bonus := scale * math.Sqrt(2*math.Log(float64(total))/float64(s.Size))
+		if opts.HigherIsBetter {
+			scores[i] = s.Value + bonus
+		} else {
+			scores[i] = s.Value - bonus
+		}
+	}
+	return greedy(samples, scores, 0, opts.HigherIsBetter)
+}
+
+// epsilonGreedy splits a fraction epsilon of the traffic evenly and allocates the rest to the version with the best reward
+func epsilonGreedy(samples []Sample, opts BanditOptions) map[string]Allocation {
+	scores := make([]float64, len(samples))
+	for i, s := range samples {
+		scores[i] = s.Value
+	}
+	return greedy(samples, scores, opts.Epsilon, opts.HigherIsBetter)
+}
+
+// greedy splits a fraction explore of the traffic evenly and allocates the rest to the version with the best score
+// Ties are resolved in favor of the earlier version.
+func greedy(samples []Sample, scores []float64, explore float64, higherIsBetter bool) map[string]Allocation {
+	best := 0
+	for i := range scores {
+		if better(scores[i], scores[best], higherIsBetter) {
+			best = i
+		}
+	}
+	allocations := map[string]Allocation{}
+	for i, s := range samples {
+		fraction := explore / float64(len(samples))
+		if i == best {
+			fraction += 1 - explore
+		}
+		allocations[s.Version] = Allocation{Score: scores[i], Fraction: fraction}
+	}
+	return allocations
+}
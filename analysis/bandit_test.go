@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func fractionSum(allocations map[string]Allocation) float64 {
+	sum := 0.0
+	for _, a := range allocations {
+		sum += a.Fraction
+	}
+	return sum
+}
+
+func TestThompsonSampling(t *testing.T) {
+	samples := []Sample{
+		{Version: "v1", Value: 0.10, Size: 200},
+		{Version: "v2", Value: 0.12, Size: 200},
+	}
+	allocations, err := Allocate(samples, BanditOptions{Algorithm: v2alpha2.BanditAlgorithmThompsonSampling, Model: v2alpha2.RewardModelBeta, HigherIsBetter: true})
+	assert.NoError(t, err)
+	assert.InDelta(t, 1, fractionSum(allocations), 1e-9)
+	// v2 is probably, but not certainly, better
+	assert.Greater(t, allocations["v2"].Fraction, 0.5)
+	assert.Greater(t, allocations["v1"].Fraction, 0.05)
+	assert.Equal(t, allocations["v2"].Fraction, allocations["v2"].Score)
+}
+
+func TestUCB(t *testing.T) {
+	// v2 has the better mean, but v1 has few observations and its bound is more optimistic
+	samples := []Sample{
+		{Version: "v1", Value: 100, StdDev: 20, Size: 2},
+		{Version: "v2", Value: 90, StdDev: 20, Size: 1000},
+	}
+	allocations, err := Allocate(samples, BanditOptions{Algorithm: v2alpha2.BanditAlgorithmUCB, Model: v2alpha2.RewardModelNormal, HigherIsBetter: false})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, allocations["v1"].Fraction)
+	assert.Equal(t, 0.0, allocations["v2"].Fraction)
+	assert.Less(t, allocations["v1"].Score, allocations["v2"].Score)
+
+	samples[0].Size = 1000
+	allocations, err = Allocate(samples, BanditOptions{Algorithm: v2alpha2.BanditAlgorithmUCB, Model: v2alpha2.RewardModelNormal, HigherIsBetter: false})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, allocations["v2"].Fraction)
+}
+
+func TestEpsilonGreedy(t *testing.T) {
+	samples := []Sample{
+		{Version: "v1", Value: 0.10, Size: 200},
+		{Version: "v2", Value: 0.12, Size: 200},
+		{Version: "v3", Value: 0.11, Size: 200},
+	}
+	allocations, err := Allocate(samples, BanditOptions{Algorithm: v2alpha2.BanditAlgorithmEpsilonGreedy, Model: v2alpha2.RewardModelBeta, HigherIsBetter: true, Epsilon: 0.3})
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.1, allocations["v1"].Fraction, 1e-9)
+	assert.InDelta(t, 0.8, allocations["v2"].Fraction, 1e-9)
+	assert.InDelta(t, 0.1, allocations["v3"].Fraction, 1e-9)
+	assert.Equal(t, 0.12, allocations["v2"].Score)
+
+	_, err = Allocate(samples, BanditOptions{Algorithm: v2alpha2.BanditAlgorithmEpsilonGreedy, Model: v2alpha2.RewardModelBeta, Epsilon: 2})
+	assert.Error(t, err)
+}
+
+func TestAllocateInvalid(t *testing.T) {
+	_, err := Allocate(nil, BanditOptions{Algorithm: v2alpha2.BanditAlgorithmUCB, Model: v2alpha2.RewardModelBeta})
+	assert.Error(t, err)
+	_, err = Allocate([]Sample{{Version: "v1", Value: 0.1}}, BanditOptions{Algorithm: v2alpha2.BanditAlgorithmUCB, Model: v2alpha2.RewardModelBeta})
+	assert.Error(t, err)
+	_, err = Allocate([]Sample{{Version: "v1", Value: 0.1, Size: 1}}, BanditOptions{Algorithm: "Other", Model: v2alpha2.RewardModelBeta})
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"math"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"golang.org/x/exp/rand"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// draws is the number of draws from the posteriors used to estimate the probability of being best
+const draws = 10000
+
+// posterior is the posterior distribution of the reward of a version
+type posterior interface {
+	Rand() float64
+	Quantile(p float64) float64
+}
+
+// posteriorOf returns the posterior of the reward of a version
+// The Beta model uses a uniform prior on the rate; the Normal model uses a flat prior on the mean.
+func posteriorOf(s Sample, model v2alpha2.RewardModelType, src rand.Source) posterior {
+	if model == v2alpha2.RewardModelBeta {
+		successes := s.Value * float64(s.Size)
+		return distuv.Beta{Alpha: 1 + successes, Beta: 1 + float64(s.Size) - successes, Src: src}
+	}
+	return distuv.Normal{Mu: s.Value, Sigma: math.Max(s.StdDev/math.Sqrt(float64(s.Size)), math.SmallestNonzeroFloat64), Src: src}
+}
+
+// bayesian estimates the probability that each version is the best version by sampling from the posteriors
+// The intervals are equal-tailed credible intervals.
+func bayesian(samples []Sample, opts Options) *Result {
+	src := rand.NewSource(opts.Seed)
+	posteriors := make([]posterior, len(samples))
+	for i, s := range samples {
+		posteriors[i] = posteriorOf(s, opts.Model, src)
+	}
+
+	wins := make([]int, len(samples))
+	values := make([]float64, len(samples))
+	for d := 0; d < draws; d++ {
+		best := 0
+		for i, p := range posteriors {
+			values[i] = p.Rand()
+			if better(values[i], values[best], opts.HigherIsBetter) {
+				best = i
+			}
+		}
+		wins[best]++
+	}
+
+	result := &Result{Assessments: map[string]Assessment{}}
+	tail := (1 - opts.Confidence) / 2
+	for i, s := range samples {
+		result.Assessments[s.Version] = Assessment{
+			Confidence: float64(wins[i]) / draws,
+			Lower:      posteriors[i].Quantile(tail),
+			Upper:      posteriors[i].Quantile(1 - tail),
+		}
+	}
+	return result
+}
+
+// better returns true if the reward a is better than the reward b
+func better(a float64, b float64, higherIsBetter bool) bool {
+	if higherIsBetter {
+		return a > b
+	}
+	return a < b
+}
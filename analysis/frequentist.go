@@ -0,0 +1,109 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"math"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// standardError returns the standard error of the reward of a version and the degrees of freedom of its estimate
+// The degrees of freedom are infinite for the Beta model, whose tests are z-tests.
+func standardError(s Sample, model v2alpha2.RewardModelType) (float64, float64) {
+	n := float64(s.Size)
+	if model == v2alpha2.RewardModelBeta {
+		return math.Sqrt(s.Value * (1 - s.Value) / n), math.Inf(1)
+	}
+	return s.StdDev / math.Sqrt(n), math.Max(n-1, 1)
+}
+
+// pValue returns the one-sided p-value of the test that version a is better than version b
+// The Normal model uses Welch's t-test; the Beta model uses a z-test of proportions.
+func pValue(a Sample, b Sample, opts Options) float64 {
+	seA, dfA := standardError(a, opts.Model)
+	seB, dfB := standardError(b, opts.Model)
+	diff := a.Value - b.Value
+	if !opts.HigherIsBetter {
+		diff = -diff
+	}
+	se := math.Sqrt(seA*seA + seB*seB)
+	if se == 0 {
+		switch {
+		case diff > 0:
+			return 0
+		case diff < 0:
+			return 1
+		}
+		return 0.5
+	}
+	df := math.Inf(1)
+	if opts.Model == v2alpha2.RewardModelNormal {
+		// Welch–Satterthwaite approximation
+		va, vb := seA*seA, seB*seB
+		df = (va + vb) * (va + vb) / (va*va/dfA + vb*vb/dfB)
+	}
+	return 1 - tDistribution(df).CDF(diff/se)
+}
+
+// tDistribution returns the standardized t distribution with df degrees of freedom, or the standard normal if df is infinite
+func tDistribution(df float64) interface {
+	CDF(x float64) float64
+	Quantile(p float64) float64
+} {
+	if math.IsInf(df, 1) {
+		return distuv.UnitNormal
+	}
+	return distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}
+}
+
+// frequentist tests whether each version is better than every other version
+// The p-values are Bonferroni corrected for the comparisons with the other versions and for the planned
+// repetitions of the analysis, so that looking at the result in every iteration does not inflate the error rate.
+// The intervals are confidence intervals.
+func frequentist(samples []Sample, opts Options) *Result {
+	looks := opts.Looks
+	if looks < 1 {
+		looks = 1
+	}
+	comparisons := len(samples) - 1
+	if comparisons < 1 {
+		comparisons = 1
+	}
+	correction := float64(looks * comparisons)
+
+	result := &Result{Assessments: map[string]Assessment{}}
+	for i, a := range samples {
+		worst := 0.0
+		for j, b := range samples {
+			if i != j {
+				worst = math.Max(worst, pValue(a, b, opts))
+			}
+		}
+		confidence := 1.0
+		if len(samples) > 1 {
+			confidence = math.Max(1-worst*correction, 0)
+		}
+		se, df := standardError(a, opts.Model)
+		margin := tDistribution(df).Quantile(1-(1-opts.Confidence)/2) * se
+		result.Assessments[a.Version] = Assessment{
+			Confidence: confidence,
+			Lower:      a.Value - margin,
+			Upper:      a.Value + margin,
+		}
+	}
+	return result
+}
@@ -17,7 +17,7 @@ limitations under the License.
 package v2alpha2
 
 // TestingPatternType identifies the type of experiment type
-// +kubebuilder:validation:Enum=Canary;A/B;A/B/N;Conformance
+// +kubebuilder:validation:Enum=Canary;A/B;A/B/N;Conformance;Shadow
 type TestingPatternType string
 
 const (
@@ -32,6 +32,10 @@ const (
 
 	// TestingPatternConformance indicates an experiment is a conformance experiment
 	TestingPatternConformance TestingPatternType = "Conformance"
+
+	// TestingPatternShadow indicates an experiment is a shadow experiment
+	// The candidate receives a mirror of the traffic of the baseline; its responses are not returned to users.
+	TestingPatternShadow TestingPatternType = "Shadow"
 )
 
 // ValidTestingPatternTypes are legal strategy types iter8 is aware of
@@ -41,8 +45,21 @@ var ValidTestingPatternTypes []TestingPatternType = []TestingPatternType{
 	TestingPatternAB,
 	TestingPatternABN,
 	TestingPatternConformance,
+	TestingPatternShadow,
 }
 
+// MetricSourceType identifies the kind of resource a metric was read from
+// +kubebuilder:validation:Enum=Metric;ClusterMetric
+type MetricSourceType string
+
+const (
+	// MetricSourceMetric indicates the metric was read from a (namespaced) Metric
+	MetricSourceMetric MetricSourceType = "Metric"
+
+	// MetricSourceClusterMetric indicates the metric was read from a (cluster-scoped) ClusterMetric
+	MetricSourceClusterMetric MetricSourceType = "ClusterMetric"
+)
+
 // DeploymentPatternType identifies the deployment patterns that can be used
 // +kubebuilder:validation:Enum=FixedSplit;Progressive;BlueGreen
 type DeploymentPatternType string
@@ -70,8 +87,86 @@ const (
 	PreferredDirectionLower PreferredDirectionType = "Low"
 )
 
+// AnalysisMethodType defines the valid values for criteria.strength.method
+// +kubebuilder:validation:Enum=Bayesian;Frequentist
+type AnalysisMethodType string
+
+const (
+	// AnalysisMethodBayesian computes the posterior probability that each version is the best version
+	AnalysisMethodBayesian AnalysisMethodType = "Bayesian"
+
+	// AnalysisMethodFrequentist tests whether each version is better than every other version
+	// The tests are corrected for being repeated in every iteration of the experiment.
+	AnalysisMethodFrequentist AnalysisMethodType = "Frequentist"
+)
+
+// RewardNormalizationType defines the valid values for criteria.rewardNormalization
+// +kubebuilder:validation:Enum=MinMax;Baseline
+type RewardNormalizationType string
+
+const (
+	// RewardNormalizationMinMax scales the values of a reward so that the worst version has 0 and the best version has 1
+	RewardNormalizationMinMax RewardNormalizationType = "MinMax"
+
+	// RewardNormalizationBaseline replaces the values of a reward by their relative improvement over the baseline
+	RewardNormalizationBaseline RewardNormalizationType = "Baseline"
+)
+
+// RelativeLimitType defines the valid values for objectives[].relative.type
+// +kubebuilder:validation:Enum=Percent;Delta
+type RelativeLimitType string
+
+const (
+	// RelativeLimitPercent limits are percentages of the value of the metric for the baseline
+	RelativeLimitPercent RelativeLimitType = "Percent"
+
+	// RelativeLimitDelta limits are differences from the value of the metric for the baseline
+	RelativeLimitDelta RelativeLimitType = "Delta"
+)
+
+// RewardModelType defines the valid values for criteria.strength.model and spec.strategy.weights.banditModel
+// +kubebuilder:validation:Enum=Beta;Normal
+type RewardModelType string
+
+const (
+	// RewardModelBeta models a reward that is a rate between 0 and 1, such as a conversion rate;
+	// its sample size is the number of trials
+	RewardModelBeta RewardModelType = "Beta"
+
+	// RewardModelNormal models a reward that is the mean of its observations;
+	// its sample size and standard deviation describe the observations
+	RewardModelNormal RewardModelType = "Normal"
+)
+
+// BanditAlgorithmType defines the valid values for spec.strategy.weights.bandit
+// +kubebuilder:validation:Enum=ThompsonSampling;UCB;EpsilonGreedy
+type BanditAlgorithmType string
+
+const (
+	// BanditAlgorithmThompsonSampling sends each version the fraction of traffic equal to the probability that it is the best version
+	BanditAlgorithmThompsonSampling BanditAlgorithmType = "ThompsonSampling"
+
+	// BanditAlgorithmUCB sends traffic to the version with the best upper confidence bound of its reward
+	BanditAlgorithmUCB BanditAlgorithmType = "UCB"
+
+	// BanditAlgorithmEpsilonGreedy sends traffic to the version with the best reward, except for a fraction epsilon that is split evenly
+	BanditAlgorithmEpsilonGreedy BanditAlgorithmType = "EpsilonGreedy"
+)
+
+// MetricsRefreshPolicyType defines the valid values for criteria.metricsRefreshPolicy
+// +kubebuilder:validation:Enum=Never;OnChange
+type MetricsRefreshPolicyType string
+
+const (
+	// MetricsRefreshPolicyNever indicates that metrics are read once, when the experiment starts
+	MetricsRefreshPolicyNever MetricsRefreshPolicyType = "Never"
+
+	// MetricsRefreshPolicyOnChange indicates that metrics are read again when a metric used by the experiment changes
+	MetricsRefreshPolicyOnChange MetricsRefreshPolicyType = "OnChange"
+)
+
 // ExperimentConditionType limits conditions can be set by controller
-// +kubebuilder:validation:Enum:=Completed;Failed;TargetAcquired
+// +kubebuilder:validation:Enum:=Completed;Failed;TargetAcquired;WeightsApplied
 type ExperimentConditionType string
 
 const (
@@ -86,6 +181,10 @@ const (
 	// ExperimentConditionTargetAcquired has status True when an experiment has a lock on the target
 	// False until can lock the target
 	ExperimentConditionTargetAcquired ExperimentConditionType = "TargetAcquired"
+
+	// ExperimentConditionWeightsApplied has status True when the recommended weights have been applied
+	// False when the recommended weights could not be applied
+	ExperimentConditionWeightsApplied ExperimentConditionType = "WeightsApplied"
 )
 
 // A set of reason setting the experiment condition status
@@ -97,15 +196,130 @@ const (
 	ReasonAnalyticsServiceError      = "AnalyticsServiceError"
 	ReasonMetricUnavailable          = "MetricUnavailable"
 	ReasonMetricsUnreadable          = "MetricsUnreadable"
+	ReasonMetricInvalid              = "MetricInvalid"
+	ReasonMetricsRefreshed           = "MetricsRefreshed"
 	ReasonHandlerLaunched            = "HandlerLaunched"
 	ReasonHandlerCompleted           = "HandlerCompleted"
 	ReasonHandlerFailed              = "HandlerFailed"
+	ReasonHandlerTimedOut            = "HandlerTimedOut"
+	ReasonHandlerRetried             = "HandlerRetried"
 	ReasonLaunchHandlerFailed        = "LaunchHandlerFailed"
 	ReasonWeightRedistributionFailed = "WeightRedistributionFailed"
+	ReasonWeightsApplied             = "WeightsApplied"
+	ReasonWeightsNotApplied          = "WeightsNotApplied"
+	ReasonWeightsDrifted             = "WeightsDrifted"
+	ReasonWeightsReapplied           = "WeightsReapplied"
+	ReasonWeightStepAdvanced         = "WeightStepAdvanced"
+	ReasonCutover                    = "Cutover"
+	ReasonTrafficReverted            = "TrafficReverted"
+	ReasonGuardrailViolated          = "GuardrailViolated"
+	ReasonDryRun                     = "DryRun"
 	ReasonInvalidExperiment          = "InvalidExperiment"
 	ReasonStageAdvanced              = "StageAdvanced"
 )
 
+// HandlerAttemptResultType identifies the outcome of an attempt to execute an action
+// +kubebuilder:validation:Enum:=Running;Complete;Failed;TimedOut
+type HandlerAttemptResultType string
+
+const (
+	// HandlerAttemptRunning indicates the attempt has been launched and has not yet finished
+	HandlerAttemptRunning HandlerAttemptResultType = "Running"
+
+	// HandlerAttemptComplete indicates the attempt completed successfully
+	HandlerAttemptComplete HandlerAttemptResultType = "Complete"
+
+	// HandlerAttemptFailed indicates the attempt failed
+	HandlerAttemptFailed HandlerAttemptResultType = "Failed"
+
+	// HandlerAttemptTimedOut indicates the attempt was terminated because it exceeded its timeout
+	HandlerAttemptTimedOut HandlerAttemptResultType = "TimedOut"
+)
+
+// WeightBackendType identifies a kind of traffic routing object whose weights iter8 can manage without fieldpaths
+// +kubebuilder:validation:Enum:=HTTPRoute;TrafficSplit;VirtualService;Linkerd
+type WeightBackendType string
+
+const (
+	// WeightBackendHTTPRoute indicates weights are set on the backendRefs of a Gateway API HTTPRoute
+	WeightBackendHTTPRoute WeightBackendType = "HTTPRoute"
+
+	// WeightBackendTrafficSplit indicates weights are set on the backends of an SMI TrafficSplit
+	WeightBackendTrafficSplit WeightBackendType = "TrafficSplit"
+
+	// WeightBackendVirtualService indicates weights are set on the http routes of an Istio VirtualService
+	WeightBackendVirtualService WeightBackendType = "VirtualService"
+
+	// WeightBackendLinkerd indicates weights are set on the backendRefs of a Linkerd (policy.linkerd.io) HTTPRoute
+	WeightBackendLinkerd WeightBackendType = "Linkerd"
+)
+
+// HeaderMatchType identifies how the value of a header is compared
+// +kubebuilder:validation:Enum:=Exact;RegularExpression
+type HeaderMatchType string
+
+const (
+	// HeaderMatchExact indicates the header must have the value
+	HeaderMatchExact HeaderMatchType = "Exact"
+
+	// HeaderMatchRegularExpression indicates the header must match the value, a regular expression
+	HeaderMatchRegularExpression HeaderMatchType = "RegularExpression"
+)
+
+// WeightValueType identifies the type of the value of a weight field
+// +kubebuilder:validation:Enum:=Integer;Number;String
+type WeightValueType string
+
+const (
+	// WeightValueInteger indicates the weight is an integer
+	WeightValueInteger WeightValueType = "Integer"
+
+	// WeightValueNumber indicates the weight is a (possibly fractional) number
+	WeightValueNumber WeightValueType = "Number"
+
+	// WeightValueString indicates the weight is a decimal number encoded as a string
+	WeightValueString WeightValueType = "String"
+)
+
+// RampType identifies how the steps of a weight schedule are generated
+// +kubebuilder:validation:Enum:=Linear;Exponential
+type RampType string
+
+const (
+	// RampLinear indicates each step is a fixed amount larger than the previous step
+	RampLinear RampType = "Linear"
+
+	// RampExponential indicates each step is a fixed multiple of the previous step
+	RampExponential RampType = "Exponential"
+)
+
+// DriftPolicyType identifies what the controller does when the weights of versions are changed by someone else
+// +kubebuilder:validation:Enum:=Reapply;Pause;Fail
+type DriftPolicyType string
+
+const (
+	// DriftPolicyReapply indicates the last applied weights are restored
+	DriftPolicyReapply DriftPolicyType = "Reapply"
+
+	// DriftPolicyPause indicates the experiment does not iterate until the last applied weights are restored
+	DriftPolicyPause DriftPolicyType = "Pause"
+
+	// DriftPolicyFail indicates the experiment fails
+	DriftPolicyFail DriftPolicyType = "Fail"
+)
+
+// ExecutorType identifies how the tasks of an action are executed
+// +kubebuilder:validation:Enum:=Job;InProcess
+type ExecutorType string
+
+const (
+	// ExecutorJob indicates the task is executed by the task runner in a handler job
+	ExecutorJob ExecutorType = "Job"
+
+	// ExecutorInProcess indicates the task is executed by the controller itself
+	ExecutorInProcess ExecutorType = "InProcess"
+)
+
 // ExperimentStageType identifies valid stages of an experiment
 // +kubebuilder:validation:Enum:=Waiting;Initializing;Running;Finishing;Completed
 type ExperimentStageType string
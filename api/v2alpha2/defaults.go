@@ -18,6 +18,9 @@ limitations under the License.
 package v2alpha2
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -37,6 +40,30 @@ const (
 	// DefaultLoopHandler is the prefix of the default loop handler
 	DefaultLoopHandler string = "loop"
 
+	// DefaultActionRetries is the default number of times a failed action is retried, 0
+	DefaultActionRetries int32 = 0
+
+	// DefaultExecutor is the default executor of a task, Job
+	DefaultExecutor ExecutorType = ExecutorJob
+
+	// DefaultWeightValueType is the default type of the value of a weight field, Integer
+	DefaultWeightValueType WeightValueType = WeightValueInteger
+
+	// DefaultWeightScale is the default factor by which a weight is multiplied to give the value of its field, 1
+	DefaultWeightScale float64 = 1
+
+	// DefaultDwellIterations is the default number of iterations at each step of a weight schedule, 1
+	DefaultDwellIterations int32 = 1
+
+	// DefaultRampIncrement is the default difference between steps of a Linear ramp, 10
+	DefaultRampIncrement int32 = 10
+
+	// DefaultRampFactor is the default ratio between steps of an Exponential ramp, 2
+	DefaultRampFactor int32 = 2
+
+	// DefaultDriftPolicy is the default response to changes to version weights, Reapply
+	DefaultDriftPolicy DriftPolicyType = DriftPolicyReapply
+
 	// DefaultMaxCandidateWeight is the default traffic percentage used in experiment, which is 100
 	DefaultMaxCandidateWeight int32 = 100
 
@@ -56,11 +83,20 @@ const (
 	// DefaultMaxLoops is the default maximum number of loops, 1
 	// reserved for future use
 	DefaultMaxLoops int32 = 1
+
+	// DefaultGuardrailIntervalSeconds is the default time between checks of the guardrail objectives, 5
+	DefaultGuardrailIntervalSeconds int32 = 5
 )
 
 // DefaultBlueGreenSplit is the default split to be used for bluegreen experiment
 var DefaultBlueGreenSplit = []int32{0, 100}
 
+// DefaultRollbackWindowSeconds is the default time a BlueGreen experiment continues after cutover, 0
+const DefaultRollbackWindowSeconds int32 = 0
+
+// DefaultMirrorPercent is the default percentage of requests mirrored to the candidate of a Shadow experiment, 100
+const DefaultMirrorPercent int32 = 100
+
 // GetNumberOfCandidates returns the number of candidates in VersionInfo
 func (s *ExperimentSpec) GetNumberOfCandidates() int {
 	if s.VersionInfo == nil {
@@ -125,10 +161,202 @@ func (s *ExperimentSpec) GetLoopHandler() *string {
 	return &handler
 }
 
+//////////////////////////////////////////////////////////////////////
+// spec.strategy.actionPolicies
+//////////////////////////////////////////////////////////////////////
+
+// GetActionTimeoutSeconds returns the timeout for a single attempt to execute an action, if set
+// Otherwise, it returns nil (no timeout)
+func (s *ExperimentSpec) GetActionTimeoutSeconds(action string) *int32 {
+	policy, ok := s.Strategy.ActionPolicies[action]
+	if !ok {
+		return nil
+	}
+	return policy.TimeoutSeconds
+}
+
+// GetActionTimeoutAsDuration returns the timeout for a single attempt to execute an action as a time.Duration
+// The second return value is false if there is no timeout
+func (s *ExperimentSpec) GetActionTimeoutAsDuration(action string) (time.Duration, bool) {
+	timeout := s.GetActionTimeoutSeconds(action)
+	if timeout == nil {
+		return 0, false
+	}
+	return time.Second * time.Duration(*timeout), true
+}
+
+// GetActionRetries returns the number of times a failed attempt to execute an action should be retried
+// Otherwise it returns DefaultActionRetries (0)
+func (s *ExperimentSpec) GetActionRetries(action string) int32 {
+	policy, ok := s.Strategy.ActionPolicies[action]
+	if !ok || policy.Retries == nil {
+		return DefaultActionRetries
+	}
+	return *policy.Retries
+}
+
+// GetExecutor returns the executor requested by a task
+// Otherwise it returns DefaultExecutor (Job)
+func (t *TaskSpec) GetExecutor() ExecutorType {
+	if t.Executor == nil {
+		return DefaultExecutor
+	}
+	return *t.Executor
+}
+
+// GetActionExecutor returns ExecutorInProcess if every task in an action requests it
+// Otherwise it returns ExecutorJob
+func (s *ExperimentSpec) GetActionExecutor(action string) ExecutorType {
+	tasks, ok := s.Strategy.Actions[action]
+	if !ok || len(tasks) == 0 {
+		return ExecutorJob
+	}
+	for i := range tasks {
+		if tasks[i].GetExecutor() != ExecutorInProcess {
+			return ExecutorJob
+		}
+	}
+	return ExecutorInProcess
+}
+
+//////////////////////////////////////////////////////////////////////
+// spec.strategy.blueGreen
+//////////////////////////////////////////////////////////////////////
+
+// GetRollbackWindow returns spec.strategy.blueGreen.rollbackWindowSeconds as a time.Duration if set
+// Otherwise it returns DefaultRollbackWindowSeconds (0)
+func (s *ExperimentSpec) GetRollbackWindow() time.Duration {
+	seconds := DefaultRollbackWindowSeconds
+	if s.Strategy.BlueGreen != nil && s.Strategy.BlueGreen.RollbackWindowSeconds != nil {
+		seconds = *s.Strategy.BlueGreen.RollbackWindowSeconds
+	}
+	return time.Second * time.Duration(seconds)
+}
+
+//////////////////////////////////////////////////////////////////////
+// spec.strategy.dryRun
+//////////////////////////////////////////////////////////////////////
+
+// GetDryRun returns spec.strategy.dryRun if set
+// Otherwise it returns false
+func (s *ExperimentSpec) GetDryRun() bool {
+	if s.Strategy.DryRun == nil {
+		return false
+	}
+	return *s.Strategy.DryRun
+}
+
+//////////////////////////////////////////////////////////////////////
+// spec.strategy.mirroring
+//////////////////////////////////////////////////////////////////////
+
+// GetMirrorPercent returns spec.strategy.mirroring.percent if set
+// Otherwise it returns DefaultMirrorPercent (100)
+func (s *ExperimentSpec) GetMirrorPercent() int32 {
+	if s.Strategy.Mirroring == nil || s.Strategy.Mirroring.Percent == nil {
+		return DefaultMirrorPercent
+	}
+	return *s.Strategy.Mirroring.Percent
+}
+
 //////////////////////////////////////////////////////////////////////
 // spec.strategy.weights
 //////////////////////////////////////////////////////////////////////
 
+// GetWeightBackend returns spec.strategy.weights.backend if set
+// Otherwise it returns nil; weights are set using the fieldPath of each weightObjRef
+func (s *ExperimentSpec) GetWeightBackend() *WeightBackendType {
+	if s.Strategy.Weights == nil {
+		return nil
+	}
+	return s.Strategy.Weights.Backend
+}
+
+// GetDriftPolicy returns spec.strategy.weights.driftPolicy if set
+// Otherwise it returns DefaultDriftPolicy (Reapply)
+func (s *ExperimentSpec) GetDriftPolicy() DriftPolicyType {
+	if s.Strategy.Weights == nil || s.Strategy.Weights.DriftPolicy == nil {
+		return DefaultDriftPolicy
+	}
+	return *s.Strategy.Weights.DriftPolicy
+}
+
+// GetWeightSteps returns the steps of spec.strategy.weights.schedule
+// The steps are those listed or, if none are listed, those generated by the ramp.
+// It returns nil if there is no schedule.
+func (s *ExperimentSpec) GetWeightSteps() []int32 {
+	if s.Strategy.Weights == nil || s.Strategy.Weights.Schedule == nil {
+		return nil
+	}
+	schedule := s.Strategy.Weights.Schedule
+	if len(schedule.Steps) > 0 || schedule.Ramp == nil {
+		return schedule.Steps
+	}
+	return schedule.Ramp.steps()
+}
+
+// GetStart returns the first step of the ramp if set
+// Otherwise it returns the increment for a Linear ramp and 1 for an Exponential ramp
+func (r *WeightRamp) GetStart() int32 {
+	if r.Start != nil {
+		return *r.Start
+	}
+	if r.Type == RampExponential {
+		return 1
+	}
+	return r.GetIncrement()
+}
+
+// GetIncrement returns the increment of a Linear ramp if set
+// Otherwise it returns DefaultRampIncrement (10)
+func (r *WeightRamp) GetIncrement() int32 {
+	if r.Increment == nil {
+		return DefaultRampIncrement
+	}
+	return *r.Increment
+}
+
+// GetFactor returns the factor of an Exponential ramp if set
+// Otherwise it returns DefaultRampFactor (2)
+func (r *WeightRamp) GetFactor() int32 {
+	if r.Factor == nil {
+		return DefaultRampFactor
+	}
+	return *r.Factor
+}
+
+// steps generates the steps of a ramp; the last step is 100
+// It returns nil if the ramp is invalid; that is, if its steps do not increase.
+func (r *WeightRamp) steps() []int32 {
+	start := int64(r.GetStart())
+	next := func(w int64) int64 { return w + int64(r.GetIncrement()) }
+	if r.Type == RampExponential {
+		next = func(w int64) int64 { return w * int64(r.GetFactor()) }
+	}
+	if start < 1 || next(start) <= start {
+		return nil
+	}
+
+	// weights are computed in 64 bits and are less than 100, so next cannot overflow
+	steps := []int32{}
+	for w := start; w < 100; w = next(w) {
+		steps = append(steps, int32(w))
+		if next(w) <= w {
+			return nil
+		}
+	}
+	return append(steps, 100)
+}
+
+// GetDwellIterations returns spec.strategy.weights.schedule.dwellIterations if set
+// Otherwise it returns DefaultDwellIterations (1)
+func (s *ExperimentSpec) GetDwellIterations() int32 {
+	if s.Strategy.Weights == nil || s.Strategy.Weights.Schedule == nil || s.Strategy.Weights.Schedule.DwellIterations == nil {
+		return DefaultDwellIterations
+	}
+	return *s.Strategy.Weights.Schedule.DwellIterations
+}
+
 // GetMaxCandidateWeight return spec.strategy.weights.maxCandidateWeight if set
 // Otherwise it returns DefaultMaxCandidateWeight (100)
 func (s *ExperimentSpec) GetMaxCandidateWeight() int32 {
@@ -169,6 +397,56 @@ func (s *ExperimentSpec) InitializeMaxCandidateWeightIncrement() {
 	}
 }
 
+// DefaultRewardWeight is the default weight of a reward in the composite score
+const DefaultRewardWeight = 1.0
+
+// GetWeight returns the weight of the reward if set
+// Otherwise it returns DefaultRewardWeight
+func (r *Reward) GetWeight() float64 {
+	if r.Weight == nil {
+		return DefaultRewardWeight
+	}
+	return r.Weight.AsApproximateFloat64()
+}
+
+// GetRewardNormalization returns spec.criteria.rewardNormalization if set
+// Otherwise it returns RewardNormalizationMinMax
+func (s *ExperimentSpec) GetRewardNormalization() RewardNormalizationType {
+	if s.Criteria == nil || s.Criteria.RewardNormalization == nil {
+		return RewardNormalizationMinMax
+	}
+	return *s.Criteria.RewardNormalization
+}
+
+// DefaultEpsilon is the default fraction of traffic split evenly by the EpsilonGreedy bandit
+const DefaultEpsilon = 0.1
+
+// GetBandit returns spec.strategy.weights.bandit if set
+func (s *ExperimentSpec) GetBandit() *BanditAlgorithmType {
+	if s.Strategy.Weights == nil {
+		return nil
+	}
+	return s.Strategy.Weights.Bandit
+}
+
+// GetBanditModel returns spec.strategy.weights.banditModel if set
+// Otherwise it returns RewardModelBeta
+func (s *ExperimentSpec) GetBanditModel() RewardModelType {
+	if s.Strategy.Weights == nil || s.Strategy.Weights.BanditModel == nil {
+		return RewardModelBeta
+	}
+	return *s.Strategy.Weights.BanditModel
+}
+
+// GetEpsilon returns spec.strategy.weights.epsilon if set
+// Otherwise it returns DefaultEpsilon
+func (s *ExperimentSpec) GetEpsilon() float64 {
+	if s.Strategy.Weights == nil || s.Strategy.Weights.Epsilon == nil {
+		return DefaultEpsilon
+	}
+	return s.Strategy.Weights.Epsilon.AsApproximateFloat64()
+}
+
 // GetDeploymentPattern returns spec.strategy.deploymentPattern if set
 func (s *ExperimentSpec) GetDeploymentPattern() DeploymentPatternType {
 	if s.Strategy.DeploymentPattern == nil {
@@ -213,6 +491,19 @@ func (s *ExperimentSpec) GetIntervalAsDuration() time.Duration {
 	return time.Second * time.Duration(s.GetIntervalSeconds())
 }
 
+// GetGuardrailIntervalSeconds returns specified(or default) guardrail interval in seconds
+func (s *ExperimentSpec) GetGuardrailIntervalSeconds() int32 {
+	if s.Duration == nil || s.Duration.GuardrailIntervalSeconds == nil {
+		return DefaultGuardrailIntervalSeconds
+	}
+	return *s.Duration.GuardrailIntervalSeconds
+}
+
+// GetGuardrailIntervalAsDuration returns spec.duration.guardrailIntervalSeconds as a time.Duration (in ns)
+func (s *ExperimentSpec) GetGuardrailIntervalAsDuration() time.Duration {
+	return time.Second * time.Duration(s.GetGuardrailIntervalSeconds())
+}
+
 // InitializeInterval sets duration.interval if not already set using the default value
 func (s *ExperimentSpec) InitializeInterval() {
 	if s.Duration == nil {
@@ -288,6 +579,57 @@ func (s *ExperimentSpec) GetRequestCount() *string {
 	return s.Criteria.RequestCount
 }
 
+// GetMetricsRefreshPolicy returns spec.criteria.metricsRefreshPolicy if set
+// Otherwise it returns MetricsRefreshPolicyNever
+func (s *ExperimentSpec) GetMetricsRefreshPolicy() MetricsRefreshPolicyType {
+	if s.Criteria == nil || s.Criteria.MetricsRefreshPolicy == nil {
+		return MetricsRefreshPolicyNever
+	}
+	return *s.Criteria.MetricsRefreshPolicy
+}
+
+// DefaultConfidence is the default confidence required to declare a winner
+const DefaultConfidence = 0.95
+
+// DefaultMinSampleSize is the default sample size required of every version to declare a winner
+const DefaultMinSampleSize int32 = 10
+
+// GetMethod returns the method of analysis if set
+// Otherwise it returns AnalysisMethodBayesian
+func (s *Strength) GetMethod() AnalysisMethodType {
+	if s.Method == nil {
+		return AnalysisMethodBayesian
+	}
+	return *s.Method
+}
+
+// GetModel returns the model of the reward metric if set
+// Otherwise it returns RewardModelBeta
+func (s *Strength) GetModel() RewardModelType {
+	if s.Model == nil {
+		return RewardModelBeta
+	}
+	return *s.Model
+}
+
+// GetConfidence returns the required confidence if set
+// Otherwise it returns DefaultConfidence
+func (s *Strength) GetConfidence() float64 {
+	if s.Confidence == nil {
+		return DefaultConfidence
+	}
+	return s.Confidence.AsApproximateFloat64()
+}
+
+// GetMinSampleSize returns the required sample size if set
+// Otherwise it returns DefaultMinSampleSize
+func (s *Strength) GetMinSampleSize() int32 {
+	if s.MinSampleSize == nil {
+		return DefaultMinSampleSize
+	}
+	return *s.MinSampleSize
+}
+
 // InitializeRequestCount sets the request count metric to the default value if not already set
 func (s *ExperimentSpec) InitializeRequestCount() {
 	if s.Criteria == nil {
@@ -302,8 +644,26 @@ func (s *ExperimentSpec) InitializeRequestCount() {
 // objective
 //////////////////////////////////////////////////////////////////////
 
+// GetType returns the type of the relative limits if set
+// Otherwise it returns RelativeLimitPercent
+func (r *RelativeLimits) GetType() RelativeLimitType {
+	if r.Type == nil {
+		return RelativeLimitPercent
+	}
+	return *r.Type
+}
+
+// IsGuardrail returns true if the objective is a guardrail
+func (o *Objective) IsGuardrail() bool {
+	return o.Guardrail != nil && *o.Guardrail
+}
+
 // GetRollbackOnFailure identifies if the experiment should be rolledback on failure of an objective
+// A guardrail is always rolled back on failure.
 func (o *Objective) GetRollbackOnFailure(deploymentPattern DeploymentPatternType) bool {
+	if o.IsGuardrail() {
+		return true
+	}
 	if o.RollbackOnFailure == nil {
 		return deploymentPattern == DeploymentPatternBlueGreen
 	}
@@ -339,3 +699,213 @@ func (s *ExperimentSpec) InitializeSpec() {
 	s.InitializeDuration()
 	s.InitializeCriteria()
 }
+
+//////////////////////////////////////////////////////////////////////
+// spec.versionInfo
+//////////////////////////////////////////////////////////////////////
+
+// GetBackend returns the backend of a version if set
+// Otherwise it returns a backend with the name of the version
+func (v *VersionDetail) GetBackend() VersionBackend {
+	if v.Backend == nil {
+		return VersionBackend{Name: v.Name}
+	}
+	return *v.Backend
+}
+
+// GetType returns the type of a header match if set
+// Otherwise it returns HeaderMatchExact
+func (h *HeaderMatch) GetType() HeaderMatchType {
+	if h.Type == nil {
+		return HeaderMatchExact
+	}
+	return *h.Type
+}
+
+// GetWeightValueType returns the type of the value of the weight field of a version if set
+// Otherwise it returns DefaultWeightValueType (Integer)
+func (v *VersionDetail) GetWeightValueType() WeightValueType {
+	if v.WeightFormat == nil || v.WeightFormat.ValueType == nil {
+		return DefaultWeightValueType
+	}
+	return *v.WeightFormat.ValueType
+}
+
+// GetWeightScale returns the factor by which the weight of a version is multiplied to give the value of its field if set
+// Otherwise it returns DefaultWeightScale (1)
+func (v *VersionDetail) GetWeightScale() float64 {
+	if v.WeightFormat == nil || v.WeightFormat.Scale == nil {
+		return DefaultWeightScale
+	}
+	return v.WeightFormat.Scale.AsApproximateFloat64()
+}
+
+//////////////////////////////////////////////////////////////////////
+// metric spec
+//////////////////////////////////////////////////////////////////////
+
+// DefaultDatadogURL is the default URL of the Datadog API
+const DefaultDatadogURL = "https://api.datadoghq.com"
+
+// DefaultNewRelicURL is the default URL of the New Relic NerdGraph API
+const DefaultNewRelicURL = "https://api.newrelic.com/graphql"
+
+// GetBuiltinProvider returns the name of the built-in provider that evaluates the metric, if any
+// A mocked metric is evaluated by the mock provider.
+// Otherwise, a built-in provider is used only if spec.provider names it and its configuration is present.
+func (s *MetricSpec) GetBuiltinProvider() *string {
+	if len(s.Mock) > 0 {
+		mock := ProviderMock
+		return &mock
+	}
+	if s.Provider == nil {
+		return nil
+	}
+	configured := false
+	switch *s.Provider {
+	case ProviderPrometheus:
+		configured = s.Prometheus != nil
+	case ProviderDatadog:
+		configured = s.Datadog != nil
+	case ProviderNewRelic:
+		configured = s.NewRelic != nil
+	case ProviderFile:
+		configured = s.File != nil
+	case ProviderFortio:
+		configured = s.IsHistogram()
+	}
+	if !configured {
+		return nil
+	}
+	return s.Provider
+}
+
+// DefaultPercentiles are the percentiles computed for a Histogram metric when none are specified
+var DefaultPercentiles = []int32{50, 90, 95, 99}
+
+// IsHistogram returns true if the metric is a Histogram metric
+func (s *MetricSpec) IsHistogram() bool {
+	return s.Type != nil && *s.Type == HistogramMetricType
+}
+
+// GetPercentiles returns the percentiles computed for a Histogram metric if set
+// Otherwise it returns DefaultPercentiles
+func (s *MetricSpec) GetPercentiles() []int32 {
+	if len(s.Percentiles) == 0 {
+		return DefaultPercentiles
+	}
+	return s.Percentiles
+}
+
+// PercentileMetricName returns the name by which a percentile of a Histogram metric is referenced; for example, latency-p95
+func PercentileMetricName(metric string, percentile int32) string {
+	return fmt.Sprintf("%s-p%d", metric, percentile)
+}
+
+// ParsePercentileMetricName returns the name of the Histogram metric and the percentile referenced by a name of the form <metric>-p<percentile>
+func ParsePercentileMetricName(name string) (string, int32, bool) {
+	i := strings.LastIndex(name, "-p")
+	if i <= 0 {
+		return "", 0, false
+	}
+	percentile, err := strconv.ParseInt(name[i+2:], 10, 32)
+	if err != nil || percentile < 0 || percentile > 100 {
+		return "", 0, false
+	}
+	return name[:i], int32(percentile), true
+}
+
+// GetMockNoise returns spec.mockOptions.noise if set
+// Otherwise it returns 0
+func (s *MetricSpec) GetMockNoise() float64 {
+	if s.MockOptions == nil || s.MockOptions.Noise == nil {
+		return 0
+	}
+	return s.MockOptions.Noise.AsApproximateFloat64()
+}
+
+// GetMockTrend returns spec.mockOptions.trend if set
+// Otherwise it returns 0
+func (s *MetricSpec) GetMockTrend() float64 {
+	if s.MockOptions == nil || s.MockOptions.Trend == nil {
+		return 0
+	}
+	return s.MockOptions.Trend.AsApproximateFloat64()
+}
+
+// GetMockFailureRate returns spec.mockOptions.failureRate if set
+// Otherwise it returns 0
+func (s *MetricSpec) GetMockFailureRate() float64 {
+	if s.MockOptions == nil || s.MockOptions.FailureRate == nil {
+		return 0
+	}
+	return s.MockOptions.FailureRate.AsApproximateFloat64()
+}
+
+// GetMockSeed returns spec.mockOptions.seed if set
+// Otherwise it returns 0
+func (s *MetricSpec) GetMockSeed() int64 {
+	if s.MockOptions == nil || s.MockOptions.Seed == nil {
+		return 0
+	}
+	return *s.MockOptions.Seed
+}
+
+// IsDerived returns true if the metric is a Derived metric
+func (s *MetricSpec) IsDerived() bool {
+	return s.Type != nil && *s.Type == DerivedMetricType
+}
+
+// GetURL returns the URL of the Datadog API if set
+// Otherwise it returns DefaultDatadogURL
+func (p *DatadogProvider) GetURL() string {
+	if p.URL == nil {
+		return DefaultDatadogURL
+	}
+	return *p.URL
+}
+
+// GetURL returns the URL of the New Relic NerdGraph API if set
+// Otherwise it returns DefaultNewRelicURL
+func (p *NewRelicProvider) GetURL() string {
+	if p.URL == nil {
+		return DefaultNewRelicURL
+	}
+	return *p.URL
+}
+
+// GetVersionColumn returns the name of the column that holds the name of the version if set
+// Otherwise it returns "version"
+func (p *FileProvider) GetVersionColumn() string {
+	if p.VersionColumn == nil {
+		return "version"
+	}
+	return *p.VersionColumn
+}
+
+// GetLowerColumn returns the name of the column that holds the lower bound of a bucket if set
+// Otherwise it returns "lower"
+func (p *FileProvider) GetLowerColumn() string {
+	if p.LowerColumn == nil {
+		return "lower"
+	}
+	return *p.LowerColumn
+}
+
+// GetUpperColumn returns the name of the column that holds the upper bound of a bucket if set
+// Otherwise it returns "upper"
+func (p *FileProvider) GetUpperColumn() string {
+	if p.UpperColumn == nil {
+		return "upper"
+	}
+	return *p.UpperColumn
+}
+
+// GetValueColumn returns the name of the column that holds the value of the metric if set
+// Otherwise it returns "value"
+func (p *FileProvider) GetValueColumn() string {
+	if p.ValueColumn == nil {
+		return "value"
+	}
+	return *p.ValueColumn
+}
@@ -138,6 +138,18 @@ var _ = Describe("VersionInfo", func() {
 			Expect(experiment.Spec.GetNumberOfCandidates()).Should(Equal(2))
 		})
 	})
+	Context("When a version has a weight format", func() {
+		It("the format is used; otherwise weights are integer percentages", func() {
+			version := v2alpha2.VersionDetail{Name: "v1"}
+			Expect(version.GetWeightValueType()).Should(Equal(v2alpha2.WeightValueInteger))
+			Expect(version.GetWeightScale()).Should(Equal(float64(1)))
+
+			number, scale := v2alpha2.WeightValueNumber, resource.MustParse("0.01")
+			version.WeightFormat = &v2alpha2.WeightFormat{ValueType: &number, Scale: &scale}
+			Expect(version.GetWeightValueType()).Should(Equal(v2alpha2.WeightValueNumber))
+			Expect(version.GetWeightScale()).Should(BeNumerically("~", 0.01))
+		})
+	})
 })
 
 var _ = Describe("Criteria", func() {
@@ -164,6 +176,257 @@ var _ = Describe("Criteria", func() {
 	})
 })
 
+var _ = Describe("Executors", func() {
+	Context("When tasks request an executor", func() {
+		inProcess, job := v2alpha2.ExecutorInProcess, v2alpha2.ExecutorJob
+		task := "notification/http"
+		experiment := v2alpha2.NewExperiment("test", "default").
+			WithAction("start", []v2alpha2.TaskSpec{{Task: &task, Executor: &inProcess}, {Task: &task, Executor: &inProcess}}).
+			WithAction("loop", []v2alpha2.TaskSpec{{Task: &task, Executor: &inProcess}, {Task: &task, Executor: &job}}).
+			WithAction("finish", []v2alpha2.TaskSpec{{Task: &task, Executor: &inProcess}, {Task: &task}}).
+			WithAction("empty", []v2alpha2.TaskSpec{}).
+			Build()
+		It("an action is executed in-process only if all of its tasks request it", func() {
+			Expect(experiment.Spec.Strategy.Actions["finish"][1].GetExecutor()).Should(Equal(v2alpha2.ExecutorJob))
+			Expect(experiment.Spec.GetActionExecutor("start")).Should(Equal(v2alpha2.ExecutorInProcess))
+			Expect(experiment.Spec.GetActionExecutor("loop")).Should(Equal(v2alpha2.ExecutorJob))
+			Expect(experiment.Spec.GetActionExecutor("finish")).Should(Equal(v2alpha2.ExecutorJob))
+			Expect(experiment.Spec.GetActionExecutor("empty")).Should(Equal(v2alpha2.ExecutorJob))
+			Expect(experiment.Spec.GetActionExecutor("missing")).Should(Equal(v2alpha2.ExecutorJob))
+		})
+	})
+})
+
+var _ = Describe("Drift Policy", func() {
+	Context("When the drift policy is not set", func() {
+		It("the weights are reapplied", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").Build()
+			Expect(experiment.Spec.GetDriftPolicy()).Should(Equal(v2alpha2.DriftPolicyReapply))
+		})
+	})
+	Context("When the drift policy is set", func() {
+		It("it is used", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").WithDriftPolicy(v2alpha2.DriftPolicyPause).Build()
+			Expect(experiment.Spec.GetDriftPolicy()).Should(Equal(v2alpha2.DriftPolicyPause))
+		})
+	})
+})
+
+var _ = Describe("Weight Schedules", func() {
+	Context("When a schedule lists steps", func() {
+		It("the steps are used", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").
+				WithWeightSchedule(v2alpha2.WeightSchedule{Steps: []int32{5, 10, 25, 50, 100}}).
+				Build()
+			Expect(experiment.Spec.GetWeightSteps()).Should(Equal([]int32{5, 10, 25, 50, 100}))
+			Expect(experiment.Spec.GetDwellIterations()).Should(Equal(int32(1)))
+		})
+	})
+	Context("When a schedule has a ramp", func() {
+		It("the steps are generated", func() {
+			start, increment := int32(5), int32(20)
+			linear := v2alpha2.NewExperiment("test", "default").
+				WithWeightSchedule(v2alpha2.WeightSchedule{Ramp: &v2alpha2.WeightRamp{Type: v2alpha2.RampLinear, Start: &start, Increment: &increment}}).
+				Build()
+			Expect(linear.Spec.GetWeightSteps()).Should(Equal([]int32{5, 25, 45, 65, 85, 100}))
+
+			exponential := v2alpha2.NewExperiment("test", "default").
+				WithWeightSchedule(v2alpha2.WeightSchedule{Ramp: &v2alpha2.WeightRamp{Type: v2alpha2.RampExponential}}).
+				Build()
+			Expect(exponential.Spec.GetWeightSteps()).Should(Equal([]int32{1, 2, 4, 8, 16, 32, 64, 100}))
+		})
+		It("a large factor does not overflow", func() {
+			start, factor := int32(2), int32(1073741824)
+			exponential := v2alpha2.NewExperiment("test", "default").
+				WithWeightSchedule(v2alpha2.WeightSchedule{Ramp: &v2alpha2.WeightRamp{Type: v2alpha2.RampExponential, Start: &start, Factor: &factor}}).
+				Build()
+			Expect(exponential.Spec.GetWeightSteps()).Should(Equal([]int32{2, 100}))
+		})
+		It("an invalid ramp has no steps", func() {
+			increment := int32(0)
+			linear := v2alpha2.NewExperiment("test", "default").
+				WithWeightSchedule(v2alpha2.WeightSchedule{Ramp: &v2alpha2.WeightRamp{Type: v2alpha2.RampLinear, Increment: &increment}}).
+				Build()
+			Expect(linear.Spec.GetWeightSteps()).Should(BeNil())
+		})
+	})
+	Context("When there is no schedule", func() {
+		It("there are no steps", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").Build()
+			Expect(experiment.Spec.GetWeightSteps()).Should(BeNil())
+		})
+	})
+})
+
+var _ = Describe("BlueGreen", func() {
+	Context("When a rollback window is set", func() {
+		It("it is used", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").
+				WithDeploymentPattern(v2alpha2.DeploymentPatternBlueGreen).
+				WithRollbackWindow(120).
+				Build()
+			Expect(experiment.Spec.GetRollbackWindow()).Should(Equal(2 * time.Minute))
+		})
+	})
+	Context("When no rollback window is set", func() {
+		It("the experiment does not wait after cutover", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").Build()
+			Expect(experiment.Spec.GetRollbackWindow()).Should(Equal(time.Duration(0)))
+		})
+	})
+})
+
+var _ = Describe("Mirroring", func() {
+	Context("When a mirror percentage is set", func() {
+		It("it is used", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").
+				WithTestingPattern(v2alpha2.TestingPatternShadow).
+				WithMirrorPercent(25).
+				Build()
+			Expect(experiment.Spec.GetMirrorPercent()).Should(Equal(int32(25)))
+		})
+	})
+	Context("When no mirror percentage is set", func() {
+		It("all requests are mirrored", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").Build()
+			Expect(experiment.Spec.GetMirrorPercent()).Should(Equal(int32(100)))
+		})
+	})
+})
+
+var _ = Describe("Match Rules", func() {
+	Context("When the type of a header match is not set", func() {
+		It("the value is matched exactly", func() {
+			regex := v2alpha2.HeaderMatchRegularExpression
+			experiment := v2alpha2.NewExperiment("test", "default").
+				WithBaselineVersion("v1", nil).
+				WithCandidateVersion("v2", nil).
+				WithMatch("v2", v2alpha2.MatchRule{Headers: []v2alpha2.HeaderMatch{{Name: "x-group", Value: "beta"}, {Name: "x-user", Value: "^a.*", Type: &regex}}}).
+				Build()
+			headers := experiment.Spec.VersionInfo.Candidates[0].Match[0].Headers
+			Expect(headers[0].GetType()).Should(Equal(v2alpha2.HeaderMatchExact))
+			Expect(headers[1].GetType()).Should(Equal(v2alpha2.HeaderMatchRegularExpression))
+		})
+	})
+})
+
+var _ = Describe("Dry Run", func() {
+	Context("When dryRun is not set", func() {
+		It("the experiment is not a dry run", func() {
+			Expect(v2alpha2.NewExperiment("test", "default").Build().Spec.GetDryRun()).Should(BeFalse())
+			Expect(v2alpha2.NewExperiment("test", "default").WithDryRun(true).Build().Spec.GetDryRun()).Should(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("Metrics Refresh Policy", func() {
+	Context("When metricsRefreshPolicy is not set", func() {
+		It("metrics are never refreshed", func() {
+			Expect(v2alpha2.NewExperiment("test", "default").Build().Spec.GetMetricsRefreshPolicy()).Should(Equal(v2alpha2.MetricsRefreshPolicyNever))
+			Expect(v2alpha2.NewExperiment("test", "default").WithMetricsRefreshPolicy(v2alpha2.MetricsRefreshPolicyOnChange).Build().Spec.GetMetricsRefreshPolicy()).Should(Equal(v2alpha2.MetricsRefreshPolicyOnChange))
+		})
+	})
+})
+
+var _ = Describe("Strength", func() {
+	Context("When the fields of strength are not set", func() {
+		It("the defaults are used", func() {
+			strength := v2alpha2.NewExperiment("test", "default").WithStrength(v2alpha2.Strength{}).Build().Spec.Criteria.Strength
+			Expect(strength.GetMethod()).Should(Equal(v2alpha2.AnalysisMethodBayesian))
+			Expect(strength.GetModel()).Should(Equal(v2alpha2.RewardModelBeta))
+			Expect(strength.GetConfidence()).Should(Equal(v2alpha2.DefaultConfidence))
+			Expect(strength.GetMinSampleSize()).Should(Equal(v2alpha2.DefaultMinSampleSize))
+		})
+	})
+})
+
+var _ = Describe("Reward Weights", func() {
+	Context("When the weights and normalization are not set", func() {
+		It("the defaults are used", func() {
+			metric := v2alpha2.NewMetric("conversion", "default").Build()
+			spec := v2alpha2.NewExperiment("test", "default").WithReward(*metric, v2alpha2.PreferredDirectionHigher).Build().Spec
+			Expect(spec.Criteria.Rewards[0].GetWeight()).Should(Equal(v2alpha2.DefaultRewardWeight))
+			Expect(spec.GetRewardNormalization()).Should(Equal(v2alpha2.RewardNormalizationMinMax))
+		})
+	})
+	Context("When the weights and normalization are set", func() {
+		It("they are returned", func() {
+			metric := v2alpha2.NewMetric("conversion", "default").Build()
+			spec := v2alpha2.NewExperiment("test", "default").
+				WithWeightedReward(*metric, v2alpha2.PreferredDirectionHigher, "2.5").
+				WithRewardNormalization(v2alpha2.RewardNormalizationBaseline).
+				Build().Spec
+			Expect(spec.Criteria.Rewards[0].GetWeight()).Should(Equal(2.5))
+			Expect(spec.GetRewardNormalization()).Should(Equal(v2alpha2.RewardNormalizationBaseline))
+		})
+	})
+})
+
+var _ = Describe("Relative Objectives", func() {
+	Context("When the type of the relative limits is not set", func() {
+		It("the limits are percentages", func() {
+			metric := v2alpha2.NewMetric("latency", "default").Build()
+			spec := v2alpha2.NewExperiment("test", "default").WithRelativeObjective(*metric, v2alpha2.RelativeLimits{}, false).Build().Spec
+			Expect(spec.Criteria.Objectives[0].Relative.GetType()).Should(Equal(v2alpha2.RelativeLimitPercent))
+		})
+	})
+})
+
+var _ = Describe("Bandit", func() {
+	Context("When the bandit is not set", func() {
+		It("there is no bandit and epsilon is the default", func() {
+			spec := v2alpha2.NewExperiment("test", "default").Build().Spec
+			Expect(spec.GetBandit()).Should(BeNil())
+			Expect(spec.GetBanditModel()).Should(Equal(v2alpha2.RewardModelBeta))
+			Expect(spec.GetEpsilon()).Should(Equal(v2alpha2.DefaultEpsilon))
+		})
+	})
+	Context("When the bandit is set", func() {
+		It("the algorithm and epsilon are returned", func() {
+			spec := v2alpha2.NewExperiment("test", "default").WithBandit(v2alpha2.BanditAlgorithmEpsilonGreedy).WithBanditModel(v2alpha2.RewardModelNormal).WithEpsilon("0.2").Build().Spec
+			Expect(*spec.GetBandit()).Should(Equal(v2alpha2.BanditAlgorithmEpsilonGreedy))
+			Expect(spec.GetBanditModel()).Should(Equal(v2alpha2.RewardModelNormal))
+			Expect(spec.GetEpsilon()).Should(Equal(0.2))
+		})
+	})
+})
+
+var _ = Describe("Cluster Metrics", func() {
+	Context("When a ClusterMetric is used as a Metric", func() {
+		It("it has the name and spec of the ClusterMetric and no namespace", func() {
+			clusterMetric := v2alpha2.NewMetric("latency", "ignored").WithDescription("latency").BuildClusterMetric()
+			metric := clusterMetric.AsMetric()
+			Expect(metric.Name).Should(Equal("latency"))
+			Expect(metric.Namespace).Should(BeEmpty())
+			Expect(metric.Kind).Should(Equal("Metric"))
+			Expect(*metric.Spec.Description).Should(Equal("latency"))
+		})
+	})
+})
+
+var _ = Describe("Histogram Metrics", func() {
+	Context("When percentiles are not set", func() {
+		It("the default percentiles are computed", func() {
+			metric := v2alpha2.NewMetric("latency", "default").WithPercentiles().Build()
+			Expect(metric.Spec.IsHistogram()).Should(BeTrue())
+			Expect(metric.Spec.GetPercentiles()).Should(Equal(v2alpha2.DefaultPercentiles))
+			Expect(v2alpha2.NewMetric("latency", "default").WithPercentiles(75).Build().Spec.GetPercentiles()).Should(Equal([]int32{75}))
+		})
+	})
+	Context("When a percentile is referenced by name", func() {
+		It("the name of the metric and the percentile are parsed", func() {
+			base, percentile, ok := v2alpha2.ParsePercentileMetricName(v2alpha2.PercentileMetricName("default/request-latency", 95))
+			Expect(ok).Should(BeTrue())
+			Expect(base).Should(Equal("default/request-latency"))
+			Expect(percentile).Should(Equal(int32(95)))
+			for _, name := range []string{"latency", "-p95", "latency-p101", "latency-pxx", "p95"} {
+				_, _, ok := v2alpha2.ParsePercentileMetricName(name)
+				Expect(ok).Should(BeFalse(), name)
+			}
+		})
+	})
+})
+
 var _ = Describe("Generated Code", func() {
 	var jqe string = "expr"
 
@@ -143,6 +143,24 @@ func (b *ExperimentBuilder) WithCandidateVersion(name string, objRef *corev1.Obj
 	return b
 }
 
+// WithMatch ..
+// Expects the version to be defined already via WithBaselineVersion() or WithCandidateVersion()
+func (b *ExperimentBuilder) WithMatch(name string, rules ...MatchRule) *ExperimentBuilder {
+	if b.Spec.VersionInfo == nil {
+		return b
+	}
+	if b.Spec.VersionInfo.Baseline.Name == name {
+		b.Spec.VersionInfo.Baseline.Match = append(b.Spec.VersionInfo.Baseline.Match, rules...)
+		return b
+	}
+	for i := range b.Spec.VersionInfo.Candidates {
+		if b.Spec.VersionInfo.Candidates[i].Name == name {
+			b.Spec.VersionInfo.Candidates[i].Match = append(b.Spec.VersionInfo.Candidates[i].Match, rules...)
+		}
+	}
+	return b
+}
+
 // WithCurrentWeight ..
 func (b *ExperimentBuilder) WithCurrentWeight(name string, weight int32) *ExperimentBuilder {
 
@@ -205,6 +223,100 @@ func (b *ExperimentBuilder) WithAction(key string, tasks []TaskSpec) *Experiment
 	return b
 }
 
+// WithActionPolicy ..
+func (b *ExperimentBuilder) WithActionPolicy(key string, policy ActionPolicy) *ExperimentBuilder {
+	if b.Spec.Strategy.ActionPolicies == nil {
+		b.Spec.Strategy.ActionPolicies = make(map[string]ActionPolicy)
+	}
+	b.Spec.Strategy.ActionPolicies[key] = policy
+	return b
+}
+
+// WithWeightBackend ..
+func (b *ExperimentBuilder) WithWeightBackend(backend WeightBackendType) *ExperimentBuilder {
+	if b.Spec.Strategy.Weights == nil {
+		b.Spec.Strategy.Weights = &Weights{}
+	}
+	b.Spec.Strategy.Weights.Backend = &backend
+	return b
+}
+
+// WithDriftPolicy ..
+func (b *ExperimentBuilder) WithDriftPolicy(policy DriftPolicyType) *ExperimentBuilder {
+	if b.Spec.Strategy.Weights == nil {
+		b.Spec.Strategy.Weights = &Weights{}
+	}
+	b.Spec.Strategy.Weights.DriftPolicy = &policy
+	return b
+}
+
+// WithWeightSchedule ..
+func (b *ExperimentBuilder) WithWeightSchedule(schedule WeightSchedule) *ExperimentBuilder {
+	if b.Spec.Strategy.Weights == nil {
+		b.Spec.Strategy.Weights = &Weights{}
+	}
+	b.Spec.Strategy.Weights.Schedule = &schedule
+	return b
+}
+
+// WithBandit ..
+func (b *ExperimentBuilder) WithBandit(algorithm BanditAlgorithmType) *ExperimentBuilder {
+	if b.Spec.Strategy.Weights == nil {
+		b.Spec.Strategy.Weights = &Weights{}
+	}
+	b.Spec.Strategy.Weights.Bandit = &algorithm
+	return b
+}
+
+// WithBanditModel ..
+func (b *ExperimentBuilder) WithBanditModel(model RewardModelType) *ExperimentBuilder {
+	if b.Spec.Strategy.Weights == nil {
+		b.Spec.Strategy.Weights = &Weights{}
+	}
+	b.Spec.Strategy.Weights.BanditModel = &model
+	return b
+}
+
+// WithEpsilon ..
+func (b *ExperimentBuilder) WithEpsilon(epsilon string) *ExperimentBuilder {
+	if b.Spec.Strategy.Weights == nil {
+		b.Spec.Strategy.Weights = &Weights{}
+	}
+	q := resource.MustParse(epsilon)
+	b.Spec.Strategy.Weights.Epsilon = &q
+	return b
+}
+
+// WithRollbackWindow ..
+func (b *ExperimentBuilder) WithRollbackWindow(seconds int32) *ExperimentBuilder {
+	if b.Spec.Strategy.BlueGreen == nil {
+		b.Spec.Strategy.BlueGreen = &BlueGreen{}
+	}
+	b.Spec.Strategy.BlueGreen.RollbackWindowSeconds = &seconds
+	return b
+}
+
+// WithDryRun ..
+func (b *ExperimentBuilder) WithDryRun(dryRun bool) *ExperimentBuilder {
+	b.Spec.Strategy.DryRun = &dryRun
+	return b
+}
+
+// WithMirrorPercent ..
+func (b *ExperimentBuilder) WithMirrorPercent(percent int32) *ExperimentBuilder {
+	if b.Spec.Strategy.Mirroring == nil {
+		b.Spec.Strategy.Mirroring = &Mirroring{}
+	}
+	b.Spec.Strategy.Mirroring.Percent = &percent
+	return b
+}
+
+// WithHandlerTemplate ..
+func (b *ExperimentBuilder) WithHandlerTemplate(template HandlerTemplate) *ExperimentBuilder {
+	b.Spec.Strategy.HandlerTemplate = &template
+	return b
+}
+
 // WithReward ..
 func (b *ExperimentBuilder) WithReward(metric Metric, preferredDirection PreferredDirectionType) *ExperimentBuilder {
 	if b.Spec.Criteria == nil {
@@ -218,6 +330,41 @@ func (b *ExperimentBuilder) WithReward(metric Metric, preferredDirection Preferr
 	return b
 }
 
+// WithWeightedReward ..
+func (b *ExperimentBuilder) WithWeightedReward(metric Metric, preferredDirection PreferredDirectionType, weight string) *ExperimentBuilder {
+	b.WithReward(metric, preferredDirection)
+	q := resource.MustParse(weight)
+	b.Spec.Criteria.Rewards[len(b.Spec.Criteria.Rewards)-1].Weight = &q
+	return b
+}
+
+// WithRewardNormalization ..
+func (b *ExperimentBuilder) WithRewardNormalization(normalization RewardNormalizationType) *ExperimentBuilder {
+	if b.Spec.Criteria == nil {
+		b.Spec.Criteria = &Criteria{}
+	}
+	b.Spec.Criteria.RewardNormalization = &normalization
+	return b
+}
+
+// WithStrength ..
+func (b *ExperimentBuilder) WithStrength(strength Strength) *ExperimentBuilder {
+	if b.Spec.Criteria == nil {
+		b.Spec.Criteria = &Criteria{}
+	}
+	b.Spec.Criteria.Strength = &strength
+	return b
+}
+
+// WithMetricsRefreshPolicy ..
+func (b *ExperimentBuilder) WithMetricsRefreshPolicy(policy MetricsRefreshPolicyType) *ExperimentBuilder {
+	if b.Spec.Criteria == nil {
+		b.Spec.Criteria = &Criteria{}
+	}
+	b.Spec.Criteria.MetricsRefreshPolicy = &policy
+	return b
+}
+
 // WithIndicator ..
 func (b *ExperimentBuilder) WithIndicator(metric Metric) *ExperimentBuilder {
 	if b.Spec.Criteria == nil {
@@ -242,3 +389,27 @@ func (b *ExperimentBuilder) WithObjective(metric Metric, upper *resource.Quantit
 	})
 	return b
 }
+
+// WithRelativeObjective ..
+func (b *ExperimentBuilder) WithRelativeObjective(metric Metric, limits RelativeLimits, rollback bool) *ExperimentBuilder {
+	b.WithObjective(metric, nil, nil, rollback)
+	b.Spec.Criteria.Objectives[len(b.Spec.Criteria.Objectives)-1].Relative = &limits
+	return b
+}
+
+// WithGuardrail ..
+func (b *ExperimentBuilder) WithGuardrail(metric Metric, upper *resource.Quantity, lower *resource.Quantity) *ExperimentBuilder {
+	b.WithObjective(metric, upper, lower, true)
+	guardrail := true
+	b.Spec.Criteria.Objectives[len(b.Spec.Criteria.Objectives)-1].Guardrail = &guardrail
+	return b
+}
+
+// WithGuardrailInterval ..
+func (b *ExperimentBuilder) WithGuardrailInterval(seconds int32) *ExperimentBuilder {
+	if b.Spec.Duration == nil {
+		b.Spec.Duration = &Duration{}
+	}
+	b.Spec.Duration.GuardrailIntervalSeconds = &seconds
+	return b
+}
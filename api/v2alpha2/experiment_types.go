@@ -87,6 +87,10 @@ type MetricInfo struct {
 	// MetricObj is the referenced metric
 	// +kubebuilder:validation:EmbeddedResource
 	MetricObj Metric `json:"metricObj" yaml:"metricObj"`
+
+	// Source is the kind of the resource the metric was read from: Metric or ClusterMetric
+	// +optional
+	Source *MetricSourceType `json:"source,omitempty" yaml:"source,omitempty"`
 }
 
 // VersionInfo is information about versions that is typically provided by the domain start handler.
@@ -112,6 +116,101 @@ type VersionDetail struct {
 	// WeightObjRef is a reference to another kubernetes object
 	// +optional
 	WeightObjRef *corev1.ObjectReference `json:"weightObjRef,omitempty" yaml:"weightObjRef,omitempty"`
+
+	// Backend identifies this version within the traffic routing object referenced by WeightObjRef.
+	// It is used only when spec.strategy.weights.backend is set; WeightObjRef then needs no fieldPath.
+	// Defaults to a backend with the name of the version.
+	// +optional
+	Backend *VersionBackend `json:"backend,omitempty" yaml:"backend,omitempty"`
+
+	// WeightFormat describes how the weight is represented in the field referenced by WeightObjRef.
+	// It is used only when the weight is identified by the fieldPath of WeightObjRef.
+	// Defaults to an integer percentage.
+	// +optional
+	WeightFormat *WeightFormat `json:"weightFormat,omitempty" yaml:"weightFormat,omitempty"`
+
+	// Match selects requests that are routed to this version regardless of the weights.
+	// A request is selected if it satisfies any of the rules. Match rules may be specified only for candidates;
+	// they are configured in the traffic routing object by the built-in weight adapter identified by
+	// spec.strategy.weights.backend.
+	// +optional
+	Match []MatchRule `json:"match,omitempty" yaml:"match,omitempty"`
+}
+
+// MatchRule selects requests that satisfy all of its conditions
+type MatchRule struct {
+	// Headers are conditions on the headers of a request
+	// +optional
+	Headers []HeaderMatch `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Cookie is a condition on a cookie of a request
+	// +optional
+	Cookie *CookieMatch `json:"cookie,omitempty" yaml:"cookie,omitempty"`
+
+	// UserHash selects a percentage of users by the hash of their user ID
+	// +optional
+	UserHash *UserHashMatch `json:"userHash,omitempty" yaml:"userHash,omitempty"`
+}
+
+// HeaderMatch is a condition on the value of a header
+type HeaderMatch struct {
+	// Name is the name of the header
+	Name string `json:"name" yaml:"name"`
+
+	// Value is the value of the header, or a regular expression it must match
+	Value string `json:"value" yaml:"value"`
+
+	// Type is how the value is compared; one of Exact or RegularExpression. Default is Exact.
+	// +optional
+	Type *HeaderMatchType `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+// CookieMatch is a condition on the value of a cookie
+type CookieMatch struct {
+	// Name is the name of the cookie
+	Name string `json:"name" yaml:"name"`
+
+	// Value is the value of the cookie
+	Value string `json:"value" yaml:"value"`
+}
+
+// UserHashMatch selects users by a hash of their user ID
+// The hash is not computed by iter8; it is expected in a header, as a number between 0 and 99, set by an
+// ingress gateway or client library. A user is therefore always assigned to the same version.
+type UserHashMatch struct {
+	// Header is the name of the header that holds the hash
+	Header string `json:"header" yaml:"header"`
+
+	// Percent is the percentage of users that are selected
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	Percent int32 `json:"percent" yaml:"percent"`
+}
+
+// WeightFormat describes how a weight is represented in a traffic routing object
+// The value of the field is the weight, a percentage, multiplied by the scale.
+// For example, a field holding a fraction between 0 and 1 has scale 0.01; a field between 0 and 1000 has scale 10.
+type WeightFormat struct {
+	// ValueType is the type of the value of the field; one of Integer, Number or String.
+	// A String holds a decimal number, as is common for annotations.
+	// Default is Integer.
+	// +optional
+	ValueType *WeightValueType `json:"valueType,omitempty" yaml:"valueType,omitempty"`
+
+	// Scale is the factor by which the weight is multiplied to give the value of the field.
+	// Default is 1.
+	// +optional
+	Scale *resource.Quantity `json:"scale,omitempty" yaml:"scale,omitempty"`
+}
+
+// VersionBackend identifies a version within a traffic routing object
+type VersionBackend struct {
+	// Name is the name of the backend service; for a VirtualService, it is the destination host
+	Name string `json:"name" yaml:"name"`
+
+	// Subset is the destination subset; used only for a VirtualService
+	// +optional
+	Subset *string `json:"subset,omitempty" yaml:"subset,omitempty"`
 }
 
 // Strategy identifies the type of experiment and its properties
@@ -131,10 +230,83 @@ type Strategy struct {
 	// +optional
 	Actions ActionMap `json:"actions,omitempty" yaml:"actions,omitempty"`
 
+	// ActionPolicies define how the controller executes actions; keys are action names.
+	// +optional
+	ActionPolicies map[string]ActionPolicy `json:"actionPolicies,omitempty" yaml:"actionPolicies,omitempty"`
+
 	// Weights modify the behavior of the traffic split algorithm.
 	// Defaults depend on the experiment type.
 	// +optional
 	Weights *Weights `json:"weights,omitempty" yaml:"weights,omitempty"`
+
+	// BlueGreen modifies the behavior of the BlueGreen deployment pattern
+	// +optional
+	BlueGreen *BlueGreen `json:"blueGreen,omitempty" yaml:"blueGreen,omitempty"`
+
+	// Mirroring modifies the behavior of the Shadow testing pattern
+	// +optional
+	Mirroring *Mirroring `json:"mirroring,omitempty" yaml:"mirroring,omitempty"`
+
+	// DryRun indicates that the traffic routing objects are not modified.
+	// The patches that would be applied are recorded in status.dryRunPatches and reported in events instead.
+	// Default is false.
+	// +optional
+	DryRun *bool `json:"dryRun,omitempty" yaml:"dryRun,omitempty"`
+
+	// HandlerTemplate overrides parts of the pod template of the jobs that execute actions.
+	// It is merged (strategically) onto the job spec defined when iter8 is installed.
+	// +optional
+	HandlerTemplate *HandlerTemplate `json:"handlerTemplate,omitempty" yaml:"handlerTemplate,omitempty"`
+}
+
+// HandlerTemplate identifies the parts of a handler job that can be overridden by an experiment.
+// Container level fields apply to the (first) handler container.
+type HandlerTemplate struct {
+	// Image is the image used by the handler container
+	// +optional
+	Image *string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// Resources are the compute resources required by the handler container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty" yaml:"resources,omitempty"`
+
+	// NodeSelector is a selector which must match a node's labels for the handler pod to be scheduled on that node
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+
+	// Tolerations are the tolerations of the handler pod
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+
+	// Env is a list of additional environment variables to set in the handler container.
+	// Variables set by iter8 (EXPERIMENT_NAME, EXPERIMENT_NAMESPACE and ACTION) cannot be overridden.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// Volumes is a list of additional volumes that can be mounted by the handler container
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+
+	// VolumeMounts is a list of additional volumes to mount into the handler container
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty" yaml:"volumeMounts,omitempty"`
+
+	// SecretMounts is a list of secrets to mount (read only) into the handler container
+	// +optional
+	SecretMounts []SecretMount `json:"secretMounts,omitempty" yaml:"secretMounts,omitempty"`
+}
+
+// SecretMount identifies a secret to be mounted into the handler container
+type SecretMount struct {
+	// SecretName is the name of the secret in the namespace of the handler job
+	// +kubebuilder:validation:MinLength:=1
+	SecretName string `json:"secretName" yaml:"secretName"`
+
+	// MountPath is the path within the handler container at which the secret should be mounted
+	// +kubebuilder:validation:MinLength:=1
+	MountPath string `json:"mountPath" yaml:"mountPath"`
 }
 
 // ActionMap type for containing a collection of actions.
@@ -161,6 +333,29 @@ type TaskSpec struct {
 	// Different task require different types of inputs. Hence, this data is held as json.RawMessage to be decoded by individual task libraries.
 	// +optional
 	With map[string]apiextensionsv1.JSON `json:"with,omitempty" yaml:"with,omitempty"`
+	// Executor is a hint about how the task should be executed.
+	// An action is executed InProcess by the controller only if all of its tasks request it and
+	// the controller supports executing them; otherwise the action is executed in a handler Job.
+	// Only lightweight tasks, such as notification tasks, can be executed InProcess.
+	// Default is Job.
+	// +optional
+	Executor *ExecutorType `json:"executor,omitempty" yaml:"executor,omitempty"`
+}
+
+// ActionPolicy defines how the controller executes an action
+type ActionPolicy struct {
+	// TimeoutSeconds is the maximum time a single attempt to execute the action may take.
+	// An attempt that takes longer is terminated and treated as failed.
+	// Default is no timeout.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+
+	// Retries is the number of times a failed attempt to execute the action is retried.
+	// Default is 0 (no retries).
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	Retries *int32 `json:"retries,omitempty" yaml:"retries,omitempty"`
 }
 
 // Weights modify the behavior of the traffic split algorithm.
@@ -177,6 +372,105 @@ type Weights struct {
 	// +kubebuilder:validation:Maximum:=100
 	// +optional
 	MaxCandidateWeightIncrement *int32 `json:"maxCandidateWeightIncrement,omitempty" yaml:"maxCandidateWeightIncrement,omitempty"`
+
+	// Backend is the kind of traffic routing object referenced by the weightObjRef of the versions.
+	// When set, the controller computes the patches and reads the weights itself and
+	// the weightObjRef of the versions need no fieldPath.
+	// +optional
+	Backend *WeightBackendType `json:"backend,omitempty" yaml:"backend,omitempty"`
+
+	// DriftPolicy is what the controller does when the weights of the versions are changed
+	// by someone else during the experiment; one of Reapply, Pause or Fail.
+	// Default is Reapply.
+	// +optional
+	DriftPolicy *DriftPolicyType `json:"driftPolicy,omitempty" yaml:"driftPolicy,omitempty"`
+
+	// Schedule is a sequence of steps that bound the total weight of the candidate versions.
+	// The recommended weights are reduced, if necessary, so that the candidates receive no more
+	// traffic than the current step allows; the next step is allowed only after the candidates
+	// have received the weight of the current step for a minimum number of iterations.
+	// +optional
+	Schedule *WeightSchedule `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+
+	// Bandit is the multi-armed bandit algorithm with which the controller determines the weights of an A/B/N
+	// experiment from the observations of its reward. The experiment must have exactly one reward.
+	// If not set, the recommended weights are used.
+	// +optional
+	Bandit *BanditAlgorithmType `json:"bandit,omitempty" yaml:"bandit,omitempty"`
+
+	// BanditModel is the model of the reward with which the bandit determines the weights. Default is Beta.
+	// +optional
+	BanditModel *RewardModelType `json:"banditModel,omitempty" yaml:"banditModel,omitempty"`
+
+	// Epsilon is the fraction of traffic split evenly among the versions by the EpsilonGreedy bandit. Default is 0.1.
+	// +optional
+	Epsilon *resource.Quantity `json:"epsilon,omitempty" yaml:"epsilon,omitempty"`
+}
+
+// BlueGreen modifies the behavior of the BlueGreen deployment pattern
+// The candidates receive no traffic until a winner is found. All traffic is then cut over to the winner at once.
+type BlueGreen struct {
+	// RollbackWindowSeconds is how long after cutover the experiment continues before it finishes.
+	// During this time the baseline is kept warm; if the experiment is rolled back, traffic is
+	// immediately reverted to the baseline. Default is 0.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	RollbackWindowSeconds *int32 `json:"rollbackWindowSeconds,omitempty" yaml:"rollbackWindowSeconds,omitempty"`
+}
+
+// Mirroring modifies the behavior of the Shadow testing pattern
+// The traffic of the baseline is mirrored to the candidate, which never receives any weight.
+// Mirroring is configured by the built-in weight adapter identified by spec.strategy.weights.backend.
+type Mirroring struct {
+	// Percent is the percentage of the requests to the baseline that are mirrored to the candidate. Default is 100.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	Percent *int32 `json:"percent,omitempty" yaml:"percent,omitempty"`
+}
+
+// WeightSchedule is a sequence of steps that bound the total weight of the candidate versions
+// The steps are either listed explicitly or generated by a ramp.
+type WeightSchedule struct {
+	// Steps is an increasing list of the maximum total weight of the candidates; for example, [5, 10, 25, 50, 100]
+	// +optional
+	Steps []int32 `json:"steps,omitempty" yaml:"steps,omitempty"`
+
+	// Ramp generates the steps; used only if steps are not listed
+	// +optional
+	Ramp *WeightRamp `json:"ramp,omitempty" yaml:"ramp,omitempty"`
+
+	// DwellIterations is the minimum number of iterations the candidates receive the weight of a step
+	// before the next step is allowed. Default is 1.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	DwellIterations *int32 `json:"dwellIterations,omitempty" yaml:"dwellIterations,omitempty"`
+}
+
+// WeightRamp generates the steps of a schedule
+// A Linear ramp adds increment at each step; an Exponential ramp multiplies by factor at each step.
+// The steps start at start and end at 100.
+type WeightRamp struct {
+	// Type is the type of ramp; one of Linear or Exponential
+	Type RampType `json:"type" yaml:"type"`
+
+	// Start is the first step. Default is the increment for a Linear ramp and 1 for an Exponential ramp.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	Start *int32 `json:"start,omitempty" yaml:"start,omitempty"`
+
+	// Increment is the difference between steps of a Linear ramp. Default is 10.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	Increment *int32 `json:"increment,omitempty" yaml:"increment,omitempty"`
+
+	// Factor is the ratio between steps of an Exponential ramp. Default is 2.
+	// +kubebuilder:validation:Minimum:=2
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	Factor *int32 `json:"factor,omitempty" yaml:"factor,omitempty"`
 }
 
 // Criteria is list of criteria to be evaluated throughout the experiment
@@ -188,9 +482,16 @@ type Criteria struct {
 	RequestCount *string `json:"requestCount,omitempty" yaml:"requestCount,omitempty"`
 
 	// Rewards is a list of metrics that should be used to evaluate the reward for a version in the experiment.
+	// If there are several rewards, the winner is the version with the best composite score: the weighted mean
+	// of the normalized values of the rewards.
 	// +optional
 	Rewards []Reward `json:"rewards,omitempty" yaml:"rewards,omitempty"`
 
+	// RewardNormalization identifies how the values of the rewards are normalized before they are combined
+	// into a composite score. Default is MinMax.
+	// +optional
+	RewardNormalization *RewardNormalizationType `json:"rewardNormalization,omitempty" yaml:"rewardNormalization,omitempty"`
+
 	// Indicators is a list of metrics to be measured and reported on each iteration of the experiment.
 	// +optional
 	Indicators []string `json:"indicators,omitempty" yaml:"indicators,omitempty"`
@@ -201,10 +502,39 @@ type Criteria struct {
 	// +optional
 	Objectives []Objective `json:"objectives,omitempty" yaml:"objectives,omitempty"`
 
-	// Strength identifies the required degree of support the analytics must provide before it will
-	// assert success for an objective.
+	// Strength identifies the required degree of support the analysis must provide before it will
+	// assert that a version is the winner. If set, iter8 assesses the winner using the first reward.
+	// It may not be set when there are several rewards; their composite score is not assessed statistically.
+	// +optional
+	Strength *Strength `json:"strength,omitempty" yaml:"strength,omitempty"`
+
+	// MetricsRefreshPolicy identifies whether the metrics read when the experiment starts
+	// are read again when the Metric (or ClusterMetric) objects change. Default is Never.
+	// +optional
+	MetricsRefreshPolicy *MetricsRefreshPolicyType `json:"metricsRefreshPolicy,omitempty" yaml:"metricsRefreshPolicy,omitempty"`
+}
+
+// Strength is the degree of statistical support required to declare a winner
+type Strength struct {
+	// Method is the method of analysis. Default is Bayesian.
+	// +optional
+	Method *AnalysisMethodType `json:"method,omitempty" yaml:"method,omitempty"`
+
+	// Model is the model of the reward metric. Default is Beta.
+	// The Normal model requires the standard deviation of the reward, which must be reported by the analytics service.
+	// +optional
+	Model *RewardModelType `json:"model,omitempty" yaml:"model,omitempty"`
+
+	// Confidence is the required probability that the winner is the best version (Bayesian)
+	// or one minus the significance level of the tests (Frequentist). Default is 0.95.
+	// +optional
+	Confidence *resource.Quantity `json:"confidence,omitempty" yaml:"confidence,omitempty"`
+
+	// MinSampleSize is the sample size of the reward metric required of every version before a winner is declared.
+	// Default is 10.
+	// +kubebuilder:validation:Minimum:=1
 	// +optional
-	Strength apiextensionsv1.JSON `json:"strength,omitempty" yaml:"strength,omitempty"`
+	MinSampleSize *int32 `json:"minSampleSize,omitempty" yaml:"minSampleSize,omitempty"`
 }
 
 // Reward ..
@@ -215,6 +545,10 @@ type Reward struct {
 	// PreferredDirection identifies whether higher or lower values of the reward metric are preferred
 	// valid values are "higher" and "lower"
 	PreferredDirection PreferredDirectionType `json:"preferredDirection" yaml:"preferredDirection"`
+
+	// Weight is the relative importance of the reward in the composite score. Default is 1.
+	// +optional
+	Weight *resource.Quantity `json:"weight,omitempty" yaml:"weight,omitempty"`
 }
 
 // Objective is a service level objective
@@ -234,10 +568,41 @@ type Objective struct {
 	// +optional
 	LowerLimit *resource.Quantity `json:"lowerLimit,omitempty" yaml:"lowerLimit,omitempty"`
 
+	// Relative limits the value of the metric for each candidate relative to its value for the baseline.
+	// A candidate does not satisfy the objective if the value for the baseline is unavailable.
+	// +optional
+	Relative *RelativeLimits `json:"relative,omitempty" yaml:"relative,omitempty"`
+
 	// RollbackOnFailure indicates that if the criterion is not met, the experiment should be ended
 	// default is false
 	// +optional
 	RollbackOnFailure *bool `json:"rollback_on_failure,omitempty" yaml:"rollback_on_failure,omitempty"`
+
+	// Guardrail indicates that the objective is also checked between iterations, every
+	// spec.duration.guardrailIntervalSeconds, by querying its metric directly rather than through the analytics service.
+	// The experiment is rolled back as soon as a version fails a guardrail, regardless of RollbackOnFailure.
+	// The metric must use a built-in provider or have a urlTemplate and jqExpression; it may not be a Histogram metric.
+	// default is false
+	// +optional
+	Guardrail *bool `json:"guardrail,omitempty" yaml:"guardrail,omitempty"`
+}
+
+// RelativeLimits are limits on the value of a metric for a candidate relative to its value for the baseline
+type RelativeLimits struct {
+	// Type identifies whether the limits are percentages of the value for the baseline
+	// or differences from it. Default is Percent.
+	// +optional
+	Type *RelativeLimitType `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// UpperLimit is the maximum acceptable increase over the value for the baseline.
+	// For example, 10 (Percent) means that the value may be at most 10% greater than the value for the baseline.
+	// +optional
+	UpperLimit *resource.Quantity `json:"upperLimit,omitempty" yaml:"upperLimit,omitempty"`
+
+	// LowerLimit is the maximum acceptable decrease below the value for the baseline.
+	// For example, 0.01 (Delta) means that the value may be at most 0.01 less than the value for the baseline.
+	// +optional
+	LowerLimit *resource.Quantity `json:"lowerLimit,omitempty" yaml:"lowerLimit,omitempty"`
 }
 
 // Duration of an experiment
@@ -260,6 +625,12 @@ type Duration struct {
 	// +kubebuilder:validation:Minimum:=1
 	// +optional
 	MaxLoops *int32 `json:"maxLoops,omitempty" yaml:"maxLoops,omitempty"`
+
+	// GuardrailIntervalSeconds is the time between checks of the guardrail objectives in seconds
+	// Default is 5 (seconds)
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	GuardrailIntervalSeconds *int32 `json:"guardrailIntervalSeconds,omitempty" yaml:"guardrailIntervalSeconds,omitempty"`
 }
 
 // ExperimentStatus defines the observed state of Experiment
@@ -317,6 +688,168 @@ type ExperimentStatus struct {
 	// Key is the name as referenced in spec.criteria
 	// +optional
 	Metrics []MetricInfo `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+
+	// HandlerAttempts is a record of each attempt to execute an action
+	// +optional
+	HandlerAttempts []HandlerAttempt `json:"handlerAttempts,omitempty" yaml:"handlerAttempts,omitempty"`
+
+	// WeightSchedule is the progress of the experiment through spec.strategy.weights.schedule
+	// +optional
+	WeightSchedule *WeightScheduleStatus `json:"weightSchedule,omitempty" yaml:"weightSchedule,omitempty"`
+
+	// Guardrails is the result of the latest check of the guardrail objectives
+	// +optional
+	Guardrails *GuardrailStatus `json:"guardrails,omitempty" yaml:"guardrails,omitempty"`
+
+	// Cutover records when all traffic of a BlueGreen experiment was sent to the winner
+	// +optional
+	Cutover *Cutover `json:"cutover,omitempty" yaml:"cutover,omitempty"`
+
+	// Bandit is the state of the multi-armed bandit algorithm of spec.strategy.weights.bandit
+	// +optional
+	Bandit *BanditStatus `json:"bandit,omitempty" yaml:"bandit,omitempty"`
+
+	// UserHashAssignments records the hash values assigned to versions with userHash match rules.
+	// Once assigned, the values do not change so that users remain with the same version.
+	// +optional
+	UserHashAssignments []UserHashAssignment `json:"userHashAssignments,omitempty" yaml:"userHashAssignments,omitempty"`
+
+	// DryRunPatches are the patches that would have been applied in the latest iteration of a dry run
+	// +optional
+	DryRunPatches []ObjectPatch `json:"dryRunPatches,omitempty" yaml:"dryRunPatches,omitempty"`
+}
+
+// ObjectPatch is a JSON patch of an object
+type ObjectPatch struct {
+	// ObjRef is the object
+	ObjRef corev1.ObjectReference `json:"objRef" yaml:"objRef"`
+
+	// Patch is the JSON patch
+	Patch string `json:"patch" yaml:"patch"`
+}
+
+// UserHashAssignment is the range of hash values assigned to a version
+type UserHashAssignment struct {
+	// Version is the name of the version
+	Version string `json:"version" yaml:"version"`
+
+	// Start is the first hash value assigned to the version
+	Start int32 `json:"start" yaml:"start"`
+
+	// End is the hash value after the last one assigned to the version
+	End int32 `json:"end" yaml:"end"`
+}
+
+// HandlerAttempt is a record of a single attempt to execute an action
+type HandlerAttempt struct {
+	// Handler is the name of the action being executed
+	Handler string `json:"handler" yaml:"handler"`
+
+	// Instance distinguishes executions of handlers that can run more than once (the loop number of loop handlers)
+	// +optional
+	Instance *int32 `json:"instance,omitempty" yaml:"instance,omitempty"`
+
+	// Attempt is the attempt number; the first attempt is 1
+	Attempt int32 `json:"attempt" yaml:"attempt"`
+
+	// JobName is the name of the job executing this attempt
+	JobName string `json:"jobName" yaml:"jobName"`
+
+	// StartTime is the time the attempt was launched
+	StartTime metav1.Time `json:"startTime" yaml:"startTime"`
+
+	// CompletionTime is the time the attempt was observed to have completed, failed or timed out
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty" yaml:"completionTime,omitempty"`
+
+	// Result is the outcome of the attempt
+	Result HandlerAttemptResultType `json:"result" yaml:"result"`
+}
+
+// Cutover records the cutover of a BlueGreen experiment
+type Cutover struct {
+	// Version is the version that received all of the traffic
+	Version string `json:"version" yaml:"version"`
+
+	// Time is when the cutover took place
+	Time metav1.Time `json:"time" yaml:"time"`
+
+	// RevertTime is when traffic was reverted to the baseline, if it was
+	// +optional
+	RevertTime *metav1.Time `json:"revertTime,omitempty" yaml:"revertTime,omitempty"`
+}
+
+// GuardrailStatus is the result of a check of the guardrail objectives
+type GuardrailStatus struct {
+	// LastCheckTime is the time of the check
+	LastCheckTime metav1.Time `json:"lastCheckTime" yaml:"lastCheckTime"`
+
+	// Violations are the guardrails failed by the versions
+	// +optional
+	Violations []GuardrailViolation `json:"violations,omitempty" yaml:"violations,omitempty"`
+}
+
+// GuardrailViolation is the failure of a guardrail objective by a version
+type GuardrailViolation struct {
+	// Metric is the metric of the objective
+	Metric string `json:"metric" yaml:"metric"`
+
+	// Version is the name of the version
+	Version string `json:"version" yaml:"version"`
+
+	// Value is the value of the metric for the version
+	Value resource.Quantity `json:"value" yaml:"value"`
+}
+
+// WeightScheduleStatus is the progress of an experiment through its weight schedule
+type WeightScheduleStatus struct {
+	// Step is the index of the current step
+	Step int32 `json:"step" yaml:"step"`
+
+	// MaxCandidateWeight is the total weight of the candidates allowed by the current step
+	MaxCandidateWeight int32 `json:"maxCandidateWeight" yaml:"maxCandidateWeight"`
+
+	// Iterations is the number of iterations in which the candidates have received the weight of the current step
+	Iterations int32 `json:"iterations" yaml:"iterations"`
+}
+
+// BanditStatus is the state of a multi-armed bandit algorithm
+type BanditStatus struct {
+	// Algorithm is the algorithm
+	Algorithm BanditAlgorithmType `json:"algorithm" yaml:"algorithm"`
+
+	// Rounds is the number of iterations in which the algorithm determined the weights
+	Rounds int32 `json:"rounds" yaml:"rounds"`
+
+	// Arms are the states of the versions
+	// +optional
+	Arms []BanditArm `json:"arms,omitempty" yaml:"arms,omitempty"`
+
+	// Message explains the latest weights; for example, that the versions are explored evenly until each has a reward
+	// +optional
+	Message *string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// BanditArm is the state of a version in a multi-armed bandit algorithm
+type BanditArm struct {
+	// Name is the name of the version
+	Name string `json:"name" yaml:"name"`
+
+	// Pulls is the number of observations of the reward of the version
+	Pulls int32 `json:"pulls" yaml:"pulls"`
+
+	// Reward is the mean of the observations of the reward of the version
+	// +optional
+	Reward *resource.Quantity `json:"reward,omitempty" yaml:"reward,omitempty"`
+
+	// Score is the score of the version: the probability that it is the best version (ThompsonSampling),
+	// the upper (or lower) confidence bound of its reward (UCB), or its reward (EpsilonGreedy)
+	// +optional
+	Score *resource.Quantity `json:"score,omitempty" yaml:"score,omitempty"`
+
+	// Weight is the weight determined by the algorithm, before it is constrained by
+	// maxCandidateWeight and maxCandidateWeightIncrement
+	Weight int32 `json:"weight" yaml:"weight"`
 }
 
 // ExperimentCondition describes a condition of an experiment
@@ -423,6 +956,24 @@ type WinnerAssessmentData struct {
 	// Winner if found
 	// +optional
 	Winner *string `json:"winner,omitempty" yaml:"winner,omitempty"`
+
+	// Confidence is, for each version, the probability that it is the best version (Bayesian)
+	// or the confidence that it is better than every other version (Frequentist)
+	// +optional
+	Confidence map[string]resource.Quantity `json:"confidence,omitempty" yaml:"confidence,omitempty"`
+
+	// Scores are the composite scores of the versions, by name, when there are several rewards
+	// +optional
+	Scores map[string]RewardScore `json:"scores,omitempty" yaml:"scores,omitempty"`
+}
+
+// RewardScore is the composite score of a version
+type RewardScore struct {
+	// Score is the weighted mean of the normalized values of the rewards; higher is better
+	Score resource.Quantity `json:"score" yaml:"score"`
+
+	// Rewards are the normalized values of the rewards, by metric; higher is better
+	Rewards map[string]resource.Quantity `json:"rewards" yaml:"rewards"`
 }
 
 // AggregatedMetricsData ..
@@ -466,6 +1017,42 @@ type AggregatedMetricsVersionData struct {
 	// This field is applicable only to Gauge metrics
 	// +kubebuilder:validation:Minimum:=0
 	SampleSize *int32 `json:"sampleSize,omitempty" yaml:"sampleSize,omitempty"`
+
+	// StdDev is the standard deviation of the observations of this metric for this version
+	// This field is applicable only to Gauge metrics
+	// +optional
+	StdDev *resource.Quantity `json:"stdDev,omitempty" yaml:"stdDev,omitempty"`
+
+	// CredibleInterval contains the value of the metric for this version with the confidence of criteria.strength;
+	// for the Frequentist method, it is a confidence interval. It is computed for the reward used to assess the winner.
+	// +optional
+	CredibleInterval *Interval `json:"credibleInterval,omitempty" yaml:"credibleInterval,omitempty"`
+
+	// Histogram is the distribution of a Histogram metric observed for this version
+	// +optional
+	Histogram []HistogramBucket `json:"histogram,omitempty" yaml:"histogram,omitempty"`
+}
+
+// Interval is an interval of values of a metric
+type Interval struct {
+	// Lower is the lower bound of the interval
+	Lower resource.Quantity `json:"lower" yaml:"lower"`
+
+	// Upper is the upper bound of the interval
+	Upper resource.Quantity `json:"upper" yaml:"upper"`
+}
+
+// HistogramBucket is a bucket of the distribution of a Histogram metric
+type HistogramBucket struct {
+	// Lower is the lower bound of the bucket
+	Lower resource.Quantity `json:"lower" yaml:"lower"`
+
+	// Upper is the upper bound of the bucket; if not set, the bucket is unbounded
+	// +optional
+	Upper *resource.Quantity `json:"upper,omitempty" yaml:"upper,omitempty"`
+
+	// Count is the number of observations in the bucket
+	Count resource.Quantity `json:"count" yaml:"count"`
 }
 
 func init() {
@@ -122,7 +122,76 @@ func (b *MetricBuilder) WithMock(mock []NamedLevel) *MetricBuilder {
 	return b
 }
 
+// WithMockOptions ..
+func (b *MetricBuilder) WithMockOptions(options MockOptions) *MetricBuilder {
+	b.Spec.MockOptions = &options
+	return b
+}
+
+// WithPrometheus ..
+func (b *MetricBuilder) WithPrometheus(config PrometheusProvider) *MetricBuilder {
+	provider := ProviderPrometheus
+	b.Spec.Provider = &provider
+	b.Spec.Prometheus = &config
+	return b
+}
+
+// WithDatadog ..
+func (b *MetricBuilder) WithDatadog(config DatadogProvider) *MetricBuilder {
+	provider := ProviderDatadog
+	b.Spec.Provider = &provider
+	b.Spec.Datadog = &config
+	return b
+}
+
+// WithNewRelic ..
+func (b *MetricBuilder) WithNewRelic(config NewRelicProvider) *MetricBuilder {
+	provider := ProviderNewRelic
+	b.Spec.Provider = &provider
+	b.Spec.NewRelic = &config
+	return b
+}
+
+// WithFile ..
+func (b *MetricBuilder) WithFile(config FileProvider) *MetricBuilder {
+	provider := ProviderFile
+	b.Spec.Provider = &provider
+	b.Spec.File = &config
+	return b
+}
+
+// WithExpression makes the metric a Derived metric computed by the expression from the referenced metrics
+func (b *MetricBuilder) WithExpression(expression string, references ...MetricReference) *MetricBuilder {
+	t := DerivedMetricType
+	b.Spec.Type = &t
+	b.Spec.Expression = &expression
+	b.Spec.References = references
+	return b
+}
+
+// WithPercentiles makes the metric a Histogram metric for which the percentiles are computed
+func (b *MetricBuilder) WithPercentiles(percentiles ...int32) *MetricBuilder {
+	t := HistogramMetricType
+	b.Spec.Type = &t
+	b.Spec.Percentiles = percentiles
+	return b
+}
+
 // Build ..
 func (b *MetricBuilder) Build() *Metric {
 	return (*Metric)(b)
 }
+
+// BuildClusterMetric builds a ClusterMetric with the name and spec of the metric
+func (b *MetricBuilder) BuildClusterMetric() *ClusterMetric {
+	return &ClusterMetric{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupVersion.String(),
+			Kind:       "ClusterMetric",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: b.Name,
+		},
+		Spec: b.Spec,
+	}
+}
@@ -29,7 +29,7 @@ import (
 )
 
 // MetricType identifies the type of the metric.
-// +kubebuilder:validation:Enum=Counter;Gauge
+// +kubebuilder:validation:Enum=Counter;Gauge;Derived;Histogram
 type MetricType string
 
 const (
@@ -38,6 +38,13 @@ const (
 
 	// GaugeMetricType is an enhancement of Prometheus Gauge metric type
 	GaugeMetricType MetricType = "Gauge"
+
+	// DerivedMetricType is a metric whose value is computed by iter8 from the values of other metrics using an expression
+	DerivedMetricType MetricType = "Derived"
+
+	// HistogramMetricType is a metric whose value for a version is a distribution, returned by a provider as buckets.
+	// Percentiles of the distribution may be referenced as metrics named <name>-p<percentile>; for example, latency-p95
+	HistogramMetricType MetricType = "Histogram"
 )
 
 // AuthType identifies the type of authentication used in the HTTP request
@@ -67,12 +74,112 @@ const (
 	POSTMethodType MethodType = "POST"
 )
 
+// Names of the metric providers built into iter8. A metric that names a built-in provider in spec.provider
+// and includes its configuration is evaluated by iter8 rather than by the analytics service.
+const (
+	// ProviderPrometheus queries Prometheus
+	ProviderPrometheus = "prometheus"
+
+	// ProviderDatadog queries Datadog
+	ProviderDatadog = "datadog"
+
+	// ProviderNewRelic queries New Relic using NRQL
+	ProviderNewRelic = "newrelic"
+
+	// ProviderFile reads a CSV file; intended for tests
+	ProviderFile = "file"
+
+	// ProviderFortio reads the latency histograms collected by the builtin metrics/collect task
+	// It requires no configuration and is used only for Histogram metrics.
+	ProviderFortio = "fortio"
+
+	// ProviderMock generates values from the mock levels of the versions
+	// It is used for any metric with spec.mock, regardless of spec.provider.
+	ProviderMock = "mock"
+)
+
+// PrometheusProvider configures the built-in Prometheus provider
+type PrometheusProvider struct {
+	// URL is the URL of the Prometheus server; for example, http://prometheus.istio-system:9090
+	URL string `json:"url" yaml:"url"`
+
+	// Query is a PromQL query that evaluates to a single value.
+	// For a Histogram metric, the query evaluates to the cumulative counts of the buckets, labeled by le; for example,
+	// sum by (le) (increase(request_duration_seconds_bucket{revision='$revision'}[${elapsedTime}s]))
+	// Placeholders such as $name, $elapsedTime and the variables of the version are substituted.
+	Query string `json:"query" yaml:"query"`
+}
+
+// DatadogProvider configures the built-in Datadog provider
+// The API and application keys are read from the keys apiKey and appKey of spec.secret.
+type DatadogProvider struct {
+	// URL is the URL of the Datadog API. Default is https://api.datadoghq.com
+	// +optional
+	URL *string `json:"url,omitempty" yaml:"url,omitempty"`
+
+	// Query is a Datadog metrics query; the latest point of the first series is the value of the metric.
+	// Placeholders such as $name, $elapsedTime and the variables of the version are substituted.
+	Query string `json:"query" yaml:"query"`
+}
+
+// NewRelicProvider configures the built-in New Relic provider
+// The user API key is read from the key apiKey of spec.secret.
+type NewRelicProvider struct {
+	// URL is the URL of the New Relic NerdGraph API. Default is https://api.newrelic.com/graphql
+	// +optional
+	URL *string `json:"url,omitempty" yaml:"url,omitempty"`
+
+	// AccountID is the New Relic account queried
+	AccountID string `json:"accountID" yaml:"accountID"`
+
+	// Query is an NRQL query that returns a single value.
+	// Placeholders such as $name, $elapsedTime and the variables of the version are substituted.
+	Query string `json:"query" yaml:"query"`
+}
+
+// FileProvider configures the built-in file provider, which reads metric values from a CSV file
+// The file has a header row. The value of a version is taken from the last row for the version.
+// For a Histogram metric, each row for the version is a bucket; the value column holds the count of the bucket.
+type FileProvider struct {
+	// Path is the path of the CSV file
+	Path string `json:"path" yaml:"path"`
+
+	// VersionColumn is the name of the column that holds the name of the version. Default is version.
+	// +optional
+	VersionColumn *string `json:"versionColumn,omitempty" yaml:"versionColumn,omitempty"`
+
+	// ValueColumn is the name of the column that holds the value of the metric. Default is value.
+	// +optional
+	ValueColumn *string `json:"valueColumn,omitempty" yaml:"valueColumn,omitempty"`
+
+	// LowerColumn is the name of the column that holds the lower bound of a bucket of a Histogram metric. Default is lower.
+	// +optional
+	LowerColumn *string `json:"lowerColumn,omitempty" yaml:"lowerColumn,omitempty"`
+
+	// UpperColumn is the name of the column that holds the upper bound of a bucket of a Histogram metric. Default is upper.
+	// +optional
+	UpperColumn *string `json:"upperColumn,omitempty" yaml:"upperColumn,omitempty"`
+}
+
+// MetricReference is a reference from a Derived metric to a metric used in its expression
+type MetricReference struct {
+	// Name is the identifier of the referenced metric in the expression; for example, errors
+	// The value of the metric for the baseline is available as baseline.<name>
+	Name string `json:"name" yaml:"name"`
+
+	// Metric is the name of the referenced metric resource.
+	// If the value contains a "/", the prefix is the namespace of the metric.
+	// Otherwise, the metric is resolved in the same way as the sampleSize of the Derived metric.
+	Metric string `json:"metric" yaml:"metric"`
+}
+
 // NamedLevel contains the name of a version and the level of the version to be used in mock metric generation.
 // The semantics of level are the following:
 // If the metric is a counter, if level is x, and time elapsed since the start of the experiment is y, then x*y is the metric value.
 // Note: this will keep increasing over time as counters do.
 // If the metric is gauge, if level is x, the metric value is a random value with mean x.
 // Note: due to randomness, this stay around x but can go up or down as a gauges do.
+// The randomness, and any trend in the level, are configured by MockOptions; without them, values are deterministic.
 type NamedLevel struct {
 	// Name of the version
 	Name string `json:"name" yaml:"name"`
@@ -81,6 +188,29 @@ type NamedLevel struct {
 	Level resource.Quantity `json:"level" yaml:"level"`
 }
 
+// MockOptions configures the generation of mock metric values
+type MockOptions struct {
+	// Noise is the standard deviation of the normally distributed noise in a value, relative to the value;
+	// for example, 0.1 is 10%. Default is 0 (no noise).
+	// +optional
+	Noise *resource.Quantity `json:"noise,omitempty" yaml:"noise,omitempty"`
+
+	// Trend is the change in the level of every version per second since the start of the experiment.
+	// Default is 0 (constant levels).
+	// +optional
+	Trend *resource.Quantity `json:"trend,omitempty" yaml:"trend,omitempty"`
+
+	// FailureRate is the probability, between 0 and 1, that a value cannot be generated;
+	// it simulates an unavailable metrics backend. Default is 0.
+	// +optional
+	FailureRate *resource.Quantity `json:"failureRate,omitempty" yaml:"failureRate,omitempty"`
+
+	// Seed seeds the random number generator. For a given seed, the value of a version depends only on the
+	// number of seconds since the start of the experiment. Default is 0.
+	// +optional
+	Seed *int64 `json:"seed,omitempty" yaml:"seed,omitempty"`
+}
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -127,10 +257,28 @@ type MetricSpec struct {
 	// +optional
 	Body *string `json:"body,omitempty" yaml:"body,omitempty"`
 
-	// Provider identifies the type of metric database. Used for informational purposes.
+	// Provider identifies the type of metric database.
+	// If it names a built-in provider (prometheus, datadog, newrelic or file) and the configuration of that
+	// provider is included, iter8 evaluates the metric using the provider. Otherwise it is informational.
 	// +optional
 	Provider *string `json:"provider,omitempty" yaml:"provider,omitempty"`
 
+	// Prometheus configures the built-in prometheus provider
+	// +optional
+	Prometheus *PrometheusProvider `json:"prometheus,omitempty" yaml:"prometheus,omitempty"`
+
+	// Datadog configures the built-in datadog provider
+	// +optional
+	Datadog *DatadogProvider `json:"datadog,omitempty" yaml:"datadog,omitempty"`
+
+	// NewRelic configures the built-in newrelic provider
+	// +optional
+	NewRelic *NewRelicProvider `json:"newRelic,omitempty" yaml:"newRelic,omitempty"`
+
+	// File configures the built-in file provider
+	// +optional
+	File *FileProvider `json:"file,omitempty" yaml:"file,omitempty"`
+
 	// JQExpression defines the jq expression used by Iter8 to extract the metric value from the (JSON) response returned by the HTTP URL queried by Iter8.
 	// An empty string is a valid jq expression.
 	// +optional
@@ -156,11 +304,31 @@ type MetricSpec struct {
 	// +optional
 	URLTemplate *string `json:"urlTemplate,omitempty" yaml:"urlTemplate,omitempty"`
 
+	// Expression computes the value of a Derived metric from the metrics in References.
+	// For example, errors / requests or latency - baseline.latency
+	// This field is relevant only when Type == Derived
+	// +optional
+	Expression *string `json:"expression,omitempty" yaml:"expression,omitempty"`
+
+	// References are the metrics used in Expression
+	// This field is relevant only when Type == Derived
+	// +optional
+	References []MetricReference `json:"references,omitempty" yaml:"references,omitempty"`
+
+	// Percentiles are the percentiles computed for a Histogram metric. Default is 50, 90, 95 and 99.
+	// This field is relevant only when Type == Histogram
+	// +optional
+	Percentiles []int32 `json:"percentiles,omitempty" yaml:"percentiles,omitempty"`
+
 	// Mock enables mocking of metric values, which is useful in tests and tutorial/documentation.
 	// Iter8 metrics can be either counter (which keep increasing over time) or gauge (which can increase or decrease over time).
 	// Mock enables mocking of both.
 	// +optional
 	Mock []NamedLevel `json:"mock,omitempty" yaml:"mock,omitempty"`
+
+	// MockOptions configures the noise, trend and failures of mocked values
+	// +optional
+	MockOptions *MockOptions `json:"mockOptions,omitempty" yaml:"mockOptions,omitempty"`
 }
 
 // Metric is the Schema for the metrics API
@@ -186,6 +354,45 @@ type MetricList struct {
 	Items           []Metric `json:"items" yaml:"items"`
 }
 
+// ClusterMetric is a cluster-scoped metric
+// An experiment that references a metric by name, without a namespace, uses the ClusterMetric with that name
+// if there is no Metric with that name in the namespace of the experiment.
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="type",type="string",JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="description",type="string",JSONPath=".spec.description"
+type ClusterMetric struct {
+	metav1.TypeMeta   `json:",inline" yaml:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec MetricSpec `json:"spec,omitempty" yaml:"spec,omitempty"`
+}
+
+// ClusterMetricList contains a list of ClusterMetric
+//+kubebuilder:object:root=true
+type ClusterMetricList struct {
+	metav1.TypeMeta `json:",inline" yaml:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Items           []ClusterMetric `json:"items" yaml:"items"`
+}
+
+// AsMetric returns the ClusterMetric as a Metric with no namespace
+func (m *ClusterMetric) AsMetric() *Metric {
+	return &Metric{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupVersion.String(),
+			Kind:       "Metric",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        m.Name,
+			Labels:      m.Labels,
+			Annotations: m.Annotations,
+		},
+		Spec: *m.Spec.DeepCopy(),
+	}
+}
+
 func init() {
 	SchemeBuilder.Register(&Metric{}, &MetricList{})
+	SchemeBuilder.Register(&ClusterMetric{}, &ClusterMetricList{})
 }
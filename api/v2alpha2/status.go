@@ -117,6 +117,11 @@ func (s *ExperimentStatus) SetVersionRecommendedForPromotion(currentBaseline str
 	}
 }
 
+// GetWinner returns the winner identified by the analysis, if any
+func (s *ExperimentStatus) GetWinner() *string {
+	return identfiedWinner(s.Analysis)
+}
+
 func identfiedWinner(analysis *Analysis) *string {
 	if analysis == nil || analysis.WinnerAssessment == nil {
 		return nil
@@ -153,3 +158,58 @@ func (s *ExperimentStatus) MarkCondition(condition ExperimentConditionType, stat
 	c.LastTransitionTime = &now
 	return updated
 }
+
+// GetLatestHandlerAttempt returns the most recent attempt to execute a handler (instance)
+// Returns nil if there have been no attempts
+func (s *ExperimentStatus) GetLatestHandlerAttempt(handler string, instance *int32) *HandlerAttempt {
+	var latest *HandlerAttempt
+	for i, a := range s.HandlerAttempts {
+		if a.Handler != handler || !sameInstance(a.Instance, instance) {
+			continue
+		}
+		if latest == nil || a.Attempt > latest.Attempt {
+			latest = &s.HandlerAttempts[i]
+		}
+	}
+	return latest
+}
+
+// AddHandlerAttempt records a new (running) attempt to execute a handler (instance)
+// The attempt number is one more than that of the most recent attempt.
+// The caller is expected to set the name of the job executing the attempt.
+func (s *ExperimentStatus) AddHandlerAttempt(handler string, instance *int32) *HandlerAttempt {
+	attempt := int32(1)
+	if latest := s.GetLatestHandlerAttempt(handler, instance); latest != nil {
+		attempt = latest.Attempt + 1
+	}
+	s.HandlerAttempts = append(s.HandlerAttempts, HandlerAttempt{
+		Handler:   handler,
+		Instance:  instance,
+		Attempt:   attempt,
+		StartTime: metav1.Now(),
+		Result:    HandlerAttemptRunning,
+	})
+	return &s.HandlerAttempts[len(s.HandlerAttempts)-1]
+}
+
+// MarkHandlerAttempt sets the result of the most recent attempt to execute a handler (instance)
+// Returns true if the result changed
+func (s *ExperimentStatus) MarkHandlerAttempt(handler string, instance *int32, result HandlerAttemptResultType) bool {
+	latest := s.GetLatestHandlerAttempt(handler, instance)
+	if latest == nil || latest.Result == result {
+		return false
+	}
+	latest.Result = result
+	if result != HandlerAttemptRunning {
+		now := metav1.Now()
+		latest.CompletionTime = &now
+	}
+	return true
+}
+
+func sameInstance(a *int32, b *int32) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
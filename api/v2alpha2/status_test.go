@@ -96,3 +96,31 @@ var _ = Describe("Winner Determination", func() {
 	})
 
 })
+
+var _ = Describe("Handler Attempts", func() {
+	Context("When a handler is attempted more than once", func() {
+		It("Each attempt is recorded", func() {
+			experiment := v2alpha2.NewExperiment("test", "default").WithTarget("target").Build()
+			loop := int32(1)
+
+			By("Verifying that there are no attempts")
+			Expect(experiment.Status.GetLatestHandlerAttempt("start", nil)).Should(BeNil())
+
+			By("Recording attempts")
+			Expect(experiment.Status.AddHandlerAttempt("start", nil).Attempt).Should(Equal(int32(1)))
+			Expect(experiment.Status.AddHandlerAttempt("loop", &loop).Attempt).Should(Equal(int32(1)))
+			Expect(experiment.Status.MarkHandlerAttempt("start", nil, v2alpha2.HandlerAttemptFailed)).Should(BeTrue())
+			Expect(experiment.Status.MarkHandlerAttempt("start", nil, v2alpha2.HandlerAttemptFailed)).Should(BeFalse())
+			Expect(experiment.Status.AddHandlerAttempt("start", nil).Attempt).Should(Equal(int32(2)))
+
+			By("Checking the latest attempts")
+			latest := experiment.Status.GetLatestHandlerAttempt("start", nil)
+			Expect(latest.Attempt).Should(Equal(int32(2)))
+			Expect(latest.Result).Should(Equal(v2alpha2.HandlerAttemptRunning))
+			Expect(latest.CompletionTime).Should(BeNil())
+			Expect(experiment.Status.GetLatestHandlerAttempt("loop", &loop).Attempt).Should(Equal(int32(1)))
+			Expect(experiment.Status.GetLatestHandlerAttempt("loop", nil)).Should(BeNil())
+			Expect(experiment.Status.HandlerAttempts).Should(HaveLen(3))
+		})
+	})
+})
@@ -23,6 +23,7 @@ package v2alpha2
 import (
 	"k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -78,6 +79,31 @@ func (in ActionMap) DeepCopy() ActionMap {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActionPolicy) DeepCopyInto(out *ActionPolicy) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActionPolicy.
+func (in *ActionPolicy) DeepCopy() *ActionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ActionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AggregatedBuiltinHists) DeepCopyInto(out *AggregatedBuiltinHists) {
 	*out = *in
@@ -173,6 +199,23 @@ func (in *AggregatedMetricsVersionData) DeepCopyInto(out *AggregatedMetricsVersi
 		*out = new(int32)
 		**out = **in
 	}
+	if in.StdDev != nil {
+		in, out := &in.StdDev, &out.StdDev
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.CredibleInterval != nil {
+		in, out := &in.CredibleInterval, &out.CredibleInterval
+		*out = new(Interval)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Histogram != nil {
+		in, out := &in.Histogram, &out.Histogram
+		*out = make([]HistogramBucket, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AggregatedMetricsVersionData.
@@ -246,6 +289,78 @@ func (in *AnalysisMetaData) DeepCopy() *AnalysisMetaData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BanditArm) DeepCopyInto(out *BanditArm) {
+	*out = *in
+	if in.Reward != nil {
+		in, out := &in.Reward, &out.Reward
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Score != nil {
+		in, out := &in.Score, &out.Score
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BanditArm.
+func (in *BanditArm) DeepCopy() *BanditArm {
+	if in == nil {
+		return nil
+	}
+	out := new(BanditArm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BanditStatus) DeepCopyInto(out *BanditStatus) {
+	*out = *in
+	if in.Arms != nil {
+		in, out := &in.Arms, &out.Arms
+		*out = make([]BanditArm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Message != nil {
+		in, out := &in.Message, &out.Message
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BanditStatus.
+func (in *BanditStatus) DeepCopy() *BanditStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BanditStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreen) DeepCopyInto(out *BlueGreen) {
+	*out = *in
+	if in.RollbackWindowSeconds != nil {
+		in, out := &in.RollbackWindowSeconds, &out.RollbackWindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreen.
+func (in *BlueGreen) DeepCopy() *BlueGreen {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreen)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in BooleanList) DeepCopyInto(out *BooleanList) {
 	{
@@ -265,6 +380,79 @@ func (in BooleanList) DeepCopy() BooleanList {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterMetric) DeepCopyInto(out *ClusterMetric) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterMetric.
+func (in *ClusterMetric) DeepCopy() *ClusterMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterMetric) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterMetricList) DeepCopyInto(out *ClusterMetricList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterMetricList.
+func (in *ClusterMetricList) DeepCopy() *ClusterMetricList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterMetricList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterMetricList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CookieMatch) DeepCopyInto(out *CookieMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CookieMatch.
+func (in *CookieMatch) DeepCopy() *CookieMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(CookieMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Criteria) DeepCopyInto(out *Criteria) {
 	*out = *in
@@ -276,7 +464,14 @@ func (in *Criteria) DeepCopyInto(out *Criteria) {
 	if in.Rewards != nil {
 		in, out := &in.Rewards, &out.Rewards
 		*out = make([]Reward, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RewardNormalization != nil {
+		in, out := &in.RewardNormalization, &out.RewardNormalization
+		*out = new(RewardNormalizationType)
+		**out = **in
 	}
 	if in.Indicators != nil {
 		in, out := &in.Indicators, &out.Indicators
@@ -290,7 +485,16 @@ func (in *Criteria) DeepCopyInto(out *Criteria) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	in.Strength.DeepCopyInto(&out.Strength)
+	if in.Strength != nil {
+		in, out := &in.Strength, &out.Strength
+		*out = new(Strength)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetricsRefreshPolicy != nil {
+		in, out := &in.MetricsRefreshPolicy, &out.MetricsRefreshPolicy
+		*out = new(MetricsRefreshPolicyType)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Criteria.
@@ -303,6 +507,46 @@ func (in *Criteria) DeepCopy() *Criteria {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Cutover) DeepCopyInto(out *Cutover) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.RevertTime != nil {
+		in, out := &in.RevertTime, &out.RevertTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cutover.
+func (in *Cutover) DeepCopy() *Cutover {
+	if in == nil {
+		return nil
+	}
+	out := new(Cutover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatadogProvider) DeepCopyInto(out *DatadogProvider) {
+	*out = *in
+	if in.URL != nil {
+		in, out := &in.URL, &out.URL
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatadogProvider.
+func (in *DatadogProvider) DeepCopy() *DatadogProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(DatadogProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Duration) DeepCopyInto(out *Duration) {
 	*out = *in
@@ -321,6 +565,11 @@ func (in *Duration) DeepCopyInto(out *Duration) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.GuardrailIntervalSeconds != nil {
+		in, out := &in.GuardrailIntervalSeconds, &out.GuardrailIntervalSeconds
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Duration.
@@ -534,6 +783,43 @@ func (in *ExperimentStatus) DeepCopyInto(out *ExperimentStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.HandlerAttempts != nil {
+		in, out := &in.HandlerAttempts, &out.HandlerAttempts
+		*out = make([]HandlerAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WeightSchedule != nil {
+		in, out := &in.WeightSchedule, &out.WeightSchedule
+		*out = new(WeightScheduleStatus)
+		**out = **in
+	}
+	if in.Guardrails != nil {
+		in, out := &in.Guardrails, &out.Guardrails
+		*out = new(GuardrailStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cutover != nil {
+		in, out := &in.Cutover, &out.Cutover
+		*out = new(Cutover)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Bandit != nil {
+		in, out := &in.Bandit, &out.Bandit
+		*out = new(BanditStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UserHashAssignments != nil {
+		in, out := &in.UserHashAssignments, &out.UserHashAssignments
+		*out = make([]UserHashAssignment, len(*in))
+		copy(*out, *in)
+	}
+	if in.DryRunPatches != nil {
+		in, out := &in.DryRunPatches, &out.DryRunPatches
+		*out = make([]ObjectPatch, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentStatus.
@@ -547,95 +833,370 @@ func (in *ExperimentStatus) DeepCopy() *ExperimentStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Metric) DeepCopyInto(out *Metric) {
+func (in *FileProvider) DeepCopyInto(out *FileProvider) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	if in.VersionColumn != nil {
+		in, out := &in.VersionColumn, &out.VersionColumn
+		*out = new(string)
+		**out = **in
+	}
+	if in.ValueColumn != nil {
+		in, out := &in.ValueColumn, &out.ValueColumn
+		*out = new(string)
+		**out = **in
+	}
+	if in.LowerColumn != nil {
+		in, out := &in.LowerColumn, &out.LowerColumn
+		*out = new(string)
+		**out = **in
+	}
+	if in.UpperColumn != nil {
+		in, out := &in.UpperColumn, &out.UpperColumn
+		*out = new(string)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metric.
-func (in *Metric) DeepCopy() *Metric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileProvider.
+func (in *FileProvider) DeepCopy() *FileProvider {
 	if in == nil {
 		return nil
 	}
-	out := new(Metric)
+	out := new(FileProvider)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Metric) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MetricBuilder) DeepCopyInto(out *MetricBuilder) {
+func (in *GuardrailStatus) DeepCopyInto(out *GuardrailStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	in.LastCheckTime.DeepCopyInto(&out.LastCheckTime)
+	if in.Violations != nil {
+		in, out := &in.Violations, &out.Violations
+		*out = make([]GuardrailViolation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricBuilder.
-func (in *MetricBuilder) DeepCopy() *MetricBuilder {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuardrailStatus.
+func (in *GuardrailStatus) DeepCopy() *GuardrailStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MetricBuilder)
+	out := new(GuardrailStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MetricInfo) DeepCopyInto(out *MetricInfo) {
+func (in *GuardrailViolation) DeepCopyInto(out *GuardrailViolation) {
 	*out = *in
-	in.MetricObj.DeepCopyInto(&out.MetricObj)
+	out.Value = in.Value.DeepCopy()
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricInfo.
-func (in *MetricInfo) DeepCopy() *MetricInfo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuardrailViolation.
+func (in *GuardrailViolation) DeepCopy() *GuardrailViolation {
 	if in == nil {
 		return nil
 	}
-	out := new(MetricInfo)
+	out := new(GuardrailViolation)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MetricList) DeepCopyInto(out *MetricList) {
+func (in *HandlerAttempt) DeepCopyInto(out *HandlerAttempt) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]Metric, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Instance != nil {
+		in, out := &in.Instance, &out.Instance
+		*out = new(int32)
+		**out = **in
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricList.
-func (in *MetricList) DeepCopy() *MetricList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HandlerAttempt.
+func (in *HandlerAttempt) DeepCopy() *HandlerAttempt {
 	if in == nil {
 		return nil
 	}
-	out := new(MetricList)
+	out := new(HandlerAttempt)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MetricList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HandlerTemplate) DeepCopyInto(out *HandlerTemplate) {
+	*out = *in
+	if in.Image != nil {
+		in, out := &in.Image, &out.Image
+		*out = new(string)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecretMounts != nil {
+		in, out := &in.SecretMounts, &out.SecretMounts
+		*out = make([]SecretMount, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HandlerTemplate.
+func (in *HandlerTemplate) DeepCopy() *HandlerTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(HandlerTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderMatch) DeepCopyInto(out *HeaderMatch) {
+	*out = *in
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(HeaderMatchType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderMatch.
+func (in *HeaderMatch) DeepCopy() *HeaderMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HistogramBucket) DeepCopyInto(out *HistogramBucket) {
+	*out = *in
+	out.Lower = in.Lower.DeepCopy()
+	if in.Upper != nil {
+		in, out := &in.Upper, &out.Upper
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	out.Count = in.Count.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HistogramBucket.
+func (in *HistogramBucket) DeepCopy() *HistogramBucket {
+	if in == nil {
+		return nil
+	}
+	out := new(HistogramBucket)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Interval) DeepCopyInto(out *Interval) {
+	*out = *in
+	out.Lower = in.Lower.DeepCopy()
+	out.Upper = in.Upper.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Interval.
+func (in *Interval) DeepCopy() *Interval {
+	if in == nil {
+		return nil
+	}
+	out := new(Interval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchRule) DeepCopyInto(out *MatchRule) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HeaderMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Cookie != nil {
+		in, out := &in.Cookie, &out.Cookie
+		*out = new(CookieMatch)
+		**out = **in
+	}
+	if in.UserHash != nil {
+		in, out := &in.UserHash, &out.UserHash
+		*out = new(UserHashMatch)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchRule.
+func (in *MatchRule) DeepCopy() *MatchRule {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metric) DeepCopyInto(out *Metric) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metric.
+func (in *Metric) DeepCopy() *Metric {
+	if in == nil {
+		return nil
+	}
+	out := new(Metric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Metric) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricBuilder) DeepCopyInto(out *MetricBuilder) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricBuilder.
+func (in *MetricBuilder) DeepCopy() *MetricBuilder {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricBuilder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricInfo) DeepCopyInto(out *MetricInfo) {
+	*out = *in
+	in.MetricObj.DeepCopyInto(&out.MetricObj)
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(MetricSourceType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricInfo.
+func (in *MetricInfo) DeepCopy() *MetricInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricList) DeepCopyInto(out *MetricList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Metric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricList.
+func (in *MetricList) DeepCopy() *MetricList {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MetricList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricReference) DeepCopyInto(out *MetricReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricReference.
+func (in *MetricReference) DeepCopy() *MetricReference {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricReference)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -686,6 +1247,26 @@ func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		*out = new(PrometheusProvider)
+		**out = **in
+	}
+	if in.Datadog != nil {
+		in, out := &in.Datadog, &out.Datadog
+		*out = new(DatadogProvider)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NewRelic != nil {
+		in, out := &in.NewRelic, &out.NewRelic
+		*out = new(NewRelicProvider)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.File != nil {
+		in, out := &in.File, &out.File
+		*out = new(FileProvider)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.JQExpression != nil {
 		in, out := &in.JQExpression, &out.JQExpression
 		*out = new(string)
@@ -706,6 +1287,21 @@ func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Expression != nil {
+		in, out := &in.Expression, &out.Expression
+		*out = new(string)
+		**out = **in
+	}
+	if in.References != nil {
+		in, out := &in.References, &out.References
+		*out = make([]MetricReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Percentiles != nil {
+		in, out := &in.Percentiles, &out.Percentiles
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
 	if in.Mock != nil {
 		in, out := &in.Mock, &out.Mock
 		*out = make([]NamedLevel, len(*in))
@@ -713,45 +1309,141 @@ func (in *MetricSpec) DeepCopyInto(out *MetricSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MockOptions != nil {
+		in, out := &in.MockOptions, &out.MockOptions
+		*out = new(MockOptions)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSpec.
+func (in *MetricSpec) DeepCopy() *MetricSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Mirroring) DeepCopyInto(out *Mirroring) {
+	*out = *in
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Mirroring.
+func (in *Mirroring) DeepCopy() *Mirroring {
+	if in == nil {
+		return nil
+	}
+	out := new(Mirroring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MockOptions) DeepCopyInto(out *MockOptions) {
+	*out = *in
+	if in.Noise != nil {
+		in, out := &in.Noise, &out.Noise
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Trend != nil {
+		in, out := &in.Trend, &out.Trend
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.FailureRate != nil {
+		in, out := &in.FailureRate, &out.FailureRate
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Seed != nil {
+		in, out := &in.Seed, &out.Seed
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockOptions.
+func (in *MockOptions) DeepCopy() *MockOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(MockOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamedLevel) DeepCopyInto(out *NamedLevel) {
+	*out = *in
+	out.Level = in.Level.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamedLevel.
+func (in *NamedLevel) DeepCopy() *NamedLevel {
+	if in == nil {
+		return nil
+	}
+	out := new(NamedLevel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamedValue) DeepCopyInto(out *NamedValue) {
+	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricSpec.
-func (in *MetricSpec) DeepCopy() *MetricSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamedValue.
+func (in *NamedValue) DeepCopy() *NamedValue {
 	if in == nil {
 		return nil
 	}
-	out := new(MetricSpec)
+	out := new(NamedValue)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NamedLevel) DeepCopyInto(out *NamedLevel) {
+func (in *NewRelicProvider) DeepCopyInto(out *NewRelicProvider) {
 	*out = *in
-	out.Level = in.Level.DeepCopy()
+	if in.URL != nil {
+		in, out := &in.URL, &out.URL
+		*out = new(string)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamedLevel.
-func (in *NamedLevel) DeepCopy() *NamedLevel {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NewRelicProvider.
+func (in *NewRelicProvider) DeepCopy() *NewRelicProvider {
 	if in == nil {
 		return nil
 	}
-	out := new(NamedLevel)
+	out := new(NewRelicProvider)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NamedValue) DeepCopyInto(out *NamedValue) {
+func (in *ObjectPatch) DeepCopyInto(out *ObjectPatch) {
 	*out = *in
+	out.ObjRef = in.ObjRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamedValue.
-func (in *NamedValue) DeepCopy() *NamedValue {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectPatch.
+func (in *ObjectPatch) DeepCopy() *ObjectPatch {
 	if in == nil {
 		return nil
 	}
-	out := new(NamedValue)
+	out := new(ObjectPatch)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -769,11 +1461,21 @@ func (in *Objective) DeepCopyInto(out *Objective) {
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.Relative != nil {
+		in, out := &in.Relative, &out.Relative
+		*out = new(RelativeLimits)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.RollbackOnFailure != nil {
 		in, out := &in.RollbackOnFailure, &out.RollbackOnFailure
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Guardrail != nil {
+		in, out := &in.Guardrail, &out.Guardrail
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Objective.
@@ -786,9 +1488,59 @@ func (in *Objective) DeepCopy() *Objective {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusProvider) DeepCopyInto(out *PrometheusProvider) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusProvider.
+func (in *PrometheusProvider) DeepCopy() *PrometheusProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RelativeLimits) DeepCopyInto(out *RelativeLimits) {
+	*out = *in
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(RelativeLimitType)
+		**out = **in
+	}
+	if in.UpperLimit != nil {
+		in, out := &in.UpperLimit, &out.UpperLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.LowerLimit != nil {
+		in, out := &in.LowerLimit, &out.LowerLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelativeLimits.
+func (in *RelativeLimits) DeepCopy() *RelativeLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(RelativeLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Reward) DeepCopyInto(out *Reward) {
 	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Reward.
@@ -801,6 +1553,44 @@ func (in *Reward) DeepCopy() *Reward {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RewardScore) DeepCopyInto(out *RewardScore) {
+	*out = *in
+	out.Score = in.Score.DeepCopy()
+	if in.Rewards != nil {
+		in, out := &in.Rewards, &out.Rewards
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RewardScore.
+func (in *RewardScore) DeepCopy() *RewardScore {
+	if in == nil {
+		return nil
+	}
+	out := new(RewardScore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretMount) DeepCopyInto(out *SecretMount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretMount.
+func (in *SecretMount) DeepCopy() *SecretMount {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretMount)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Strategy) DeepCopyInto(out *Strategy) {
 	*out = *in
@@ -826,11 +1616,38 @@ func (in *Strategy) DeepCopyInto(out *Strategy) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.ActionPolicies != nil {
+		in, out := &in.ActionPolicies, &out.ActionPolicies
+		*out = make(map[string]ActionPolicy, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	if in.Weights != nil {
 		in, out := &in.Weights, &out.Weights
 		*out = new(Weights)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreen)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mirroring != nil {
+		in, out := &in.Mirroring, &out.Mirroring
+		*out = new(Mirroring)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HandlerTemplate != nil {
+		in, out := &in.HandlerTemplate, &out.HandlerTemplate
+		*out = new(HandlerTemplate)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Strategy.
@@ -843,6 +1660,41 @@ func (in *Strategy) DeepCopy() *Strategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Strength) DeepCopyInto(out *Strength) {
+	*out = *in
+	if in.Method != nil {
+		in, out := &in.Method, &out.Method
+		*out = new(AnalysisMethodType)
+		**out = **in
+	}
+	if in.Model != nil {
+		in, out := &in.Model, &out.Model
+		*out = new(RewardModelType)
+		**out = **in
+	}
+	if in.Confidence != nil {
+		in, out := &in.Confidence, &out.Confidence
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MinSampleSize != nil {
+		in, out := &in.MinSampleSize, &out.MinSampleSize
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Strength.
+func (in *Strength) DeepCopy() *Strength {
+	if in == nil {
+		return nil
+	}
+	out := new(Strength)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TaskSpec) DeepCopyInto(out *TaskSpec) {
 	*out = *in
@@ -868,6 +1720,11 @@ func (in *TaskSpec) DeepCopyInto(out *TaskSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Executor != nil {
+		in, out := &in.Executor, &out.Executor
+		*out = new(ExecutorType)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaskSpec.
@@ -880,6 +1737,36 @@ func (in *TaskSpec) DeepCopy() *TaskSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserHashAssignment) DeepCopyInto(out *UserHashAssignment) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserHashAssignment.
+func (in *UserHashAssignment) DeepCopy() *UserHashAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(UserHashAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserHashMatch) DeepCopyInto(out *UserHashMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserHashMatch.
+func (in *UserHashMatch) DeepCopy() *UserHashMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(UserHashMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionAssessmentAnalysis) DeepCopyInto(out *VersionAssessmentAnalysis) {
 	*out = *in
@@ -911,6 +1798,26 @@ func (in *VersionAssessmentAnalysis) DeepCopy() *VersionAssessmentAnalysis {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionBackend) DeepCopyInto(out *VersionBackend) {
+	*out = *in
+	if in.Subset != nil {
+		in, out := &in.Subset, &out.Subset
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionBackend.
+func (in *VersionBackend) DeepCopy() *VersionBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionDetail) DeepCopyInto(out *VersionDetail) {
 	*out = *in
@@ -924,6 +1831,23 @@ func (in *VersionDetail) DeepCopyInto(out *VersionDetail) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.Backend != nil {
+		in, out := &in.Backend, &out.Backend
+		*out = new(VersionBackend)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WeightFormat != nil {
+		in, out := &in.WeightFormat, &out.WeightFormat
+		*out = new(WeightFormat)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = make([]MatchRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionDetail.
@@ -974,6 +1898,106 @@ func (in *WeightData) DeepCopy() *WeightData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightFormat) DeepCopyInto(out *WeightFormat) {
+	*out = *in
+	if in.ValueType != nil {
+		in, out := &in.ValueType, &out.ValueType
+		*out = new(WeightValueType)
+		**out = **in
+	}
+	if in.Scale != nil {
+		in, out := &in.Scale, &out.Scale
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightFormat.
+func (in *WeightFormat) DeepCopy() *WeightFormat {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightFormat)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightRamp) DeepCopyInto(out *WeightRamp) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Increment != nil {
+		in, out := &in.Increment, &out.Increment
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Factor != nil {
+		in, out := &in.Factor, &out.Factor
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightRamp.
+func (in *WeightRamp) DeepCopy() *WeightRamp {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightRamp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightSchedule) DeepCopyInto(out *WeightSchedule) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ramp != nil {
+		in, out := &in.Ramp, &out.Ramp
+		*out = new(WeightRamp)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DwellIterations != nil {
+		in, out := &in.DwellIterations, &out.DwellIterations
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightSchedule.
+func (in *WeightSchedule) DeepCopy() *WeightSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightScheduleStatus) DeepCopyInto(out *WeightScheduleStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightScheduleStatus.
+func (in *WeightScheduleStatus) DeepCopy() *WeightScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Weights) DeepCopyInto(out *Weights) {
 	*out = *in
@@ -987,6 +2011,36 @@ func (in *Weights) DeepCopyInto(out *Weights) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Backend != nil {
+		in, out := &in.Backend, &out.Backend
+		*out = new(WeightBackendType)
+		**out = **in
+	}
+	if in.DriftPolicy != nil {
+		in, out := &in.DriftPolicy, &out.DriftPolicy
+		*out = new(DriftPolicyType)
+		**out = **in
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(WeightSchedule)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Bandit != nil {
+		in, out := &in.Bandit, &out.Bandit
+		*out = new(BanditAlgorithmType)
+		**out = **in
+	}
+	if in.BanditModel != nil {
+		in, out := &in.BanditModel, &out.BanditModel
+		*out = new(RewardModelType)
+		**out = **in
+	}
+	if in.Epsilon != nil {
+		in, out := &in.Epsilon, &out.Epsilon
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Weights.
@@ -1045,6 +2099,20 @@ func (in *WinnerAssessmentData) DeepCopyInto(out *WinnerAssessmentData) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Confidence != nil {
+		in, out := &in.Confidence, &out.Confidence
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Scores != nil {
+		in, out := &in.Scores, &out.Scores
+		*out = make(map[string]RewardScore, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WinnerAssessmentData.
@@ -0,0 +1,177 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// bandit.go - weights of A/B/N experiments determined by a multi-armed bandit (spec.strategy.weights.bandit)
+//    - the observations of the reward, described by spec.strategy.weights.banditModel, are the rewards
+//      of the arms; an experiment with a bandit has exactly one reward; versions that do not satisfy
+//      all objectives receive no more traffic than the constraints below force them to keep
+//    - until every version has an observed reward, the versions are explored evenly
+//    - no candidate receives more than maxCandidateWeight, nor more than maxCandidateWeightIncrement over
+//      its current weight; the baseline receives the remainder
+//    - the state of the algorithm is recorded in status.bandit
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/iter8-tools/etc3/analysis"
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// banditProvenance identifies weights determined by a multi-armed bandit
+const banditProvenance = "iter8 controller (bandit)"
+
+// banditWeights replaces the recommended weights of an A/B/N experiment with those determined by
+// the multi-armed bandit of spec.strategy.weights.bandit, if set
+func banditWeights(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("banditWeights called")
+	defer log.Info("banditWeights completed")
+
+	algorithm := instance.Spec.GetBandit()
+	criteria := instance.Spec.Criteria
+	if algorithm == nil || instance.Spec.Strategy.TestingPattern != v2alpha2.TestingPatternABN ||
+		!shouldRedistribute(instance) || isBlueGreen(instance) || instance.Spec.VersionInfo == nil ||
+		instance.Status.Analysis == nil || criteria == nil || len(criteria.Rewards) == 0 {
+		return
+	}
+	reward := criteria.Rewards[0]
+	key := metricInfoName(instance, reward.Metric)
+	model := instance.Spec.GetBanditModel()
+
+	state := instance.Status.Bandit
+	if state == nil || state.Algorithm != *algorithm {
+		state = &v2alpha2.BanditStatus{Algorithm: *algorithm}
+		instance.Status.Bandit = state
+	}
+	state.Rounds++
+	state.Message = nil
+
+	fractions := map[string]float64{}
+	scores := map[string]float64{}
+	samples, err := rewardSamples(instance, key, model)
+	if err == nil && len(samples) == 0 {
+		err = fmt.Errorf("no version satisfies the objectives")
+	}
+	if err == nil {
+		var allocations map[string]analysis.Allocation
+		allocations, err = analysis.Allocate(samples, analysis.BanditOptions{
+			Algorithm:      *algorithm,
+			Model:          model,
+			HigherIsBetter: reward.PreferredDirection == v2alpha2.PreferredDirectionHigher,
+			Epsilon:        instance.Spec.GetEpsilon(),
+			Seed:           uint64(instance.Status.GetCompletedIterations()),
+		})
+		for version, a := range allocations {
+			fractions[version] = a.Fraction
+			scores[version] = a.Score
+		}
+	}
+	if err != nil {
+		log.Info("Exploring versions evenly", "reason", err.Error())
+		message := fmt.Sprintf("versions are explored evenly: %s", err.Error())
+		state.Message = &message
+		for _, name := range versionNames(instance) {
+			fractions[name] = 1 / float64(len(versionNames(instance)))
+		}
+		scores = map[string]float64{}
+	}
+
+	desired := weightsFromFractions(versionNames(instance), fractions)
+	weights := constrainCandidateWeights(instance, desired)
+	log.Info("banditWeights", "algorithm", *algorithm, "desired", desired, "constrained", weights)
+	setWeights(instance, weights, banditProvenance)
+
+	state.Arms = []v2alpha2.BanditArm{}
+	pulls := map[string]int32{}
+	rewards := map[string]float64{}
+	for _, s := range samples {
+		pulls[s.Version] = int32(s.Size)
+		rewards[s.Version] = s.Value
+	}
+	for _, w := range desired {
+		arm := v2alpha2.BanditArm{Name: w.Name, Pulls: pulls[w.Name], Weight: w.Value}
+		if value, ok := rewards[w.Name]; ok {
+			if q, ok := quantity(value); ok {
+				arm.Reward = &q
+			}
+		}
+		if score, ok := scores[w.Name]; ok {
+			if q, ok := quantity(score); ok {
+				arm.Score = &q
+			}
+		}
+		state.Arms = append(state.Arms, arm)
+	}
+}
+
+// weightsFromFractions converts fractions of traffic to integer weights that add up to 100
+// Weights are rounded down; the remaining percent are given to the versions with the largest remainders.
+func weightsFromFractions(names []string, fractions map[string]float64) []v2alpha2.WeightData {
+	weights := make([]v2alpha2.WeightData, len(names))
+	remainders := make([]float64, len(names))
+	total := int32(0)
+	for i, name := range names {
+		exact := fractions[name] * 100
+		weights[i] = v2alpha2.WeightData{Name: name, Value: int32(exact)}
+		remainders[i] = exact - float64(weights[i].Value)
+		total += weights[i].Value
+	}
+	order := make([]int, len(names))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; total < 100 && len(order) > 0; i = (i + 1) % len(order) {
+		weights[order[i]].Value++
+		total++
+	}
+	return weights
+}
+
+// constrainCandidateWeights reduces the weight of each candidate, if necessary, to no more than
+// spec.strategy.weights.maxCandidateWeight and no more than its current weight plus
+// spec.strategy.weights.maxCandidateWeightIncrement. The baseline receives the remainder.
+func constrainCandidateWeights(instance *v2alpha2.Experiment, weights []v2alpha2.WeightData) []v2alpha2.WeightData {
+	current := map[string]int32{}
+	for _, w := range instance.Status.CurrentWeightDistribution {
+		current[w.Name] = w.Value
+	}
+	baseline := instance.Spec.VersionInfo.Baseline.Name
+	constrained := make([]v2alpha2.WeightData, len(weights))
+	baselineIndex := -1
+	candidateTotal := int32(0)
+	for i, w := range weights {
+		constrained[i] = w
+		if w.Name == baseline {
+			baselineIndex = i
+			continue
+		}
+		max := instance.Spec.GetMaxCandidateWeight()
+		if increment := current[w.Name] + instance.Spec.GetMaxCandidateWeightIncrement(); increment < max {
+			max = increment
+		}
+		if constrained[i].Value > max {
+			constrained[i].Value = max
+		}
+		candidateTotal += constrained[i].Value
+	}
+	if baselineIndex >= 0 {
+		constrained[baselineIndex].Value = 100 - candidateTotal
+	}
+	return constrained
+}
@@ -0,0 +1,176 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bandit Weights", func() {
+	versionData := func(value string, stdDev string, sampleSize int32) v2alpha2.AggregatedMetricsVersionData {
+		v, sd := resource.MustParse(value), resource.MustParse(stdDev)
+		return v2alpha2.AggregatedMetricsVersionData{Value: &v, StdDev: &sd, SampleSize: &sampleSize}
+	}
+
+	bldr := func(algorithm *v2alpha2.BanditAlgorithmType) *v2alpha2.Experiment {
+		reward := v2alpha2.NewMetric("revenue", "default").Build()
+		b := v2alpha2.NewExperiment("bandit", "default").
+			WithTarget("target").
+			WithTestingPattern(v2alpha2.TestingPatternABN).
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithCandidateVersion("v3", nil).
+			WithReward(*reward, v2alpha2.PreferredDirectionHigher).
+			WithCurrentWeight("v1", 40).
+			WithCurrentWeight("v2", 30).
+			WithCurrentWeight("v3", 30).
+			WithRecommendedWeight("v1", 34).
+			WithRecommendedWeight("v2", 33).
+			WithRecommendedWeight("v3", 33)
+		if algorithm != nil {
+			// revenue is the mean of its observations
+			b = b.WithBandit(*algorithm).WithBanditModel(v2alpha2.RewardModelNormal).WithEpsilon("0.3")
+		}
+		experiment := b.Build()
+		maxCandidateWeight, maxCandidateWeightIncrement := int32(60), int32(20)
+		if experiment.Spec.Strategy.Weights == nil {
+			experiment.Spec.Strategy.Weights = &v2alpha2.Weights{}
+		}
+		experiment.Spec.Strategy.Weights.MaxCandidateWeight = &maxCandidateWeight
+		experiment.Spec.Strategy.Weights.MaxCandidateWeightIncrement = &maxCandidateWeightIncrement
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{{Name: "default/revenue", MetricObj: *reward}}
+		experiment.Status.Analysis.AggregatedMetrics = &v2alpha2.AggregatedMetricsAnalysis{
+			Data: map[string]v2alpha2.AggregatedMetricsData{
+				"default/revenue": {Data: map[string]v2alpha2.AggregatedMetricsVersionData{
+					"v1": versionData("100", "20", 100),
+					"v2": versionData("150", "20", 100),
+					"v3": versionData("110", "20", 100),
+				}},
+			},
+		}
+		return experiment
+	}
+
+	weightOf := func(experiment *v2alpha2.Experiment, version string) int32 {
+		for _, w := range experiment.Status.Analysis.Weights.Data {
+			if w.Name == version {
+				return w.Value
+			}
+		}
+		return -1
+	}
+
+	Context("When no bandit is set", func() {
+		It("the recommended weights are used", func() {
+			experiment := bldr(nil)
+			banditWeights(ctx(), experiment)
+			Expect(weightOf(experiment, "v2")).To(Equal(int32(33)))
+			Expect(experiment.Status.Bandit).To(BeNil())
+		})
+	})
+
+	Context("When the EpsilonGreedy bandit is set", func() {
+		It("the best version is exploited within maxCandidateWeight and maxCandidateWeightIncrement", func() {
+			algorithm := v2alpha2.BanditAlgorithmEpsilonGreedy
+			experiment := bldr(&algorithm)
+			banditWeights(ctx(), experiment)
+			Expect(experiment.Status.Analysis.Weights.Provenance).To(Equal(banditProvenance))
+			// v2 wants 80 but may increase by 20 only; v3 wants 10
+			Expect(weightOf(experiment, "v2")).To(Equal(int32(50)))
+			Expect(weightOf(experiment, "v3")).To(Equal(int32(10)))
+			Expect(weightOf(experiment, "v1")).To(Equal(int32(40)))
+
+			state := experiment.Status.Bandit
+			Expect(state.Algorithm).To(Equal(algorithm))
+			Expect(state.Rounds).To(Equal(int32(1)))
+			Expect(state.Arms).To(HaveLen(3))
+			Expect(state.Arms[1].Name).To(Equal("v2"))
+			Expect(state.Arms[1].Weight).To(Equal(int32(80)))
+			Expect(state.Arms[1].Pulls).To(Equal(int32(100)))
+			Expect(state.Arms[1].Reward.AsApproximateFloat64()).To(BeNumerically("~", 150))
+
+			// a second round caps v2 at maxCandidateWeight
+			experiment.Status.CurrentWeightDistribution = experiment.Status.Analysis.Weights.Data
+			banditWeights(ctx(), experiment)
+			Expect(weightOf(experiment, "v2")).To(Equal(int32(60)))
+			Expect(experiment.Status.Bandit.Rounds).To(Equal(int32(2)))
+		})
+
+		It("versions that do not satisfy the objectives are not exploited", func() {
+			algorithm := v2alpha2.BanditAlgorithmEpsilonGreedy
+			experiment := bldr(&algorithm)
+			experiment.Status.Analysis.VersionAssessments = &v2alpha2.VersionAssessmentAnalysis{
+				Data: map[string]v2alpha2.BooleanList{"v1": {true}, "v2": {false}, "v3": {true}},
+			}
+			banditWeights(ctx(), experiment)
+			Expect(weightOf(experiment, "v2")).To(Equal(int32(0)))
+			Expect(weightOf(experiment, "v3")).To(Equal(int32(50)))
+		})
+	})
+
+	Context("When the rewards are evaluated by the controller", func() {
+		It("the samples are taken from the recorded metrics", func() {
+			algorithm := v2alpha2.BanditAlgorithmEpsilonGreedy
+			experiment := bldr(&algorithm)
+			users := v2alpha2.NewMetric("users", "default").Build()
+			conversion := v2alpha2.NewMetric("conversion", "default").WithSampleSize("users").Build()
+			experiment.Spec.Criteria.Rewards = []v2alpha2.Reward{{Metric: "default/conversion", PreferredDirection: v2alpha2.PreferredDirectionHigher}}
+			experiment.Spec.Strategy.Weights.BanditModel = nil
+			experiment.Status.Metrics = []v2alpha2.MetricInfo{
+				{Name: "default/conversion", MetricObj: *conversion},
+				{Name: "default/users", MetricObj: *users},
+			}
+			experiment.Status.Analysis.AggregatedMetrics = nil
+			recordMetricValues(experiment, map[string]map[string]float64{
+				"default/conversion": {"v1": 0.10, "v2": 0.20, "v3": 0.12},
+				"default/users":      {"v1": 1000, "v2": 1000, "v3": 1000},
+			}, builtinProvenance)
+			recordSampleSizes(experiment)
+
+			banditWeights(ctx(), experiment)
+			Expect(experiment.Status.Bandit.Message).To(BeNil())
+			Expect(experiment.Status.Bandit.Arms[1].Pulls).To(Equal(int32(1000)))
+			Expect(weightOf(experiment, "v2")).To(Equal(int32(50)))
+			Expect(weightOf(experiment, "v3")).To(Equal(int32(10)))
+		})
+	})
+
+	Context("When the reward has not been observed for every version", func() {
+		It("the versions are explored evenly", func() {
+			algorithm := v2alpha2.BanditAlgorithmThompsonSampling
+			experiment := bldr(&algorithm)
+			delete(experiment.Status.Analysis.AggregatedMetrics.Data["default/revenue"].Data, "v3")
+			banditWeights(ctx(), experiment)
+			Expect(weightOf(experiment, "v1")).To(Equal(int32(34)))
+			Expect(weightOf(experiment, "v2")).To(Equal(int32(33)))
+			Expect(weightOf(experiment, "v3")).To(Equal(int32(33)))
+			Expect(experiment.Status.Bandit.Message).ToNot(BeNil())
+		})
+	})
+
+	Context("When the experiment is not an A/B/N experiment", func() {
+		It("the bandit is ignored", func() {
+			algorithm := v2alpha2.BanditAlgorithmUCB
+			experiment := bldr(&algorithm)
+			experiment.Spec.Strategy.TestingPattern = v2alpha2.TestingPatternAB
+			banditWeights(ctx(), experiment)
+			Expect(weightOf(experiment, "v2")).To(Equal(int32(33)))
+		})
+	})
+})
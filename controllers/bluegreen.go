@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// bluegreen.go implements the weights of the BlueGreen deployment pattern:
+//    - the candidates receive no traffic while they are assessed
+//    - all traffic is cut over to the winner at once
+//    - the experiment continues for a rollback window during which traffic can be reverted to the baseline
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// blueGreenProvenance identifies weights determined by the controller for BlueGreen experiments
+const blueGreenProvenance = "iter8 controller (BlueGreen)"
+
+func isBlueGreen(instance *v2alpha2.Experiment) bool {
+	return instance.Spec.GetDeploymentPattern() == v2alpha2.DeploymentPatternBlueGreen
+}
+
+// singleVersionWeights returns weights that send all traffic to one version
+func singleVersionWeights(instance *v2alpha2.Experiment, version string) []v2alpha2.WeightData {
+	weights := []v2alpha2.WeightData{}
+	for _, name := range versionNames(instance) {
+		weight := int32(0)
+		if name == version {
+			weight = 100
+		}
+		weights = append(weights, v2alpha2.WeightData{Name: name, Value: weight})
+	}
+	return weights
+}
+
+// setWeights replaces the recommended weights in status.analysis
+func setWeights(instance *v2alpha2.Experiment, weights []v2alpha2.WeightData, provenance string) {
+	if instance.Status.Analysis == nil {
+		instance.Status.Analysis = &v2alpha2.Analysis{}
+	}
+	instance.Status.Analysis.Weights = &v2alpha2.WeightsAnalysis{
+		AnalysisMetaData: v2alpha2.AnalysisMetaData{
+			Provenance: provenance,
+			Timestamp:  metav1.Now(),
+		},
+		Data: weights,
+	}
+}
+
+// blueGreenWeights replaces the recommended weights of a BlueGreen experiment
+// All traffic is sent to the baseline until a candidate is identified as the winner and to the winner after that.
+// If the winner has been identified in this iteration, it is returned; the cutover is complete once the weights are applied.
+func blueGreenWeights(ctx context.Context, instance *v2alpha2.Experiment) *string {
+	if !isBlueGreen(instance) || instance.Spec.VersionInfo == nil {
+		return nil
+	}
+
+	if instance.Status.Cutover != nil {
+		setWeights(instance, singleVersionWeights(instance, instance.Status.Cutover.Version), blueGreenProvenance)
+		return nil
+	}
+
+	baseline := instance.Spec.VersionInfo.Baseline.Name
+	winner := instance.Status.GetWinner()
+	if winner == nil || *winner == baseline {
+		setWeights(instance, singleVersionWeights(instance, baseline), blueGreenProvenance)
+		return nil
+	}
+
+	Logger(ctx).Info("Cutting over", "winner", *winner)
+	setWeights(instance, singleVersionWeights(instance, *winner), blueGreenProvenance)
+	return winner
+}
+
+// completeCutover records that all traffic has been sent to the winner of a BlueGreen experiment
+func (r *ExperimentReconciler) completeCutover(ctx context.Context, instance *v2alpha2.Experiment, version string) {
+	instance.Status.Cutover = &v2alpha2.Cutover{
+		Version: version,
+		Time:    metav1.Now(),
+	}
+	r.recordExperimentProgress(ctx, instance, v2alpha2.ReasonCutover, "All traffic sent to %s; rollback window %s", version, instance.Spec.GetRollbackWindow())
+}
+
+// rollbackWindowRemaining returns the time remaining in the rollback window of a BlueGreen experiment
+// The second return value is false if the experiment has not cut over.
+func rollbackWindowRemaining(instance *v2alpha2.Experiment) (time.Duration, bool) {
+	if !isBlueGreen(instance) || instance.Status.Cutover == nil {
+		return 0, false
+	}
+	return time.Until(instance.Status.Cutover.Time.Add(instance.Spec.GetRollbackWindow())), true
+}
+
+// revertTraffic sends all traffic of a BlueGreen experiment back to the baseline
+// This is done by the controller as the experiment is rolled back so that the revert does not wait for the rollback action.
+func (r *ExperimentReconciler) revertTraffic(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("revertTraffic called")
+	defer log.Info("revertTraffic completed")
+
+	if !isBlueGreen(instance) || instance.Spec.VersionInfo == nil {
+		return
+	}
+	if instance.Status.Cutover != nil && instance.Status.Cutover.RevertTime != nil {
+		return
+	}
+
+	setWeights(instance, singleVersionWeights(instance, instance.Spec.VersionInfo.Baseline.Name), blueGreenProvenance)
+	if err := redistributeWeight(ctx, instance, r.RestConfig); err != nil {
+		// the rollback action is still expected to restore the baseline
+		r.recordWeightsApplied(ctx, instance, corev1.ConditionFalse, v2alpha2.ReasonWeightsNotApplied, "Unable to revert traffic to the baseline: %s", err.Error())
+		return
+	}
+	if err := updateObservedWeights(ctx, instance, r.RestConfig); err != nil {
+		log.Error(err, "Unable to read weights after reverting traffic")
+	}
+	if instance.Status.Cutover != nil {
+		now := metav1.Now()
+		instance.Status.Cutover.RevertTime = &now
+		r.recordExperimentProgress(ctx, instance, v2alpha2.ReasonTrafficReverted, "All traffic reverted to %s", instance.Spec.VersionInfo.Baseline.Name)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BlueGreen Weights", func() {
+	bldr := func() *v2alpha2.ExperimentBuilder {
+		return v2alpha2.NewExperiment("bluegreen", "default").
+			WithTarget("target").
+			WithTestingPattern(v2alpha2.TestingPatternCanary).
+			WithDeploymentPattern(v2alpha2.DeploymentPatternBlueGreen).
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithRecommendedWeight("v1", 50).
+			WithRecommendedWeight("v2", 50).
+			WithRollbackWindow(60)
+	}
+	winner := func(experiment *v2alpha2.Experiment, version string) {
+		experiment.Status.Analysis.WinnerAssessment = &v2alpha2.WinnerAssessmentAnalysis{
+			Data: v2alpha2.WinnerAssessmentData{WinnerFound: true, Winner: &version},
+		}
+	}
+
+	Context("When no winner has been identified", func() {
+		It("all traffic is sent to the baseline", func() {
+			experiment := bldr().Build()
+			Expect(blueGreenWeights(ctx(), experiment)).To(BeNil())
+			Expect(experiment.Status.Analysis.Weights.Data).To(Equal([]v2alpha2.WeightData{{Name: "v1", Value: 100}, {Name: "v2", Value: 0}}))
+		})
+	})
+
+	Context("When a candidate is identified as the winner", func() {
+		It("all traffic is cut over to the winner", func() {
+			experiment := bldr().Build()
+			winner(experiment, "v2")
+			cutover := blueGreenWeights(ctx(), experiment)
+			Expect(cutover).ToNot(BeNil())
+			Expect(*cutover).To(Equal("v2"))
+			Expect(experiment.Status.Analysis.Weights.Data).To(Equal([]v2alpha2.WeightData{{Name: "v1", Value: 0}, {Name: "v2", Value: 100}}))
+
+			r := &ExperimentReconciler{EventRecorder: record.NewFakeRecorder(10)}
+			r.completeCutover(ctx(), experiment, *cutover)
+			Expect(experiment.Status.Cutover.Version).To(Equal("v2"))
+
+			// later iterations keep traffic on the winner even if the assessment changes
+			winner(experiment, "v1")
+			Expect(blueGreenWeights(ctx(), experiment)).To(BeNil())
+			Expect(experiment.Status.Analysis.Weights.Data).To(Equal([]v2alpha2.WeightData{{Name: "v1", Value: 0}, {Name: "v2", Value: 100}}))
+		})
+	})
+
+	Context("When the experiment is not BlueGreen", func() {
+		It("the recommended weights are not changed", func() {
+			experiment := bldr().WithDeploymentPattern(v2alpha2.DeploymentPatternProgressive).Build()
+			winner(experiment, "v2")
+			Expect(blueGreenWeights(ctx(), experiment)).To(BeNil())
+			Expect(experiment.Status.Analysis.Weights.Data).To(Equal([]v2alpha2.WeightData{{Name: "v1", Value: 50}, {Name: "v2", Value: 50}}))
+		})
+	})
+
+	Context("When the experiment has cut over", func() {
+		It("the rollback window is measured from the cutover", func() {
+			experiment := bldr().Build()
+			_, cutover := rollbackWindowRemaining(experiment)
+			Expect(cutover).To(BeFalse())
+
+			experiment.Status.Cutover = &v2alpha2.Cutover{Version: "v2", Time: metav1.NewTime(time.Now().Add(-30 * time.Second))}
+			remaining, cutover := rollbackWindowRemaining(experiment)
+			Expect(cutover).To(BeTrue())
+			Expect(remaining).To(BeNumerically("~", 30*time.Second, time.Second))
+
+			experiment.Status.Cutover.Time = metav1.NewTime(time.Now().Add(-90 * time.Second))
+			remaining, _ = rollbackWindowRemaining(experiment)
+			Expect(remaining).To(BeNumerically("<=", 0))
+		})
+	})
+})
@@ -0,0 +1,124 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// derived.go - evaluation of Derived metrics
+//    - the value of a Derived metric is computed by the controller from the values of the metrics it references
+//    - the referenced metrics are read (and checked for cycles) with the metric, cf. ReadMetric
+//    - Derived metrics that reference other Derived metrics are evaluated after them
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/iter8-tools/etc3/metrics"
+)
+
+// derivedProvenance identifies analysis computed from Derived metrics
+const derivedProvenance = "iter8 controller (derived metrics)"
+
+// evaluateDerivedMetrics evaluates the Derived metrics and records their values in the analysis
+// A metric that cannot be evaluated for a version is logged and left without a value.
+func evaluateDerivedMetrics(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("evaluateDerivedMetrics called")
+	defer log.Info("evaluateDerivedMetrics completed")
+
+	if instance.Spec.VersionInfo == nil || instance.Status.Analysis == nil {
+		return
+	}
+
+	pending := map[string]v2alpha2.MetricInfo{}
+	for _, info := range instance.Status.Metrics {
+		// mocked metrics are evaluated by the mock provider
+		if info.MetricObj.Spec.IsDerived() && !metrics.IsBuiltin(info.MetricObj.Spec) {
+			pending[info.Name] = info
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	evaluated := map[string]map[string]float64{}
+	baseline := instance.Spec.VersionInfo.Baseline.Name
+	// each pass evaluates the Derived metrics whose references are not pending; there are no cycles
+	for progress := true; progress && len(pending) > 0; {
+		progress = false
+		for key, info := range pending {
+			if referencesPending(key, info.MetricObj.Spec, pending) {
+				continue
+			}
+			delete(pending, key)
+			progress = true
+
+			expression, err := metrics.CompileExpression(info.MetricObj.Spec)
+			if err != nil {
+				log.Error(err, "Invalid derived metric", "metric", key)
+				continue
+			}
+			evaluated[key] = map[string]float64{}
+			baselineValues := referenceValues(instance, key, info.MetricObj.Spec, baseline)
+			for _, version := range versionDetails(instance) {
+				value, err := expression.Evaluate(referenceValues(instance, key, info.MetricObj.Spec, version.Name), baselineValues)
+				if err != nil {
+					log.Error(err, "Unable to evaluate derived metric", "metric", key, "version", version.Name)
+					continue
+				}
+				evaluated[key][version.Name] = value
+			}
+			// make the values available to Derived metrics that reference this metric
+			recordMetricValues(instance, map[string]map[string]float64{key: evaluated[key]}, derivedProvenance)
+		}
+	}
+
+	assessObjectives(instance, evaluated)
+}
+
+// referenceKey returns the name under which a metric referenced by the metric with the given key is recorded in status.metrics
+// References are resolved in the namespace in which the referencing metric was found, cf. ReadMetric
+func referenceKey(key string, metric string) string {
+	if strings.Contains(metric, "/") {
+		return metric
+	}
+	return strings.SplitN(key, "/", 2)[0] + "/" + metric
+}
+
+// referencesPending returns true if any metric referenced by a Derived metric is yet to be evaluated
+func referencesPending(key string, spec v2alpha2.MetricSpec, pending map[string]v2alpha2.MetricInfo) bool {
+	for _, ref := range spec.References {
+		if _, ok := pending[referenceKey(key, ref.Metric)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// referenceValues returns the values, for a version, of the metrics referenced by a Derived metric, by reference name
+func referenceValues(instance *v2alpha2.Experiment, key string, spec v2alpha2.MetricSpec, version string) map[string]float64 {
+	values := map[string]float64{}
+	am := instance.Status.Analysis.AggregatedMetrics
+	if am == nil {
+		return values
+	}
+	for _, ref := range spec.References {
+		if data, ok := am.Data[referenceKey(key, ref.Metric)]; ok {
+			if v, ok := data.Data[version]; ok && v.Value != nil {
+				values[ref.Name] = v.Value.AsApproximateFloat64()
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,105 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Derived Metrics", func() {
+	quantity := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+	values := func(v1 string, v2 string) v2alpha2.AggregatedMetricsData {
+		return v2alpha2.AggregatedMetricsData{Data: map[string]v2alpha2.AggregatedMetricsVersionData{
+			"v1": {Value: quantity(v1)},
+			"v2": {Value: quantity(v2)},
+		}}
+	}
+
+	bldr := func() *v2alpha2.Experiment {
+		errorRate := v2alpha2.NewMetric("error-rate", "default").WithExpression("errors / requests",
+			v2alpha2.MetricReference{Name: "errors", Metric: "errors"},
+			v2alpha2.MetricReference{Name: "requests", Metric: "default/requests"}).Build()
+		increase := v2alpha2.NewMetric("error-rate-increase", "default").WithExpression("rate - baseline.rate",
+			v2alpha2.MetricReference{Name: "rate", Metric: "error-rate"}).Build()
+		experiment := v2alpha2.NewExperiment("derived", "default").
+			WithTarget("target").
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithObjective(*errorRate, quantity("0.1"), nil, false).
+			Build()
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{
+			{Name: "default/errors", MetricObj: *v2alpha2.NewMetric("errors", "default").Build()},
+			{Name: "default/requests", MetricObj: *v2alpha2.NewMetric("requests", "default").Build()},
+			{Name: "default/error-rate-increase", MetricObj: *increase},
+			{Name: "default/error-rate", MetricObj: *errorRate},
+		}
+		experiment.Status.Analysis = &v2alpha2.Analysis{
+			AggregatedMetrics: &v2alpha2.AggregatedMetricsAnalysis{
+				Data: map[string]v2alpha2.AggregatedMetricsData{
+					"default/errors":   values("5", "20"),
+					"default/requests": values("100", "100"),
+				},
+			},
+		}
+		return experiment
+	}
+
+	Context("When an experiment uses Derived metrics", func() {
+		It("they are computed from the metrics they reference", func() {
+			experiment := bldr()
+			evaluateDerivedMetrics(ctx(), experiment)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data
+			Expect(data["default/error-rate"].Data["v1"].Value.AsApproximateFloat64()).To(BeNumerically("~", 0.05))
+			Expect(data["default/error-rate"].Data["v2"].Value.AsApproximateFloat64()).To(BeNumerically("~", 0.2))
+		})
+		It("a Derived metric may reference another Derived metric and the baseline", func() {
+			experiment := bldr()
+			evaluateDerivedMetrics(ctx(), experiment)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data
+			Expect(data["default/error-rate-increase"].Data["v1"].Value.AsApproximateFloat64()).To(BeNumerically("~", 0))
+			Expect(data["default/error-rate-increase"].Data["v2"].Value.AsApproximateFloat64()).To(BeNumerically("~", 0.15))
+		})
+		It("objectives on Derived metrics are assessed", func() {
+			experiment := bldr()
+			evaluateDerivedMetrics(ctx(), experiment)
+			assessments := experiment.Status.Analysis.VersionAssessments.Data
+			Expect(assessments["v1"]).To(Equal(v2alpha2.BooleanList{true}))
+			Expect(assessments["v2"]).To(Equal(v2alpha2.BooleanList{false}))
+		})
+		It("a version without a value for a referenced metric has no value", func() {
+			experiment := bldr()
+			delete(experiment.Status.Analysis.AggregatedMetrics.Data["default/requests"].Data, "v2")
+			evaluateDerivedMetrics(ctx(), experiment)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data
+			Expect(data["default/error-rate"].Data).To(HaveKey("v1"))
+			Expect(data["default/error-rate"].Data).ToNot(HaveKey("v2"))
+			Expect(data["default/error-rate-increase"].Data).ToNot(HaveKey("v2"))
+		})
+	})
+
+	Context("When a metric references a metric without a namespace", func() {
+		It("the reference is resolved in the namespace of the referencing metric", func() {
+			Expect(referenceKey("default/error-rate", "errors")).To(Equal("default/errors"))
+			Expect(referenceKey("default/error-rate", "other/errors")).To(Equal("other/errors"))
+		})
+	})
+})
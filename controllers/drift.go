@@ -0,0 +1,454 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// drift.go implements detection of changes to the weights of versions made outside of the controller
+// and the response to them defined by spec.strategy.weights.driftPolicy
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// WeightWatcher watches the objects referenced by the weightObjRef of the versions of running experiments.
+// When one of them changes, the experiments that reference it are reconciled so that drift is detected
+// without waiting for the next iteration. Drift is checked only for experiments with such changes.
+// It is also a manager.Runnable; all watches are stopped when the context passed to Start is done.
+type WeightWatcher struct {
+	client dynamic.Interface
+	mapper meta.RESTMapper
+	events chan event.GenericEvent
+
+	mu       sync.Mutex
+	watches  map[corev1.ObjectReference]*objectWatch
+	watching map[types.NamespacedName][]corev1.ObjectReference
+	// experiments for which a watched object may have changed since drift was last checked
+	changes map[types.NamespacedName]bool
+}
+
+// objectWatch is an informer on a single object
+type objectWatch struct {
+	stop        chan struct{}
+	experiments map[types.NamespacedName]bool
+}
+
+// NewWeightWatcher returns a WeightWatcher that triggers reconciliation of experiments using events
+func NewWeightWatcher(cfg *rest.Config, events chan event.GenericEvent) (*WeightWatcher, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &WeightWatcher{
+		client:   client,
+		mapper:   restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc)),
+		events:   events,
+		watches:  map[corev1.ObjectReference]*objectWatch{},
+		watching: map[types.NamespacedName][]corev1.ObjectReference{},
+		changes:  map[types.NamespacedName]bool{},
+	}, nil
+}
+
+// Start waits until the context is done and then stops all watches
+func (w *WeightWatcher) Start(ctx context.Context) error {
+	<-ctx.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for objRef, watch := range w.watches {
+		close(watch.stop)
+		delete(w.watches, objRef)
+	}
+	w.watching = map[types.NamespacedName][]corev1.ObjectReference{}
+	w.changes = map[types.NamespacedName]bool{}
+	return nil
+}
+
+// Watch watches the objects referenced by the versions of an experiment
+// Objects no longer referenced by the experiment are no longer watched on its behalf.
+// An object that is newly watched, or that cannot be watched, may have changed unnoticed.
+func (w *WeightWatcher) Watch(instance *v2alpha2.Experiment) error {
+	experiment := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+	objRefs := weightObjRefs(instance)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.release(experiment, objRefs)
+
+	watched := []corev1.ObjectReference{}
+	var err error
+	for _, objRef := range objRefs {
+		watch, ok := w.watches[objRef]
+		if !ok {
+			if watch, err = w.start(objRef); err != nil {
+				// try again when next reconciled
+				w.changes[experiment] = true
+				continue
+			}
+			w.watches[objRef] = watch
+		}
+		if !watch.experiments[experiment] {
+			w.changes[experiment] = true
+		}
+		watch.experiments[experiment] = true
+		watched = append(watched, objRef)
+	}
+	w.watching[experiment] = watched
+	return err
+}
+
+// Unwatch stops watching objects on behalf of an experiment
+func (w *WeightWatcher) Unwatch(experiment types.NamespacedName) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.release(experiment, nil)
+	delete(w.watching, experiment)
+	delete(w.changes, experiment)
+}
+
+// Changed returns true if an object watched for an experiment may have changed since Changed was last called
+func (w *WeightWatcher) Changed(experiment types.NamespacedName) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := w.changes[experiment]
+	delete(w.changes, experiment)
+	return changed
+}
+
+// recheck ensures that drift is checked again for an experiment when it is next reconciled
+func (w *WeightWatcher) recheck(experiment types.NamespacedName) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.changes[experiment] = true
+}
+
+// release stops watching the objects watched for an experiment that are not in keep
+// Watches no longer needed by any experiment are stopped. Must be called with the lock held.
+func (w *WeightWatcher) release(experiment types.NamespacedName, keep []corev1.ObjectReference) {
+	for _, objRef := range w.watching[experiment] {
+		if containsObjRef(keep, objRef) {
+			continue
+		}
+		watch, ok := w.watches[objRef]
+		if !ok {
+			continue
+		}
+		delete(watch.experiments, experiment)
+		if len(watch.experiments) == 0 {
+			close(watch.stop)
+			delete(w.watches, objRef)
+		}
+	}
+}
+
+// start an informer on a single object; must be called with the lock held
+func (w *WeightWatcher) start(objRef corev1.ObjectReference) (*objectWatch, error) {
+	gvk := schema.FromAPIVersionAndKind(objRef.APIVersion, objRef.Kind)
+	mapping, err := w.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	namespace := objRef.Namespace
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		namespace = metav1.NamespaceAll
+	}
+
+	informer := dynamicinformer.NewFilteredDynamicInformer(w.client, mapping.Resource, namespace, 0, cache.Indexers{},
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", objRef.Name).String()
+		}).Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) { w.changed(objRef) },
+		DeleteFunc: func(obj interface{}) { w.changed(objRef) },
+	})
+
+	watch := &objectWatch{
+		stop:        make(chan struct{}),
+		experiments: map[types.NamespacedName]bool{},
+	}
+	go informer.Run(watch.stop)
+	return watch, nil
+}
+
+// changed triggers reconciliation of the experiments that reference an object
+func (w *WeightWatcher) changed(objRef corev1.ObjectReference) {
+	w.mu.Lock()
+	experiments := []types.NamespacedName{}
+	if watch, ok := w.watches[objRef]; ok {
+		for experiment := range watch.experiments {
+			experiments = append(experiments, experiment)
+			w.changes[experiment] = true
+		}
+	}
+	w.mu.Unlock()
+
+	for _, experiment := range experiments {
+		// only the name and namespace are needed to trigger reconcile
+		trigger := &v2alpha2.Experiment{}
+		trigger.Name, trigger.Namespace = experiment.Name, experiment.Namespace
+		w.events <- event.GenericEvent{Object: trigger}
+	}
+}
+
+// weightObjRefs returns the distinct objects referenced by the weightObjRef of the versions of an experiment
+func weightObjRefs(instance *v2alpha2.Experiment) []corev1.ObjectReference {
+	objRefs := []corev1.ObjectReference{}
+	if instance.Spec.VersionInfo == nil {
+		return objRefs
+	}
+	for _, version := range append([]v2alpha2.VersionDetail{instance.Spec.VersionInfo.Baseline}, instance.Spec.VersionInfo.Candidates...) {
+		if version.WeightObjRef == nil {
+			continue
+		}
+		objRef := getKey(*version.WeightObjRef)
+		if objRef.Namespace == "" {
+			objRef.Namespace = instance.Namespace
+		}
+		if !containsObjRef(objRefs, objRef) {
+			objRefs = append(objRefs, objRef)
+		}
+	}
+	return objRefs
+}
+
+func containsObjRef(objRefs []corev1.ObjectReference, objRef corev1.ObjectReference) bool {
+	for _, o := range objRefs {
+		if o == objRef {
+			return true
+		}
+	}
+	return false
+}
+
+// versionDrift is a difference between the last applied weight of a version and its weight in the cluster
+type versionDrift struct {
+	version  string
+	applied  int32
+	observed int32
+}
+
+func (d versionDrift) String() string {
+	return fmt.Sprintf("%s is %d, not %d", d.version, d.observed, d.applied)
+}
+
+// weightDrift compares the observed weights of versions with the applied weights
+// Versions without an observed weight are ignored.
+func weightDrift(applied []v2alpha2.WeightData, observed []v2alpha2.WeightData) []versionDrift {
+	drift := []versionDrift{}
+	for _, o := range observed {
+		a := getCurrentWeight(o.Name, applied)
+		if o.Value != *a {
+			drift = append(drift, versionDrift{version: o.Name, applied: *a, observed: o.Value})
+		}
+	}
+	return drift
+}
+
+func describeDrift(drift []versionDrift) string {
+	descriptions := make([]string, len(drift))
+	for i, d := range drift {
+		descriptions[i] = d.String()
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// detectDrift reads the weights of the versions from the cluster and compares them with the last applied
+// weights, status.currentWeightDistribution.
+// Since the experiment may have been read from a stale cache, a difference is confirmed using the
+// status of the experiment read directly from the cluster.
+func detectDrift(ctx context.Context, instance *v2alpha2.Experiment, restCfg *rest.Config) ([]versionDrift, error) {
+	log := Logger(ctx)
+	log.Info("detectDrift called")
+	defer log.Info("detectDrift ended")
+
+	observed := []v2alpha2.WeightData{}
+	for _, version := range patchableVersions(instance) {
+		w, err := observeVersionWeight(ctx, instance, version, restCfg)
+		if err != nil {
+			return nil, err
+		}
+		observed = append(observed, v2alpha2.WeightData{Name: version.Name, Value: *w})
+	}
+
+	drift := weightDrift(instance.Status.CurrentWeightDistribution, observed)
+	if len(drift) == 0 {
+		return drift, nil
+	}
+
+	obj, err := readObject(ctx, &corev1.ObjectReference{
+		APIVersion: v2alpha2.GroupVersion.String(),
+		Kind:       "Experiment",
+		Name:       instance.Name,
+		Namespace:  instance.Namespace,
+	}, instance.Namespace, restCfg)
+	if err != nil {
+		return nil, err
+	}
+	latest := &v2alpha2.Experiment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, latest); err != nil {
+		return nil, err
+	}
+	if len(weightDrift(latest.Status.CurrentWeightDistribution, observed)) == 0 {
+		log.Info("Weights match the latest status", "status", latest.Status.CurrentWeightDistribution)
+		return []versionDrift{}, nil
+	}
+	return drift, nil
+}
+
+// watchWeights watches the objects referenced by the versions of an experiment, if a WeightWatcher is configured
+func (r *ExperimentReconciler) watchWeights(ctx context.Context, instance *v2alpha2.Experiment) {
+	if r.WeightWatcher == nil {
+		return
+	}
+	if err := r.WeightWatcher.Watch(instance); err != nil {
+		Logger(ctx).Error(err, "Unable to watch weightObjRef; drift will be detected at the next iteration")
+	}
+}
+
+// unwatchWeights stops watching the objects referenced by the versions of an experiment
+func (r *ExperimentReconciler) unwatchWeights(experiment types.NamespacedName) {
+	if r.WeightWatcher == nil {
+		return
+	}
+	r.WeightWatcher.Unwatch(experiment)
+}
+
+// weightsMayHaveDrifted returns true if drift should be checked: if a weightObjRef of the experiment may have
+// changed since drift was last checked, or if the experiment is paused until the weights are restored.
+// Without a WeightWatcher, drift is checked whenever the experiment is reconciled.
+func (r *ExperimentReconciler) weightsMayHaveDrifted(instance *v2alpha2.Experiment) bool {
+	if r.WeightWatcher == nil {
+		return true
+	}
+	changed := r.WeightWatcher.Changed(types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+	condition := instance.Status.GetCondition(v2alpha2.ExperimentConditionWeightsApplied)
+	return changed || (condition.Reason != nil && *condition.Reason == v2alpha2.ReasonWeightsDrifted)
+}
+
+// recheckWeights ensures that drift is checked again when the experiment is next reconciled
+func (r *ExperimentReconciler) recheckWeights(instance *v2alpha2.Experiment) {
+	if r.WeightWatcher == nil {
+		return
+	}
+	r.WeightWatcher.recheck(types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+}
+
+// checkWeightDrift detects changes to the weights of the versions made outside of the controller
+// and responds according to spec.strategy.weights.driftPolicy:
+//   - Reapply: the last applied weights are restored and the experiment continues
+//   - Pause: the experiment does not iterate until the last applied weights are restored
+//   - Fail: the experiment fails
+//
+// The first return value indicates whether the caller should stop; if so, it returns the other values.
+func (r *ExperimentReconciler) checkWeightDrift(ctx context.Context, instance *v2alpha2.Experiment) (bool, ctrl.Result, error) {
+	log := Logger(ctx)
+	log.Info("checkWeightDrift called")
+	defer log.Info("checkWeightDrift completed")
+
+	dummyResult := ctrl.Result{}
+	stop := true
+
+	// nothing has been applied yet, or the weights are unchanged since drift was last checked
+	if len(instance.Status.CurrentWeightDistribution) == 0 || !r.weightsMayHaveDrifted(instance) {
+		return !stop, dummyResult, nil
+	}
+
+	drift, err := detectDrift(ctx, instance, r.RestConfig)
+	if err != nil {
+		// an invalid weightObjRef is reported when the observed weights are next updated
+		log.Error(err, "Unable to detect drift")
+		r.recheckWeights(instance)
+		return !stop, dummyResult, nil
+	}
+
+	condition := instance.Status.GetCondition(v2alpha2.ExperimentConditionWeightsApplied)
+	if len(drift) == 0 {
+		if condition.Reason != nil && *condition.Reason == v2alpha2.ReasonWeightsDrifted {
+			r.recordWeightsApplied(ctx, instance, corev1.ConditionTrue, v2alpha2.ReasonWeightsApplied, "Weights restored; experiment resumed")
+		}
+		return !stop, dummyResult, nil
+	}
+
+	description := describeDrift(drift)
+	switch instance.Spec.GetDriftPolicy() {
+	case v2alpha2.DriftPolicyFail:
+		r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonWeightsDrifted, "Weights changed outside of the experiment: %s", description)
+		result, err := r.failExperiment(ctx, instance, nil)
+		return stop, result, err
+	case v2alpha2.DriftPolicyPause:
+		r.recordWeightsApplied(ctx, instance, corev1.ConditionFalse, v2alpha2.ReasonWeightsDrifted, "Experiment paused; weights changed outside of the experiment: %s", description)
+		// check again after an interval in case the change that restores the weights is not observed
+		result, err := r.endRequest(ctx, instance, instance.Spec.GetIntervalAsDuration())
+		return stop, result, err
+	default: // v2alpha2.DriftPolicyReapply
+		if err := reapplyWeights(ctx, instance, drift, r.RestConfig); err != nil {
+			r.recheckWeights(instance)
+			r.recordWeightsApplied(ctx, instance, corev1.ConditionFalse, v2alpha2.ReasonWeightsNotApplied, "Unable to restore weights changed outside of the experiment: %s", err.Error())
+		} else {
+			r.recordWeightsApplied(ctx, instance, corev1.ConditionTrue, v2alpha2.ReasonWeightsReapplied, "Restored weights changed outside of the experiment: %s", description)
+		}
+		return !stop, dummyResult, nil
+	}
+}
+
+// reapplyWeights restores the last applied weights of versions that have drifted
+func reapplyWeights(ctx context.Context, instance *v2alpha2.Experiment, drift []versionDrift, restCfg *rest.Config) error {
+	log := Logger(ctx)
+	log.Info("reapplyWeights called")
+	defer log.Info("reapplyWeights ended")
+
+	patches := map[corev1.ObjectReference][]patchValue{}
+	for _, version := range patchableVersions(instance) {
+		for _, d := range drift {
+			if d.version != version.Name {
+				continue
+			}
+			p, err := versionPatches(ctx, instance, version, d.applied, restCfg)
+			if err != nil {
+				return err
+			}
+			key := getKey(*version.WeightObjRef)
+			patches[key] = append(patches[key], p...)
+		}
+	}
+	return applyWeights(ctx, instance, patches, instance.Status.CurrentWeightDistribution, restCfg)
+}
@@ -0,0 +1,146 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Weight Drift", func() {
+	Context("When comparing observed weights with applied weights", func() {
+		applied := []v2alpha2.WeightData{{Name: "v1", Value: 80}, {Name: "v2", Value: 20}}
+		It("only versions with different weights have drifted", func() {
+			Expect(weightDrift(applied, []v2alpha2.WeightData{{Name: "v1", Value: 80}, {Name: "v2", Value: 20}})).To(BeEmpty())
+			Expect(weightDrift(applied, []v2alpha2.WeightData{{Name: "v2", Value: 20}})).To(BeEmpty())
+
+			drift := weightDrift(applied, []v2alpha2.WeightData{{Name: "v1", Value: 50}, {Name: "v2", Value: 50}})
+			Expect(drift).To(Equal([]versionDrift{
+				{version: "v1", applied: 80, observed: 50},
+				{version: "v2", applied: 20, observed: 50},
+			}))
+			Expect(describeDrift(drift)).To(Equal("v1 is 50, not 80, v2 is 50, not 20"))
+		})
+	})
+
+	Context("When identifying the objects to watch", func() {
+		It("each object referenced by a version is watched once", func() {
+			vs := &corev1.ObjectReference{APIVersion: "networking.istio.io/v1beta1", Kind: "VirtualService", Name: "reviews", FieldPath: ".spec.http[0].route[0].weight"}
+			vs2 := vs.DeepCopy()
+			vs2.FieldPath = ".spec.http[0].route[1].weight"
+			experiment := v2alpha2.NewExperiment("drift", "default").
+				WithBaselineVersion("v1", vs).
+				WithCandidateVersion("v2", vs2).
+				WithCandidateVersion("v3", nil).
+				Build()
+			Expect(weightObjRefs(experiment)).To(Equal([]corev1.ObjectReference{
+				{APIVersion: "networking.istio.io/v1beta1", Kind: "VirtualService", Name: "reviews", Namespace: "default"},
+			}))
+		})
+	})
+
+	Context("When a watched object changes", func() {
+		gvr := schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"}
+		gvk := schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService"}
+		var watcher *WeightWatcher
+		var client *fake.FakeDynamicClient
+		var events chan event.GenericEvent
+		var cancel context.CancelFunc
+		BeforeEach(func() {
+			vs := &unstructured.Unstructured{}
+			vs.SetGroupVersionKind(gvk)
+			vs.SetName("reviews")
+			vs.SetNamespace("default")
+			client = fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{gvr: "VirtualServiceList"}, vs)
+
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+
+			events = make(chan event.GenericEvent, 10)
+			watcher = &WeightWatcher{
+				client:   client,
+				mapper:   mapper,
+				events:   events,
+				watches:  map[corev1.ObjectReference]*objectWatch{},
+				watching: map[types.NamespacedName][]corev1.ObjectReference{},
+				changes:  map[types.NamespacedName]bool{},
+			}
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go watcher.Start(ctx)
+		})
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("the experiments referencing it are reconciled until they stop watching it", func() {
+			objRef := &corev1.ObjectReference{APIVersion: "networking.istio.io/v1beta1", Kind: "VirtualService", Name: "reviews", FieldPath: ".spec.http[0].route[0].weight"}
+			experiment := v2alpha2.NewExperiment("drift", "default").WithBaselineVersion("v1", objRef).WithCandidateVersion("v2", nil).Build()
+			Expect(watcher.Watch(experiment)).To(Succeed())
+			Expect(watcher.watches).To(HaveLen(1))
+			name := types.NamespacedName{Name: "drift", Namespace: "default"}
+			// a newly watched object may have changed before it was watched
+			Expect(watcher.Changed(name)).To(BeTrue())
+			Expect(watcher.Changed(name)).To(BeFalse())
+
+			update := func() {
+				vs, err := client.Resource(gvr).Namespace("default").Get(context.Background(), "reviews", metav1.GetOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				vs.SetLabels(map[string]string{"changed": time.Now().String()})
+				_, err = client.Resource(gvr).Namespace("default").Update(context.Background(), vs, metav1.UpdateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			// the informer must have started before changes are observed
+			Eventually(func() bool {
+				update()
+				select {
+				case e := <-events:
+					return e.Object.GetName() == "drift" && e.Object.GetNamespace() == "default"
+				case <-time.After(100 * time.Millisecond):
+					return false
+				}
+			}, 5*time.Second).Should(BeTrue())
+			Expect(watcher.Changed(name)).To(BeTrue())
+
+			watcher.Unwatch(name)
+			Expect(watcher.watches).To(BeEmpty())
+			Expect(watcher.watching).To(BeEmpty())
+		})
+
+		It("an object whose kind is unknown is not watched", func() {
+			objRef := &corev1.ObjectReference{APIVersion: "split.smi-spec.io/v1alpha2", Kind: "TrafficSplit", Name: "reviews"}
+			experiment := v2alpha2.NewExperiment("drift", "default").WithBaselineVersion("v1", objRef).Build()
+			Expect(watcher.Watch(experiment)).ToNot(Succeed())
+			Expect(watcher.watches).To(BeEmpty())
+			Expect(watcher.Changed(types.NamespacedName{Name: "drift", Namespace: "default"})).To(BeTrue())
+		})
+	})
+})
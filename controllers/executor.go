@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// executor.go implements code to execute actions in the controller instead of in handler jobs
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// ActionExecutor executes the actions of experiments within the controller process.
+// It is implemented outside of this package (the task runner depends on this package) and injected.
+type ActionExecutor interface {
+	// Supports returns true if every task in the action can be executed by the executor
+	Supports(action v2alpha2.Action) bool
+	// Execute starts the asynchronous execution of an action of an experiment.
+	// The id uniquely identifies the execution; starting an execution that already exists has no effect.
+	// onDone is called when the execution finishes.
+	Execute(id string, instance *v2alpha2.Experiment, action string, onDone func()) error
+	// Status returns the status of an execution and, if it failed, the reason.
+	// The status of an unknown execution is HandlerStatusNotLaunched.
+	Status(id string) (HandlerStatusType, error)
+	// Cancel terminates an execution
+	Cancel(id string)
+}
+
+// executesInProcess determines if a handler should be executed by the ActionExecutor instead of in a job.
+// This is the case when every task in the action requests it and the executor supports all of them.
+func (r *ExperimentReconciler) executesInProcess(instance *v2alpha2.Experiment, handler string) bool {
+	if r.ActionExecutor == nil {
+		return false
+	}
+	if instance.Spec.GetActionExecutor(handler) != v2alpha2.ExecutorInProcess {
+		return false
+	}
+	return r.ActionExecutor.Supports(instance.Spec.Strategy.Actions[handler])
+}
+
+// launchInProcess starts executing a handler with the ActionExecutor
+// When the execution finishes, the experiment is reconciled again
+func (r *ExperimentReconciler) launchInProcess(ctx context.Context, instance *v2alpha2.Experiment, handler string, handlerInstance *int) error {
+	log := Logger(ctx)
+	log.Info("launchInProcess called", "handler", handler)
+	defer log.Info("launchInProcess completed", "handler", handler)
+
+	// only the name and namespace are needed to trigger reconcile
+	trigger := &v2alpha2.Experiment{}
+	trigger.Name, trigger.Namespace = instance.Name, instance.Namespace
+
+	return r.ActionExecutor.Execute(jobName(instance, handler, handlerInstance), instance.DeepCopy(), handler, func() {
+		r.ReleaseEvents <- event.GenericEvent{
+			Object: trigger,
+		}
+	})
+}
+
+// inProcessHandlerStatus determines the status of a handler executed by the ActionExecutor.
+// The executor does not remember executions across controller restarts, so if it does not know
+// of an execution, the result of the most recent attempt recorded in status.handlerAttempts is used.
+func (r *ExperimentReconciler) inProcessHandlerStatus(instance *v2alpha2.Experiment, handler string, handlerInstance *int) HandlerStatusType {
+	if status, _ := r.ActionExecutor.Status(jobName(instance, handler, handlerInstance)); status != HandlerStatusNotLaunched {
+		return status
+	}
+	latest := instance.Status.GetLatestHandlerAttempt(handler, handlerInstanceID(handlerInstance))
+	if latest == nil {
+		return HandlerStatusNotLaunched
+	}
+	switch latest.Result {
+	case v2alpha2.HandlerAttemptComplete:
+		return HandlerStatusComplete
+	case v2alpha2.HandlerAttemptFailed:
+		return HandlerStatusFailed
+	case v2alpha2.HandlerAttemptTimedOut:
+		return HandlerStatusTimedOut
+	default:
+		// an interrupted execution; it will be launched again
+		return HandlerStatusNotLaunched
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// testActionExecutor records executions; they finish only when told to
+type testActionExecutor struct {
+	supported  bool
+	executions map[string]HandlerStatusType
+	errors     map[string]error
+}
+
+func (e *testActionExecutor) Supports(action v2alpha2.Action) bool {
+	return e.supported
+}
+
+func (e *testActionExecutor) Execute(id string, instance *v2alpha2.Experiment, action string, onDone func()) error {
+	if _, ok := e.executions[id]; !ok {
+		e.executions[id] = HandlerStatusRunning
+	}
+	return nil
+}
+
+func (e *testActionExecutor) Status(id string) (HandlerStatusType, error) {
+	status, ok := e.executions[id]
+	if !ok {
+		return HandlerStatusNotLaunched, nil
+	}
+	return status, e.errors[id]
+}
+
+func (e *testActionExecutor) Cancel(id string) {
+	e.executions[id] = HandlerStatusFailed
+	e.errors[id] = errors.New("execution cancelled")
+}
+
+var _ = Describe("In-process Execution", func() {
+	var executor *testActionExecutor
+	var r *ExperimentReconciler
+	var experiment *v2alpha2.Experiment
+	BeforeEach(func() {
+		executor = &testActionExecutor{
+			supported:  true,
+			executions: map[string]HandlerStatusType{},
+			errors:     map[string]error{},
+		}
+		r = &ExperimentReconciler{ActionExecutor: executor}
+
+		inProcess := v2alpha2.ExecutorInProcess
+		task := "notification/http"
+		experiment = v2alpha2.NewExperiment("in-process", "default").
+			WithAction("start", []v2alpha2.TaskSpec{{Task: &task, Executor: &inProcess}}).
+			WithAction("finish", []v2alpha2.TaskSpec{{Task: &task}}).
+			Build()
+	})
+
+	Context("When deciding how to execute a handler", func() {
+		It("only actions requesting it and supported by the executor are executed in-process", func() {
+			Expect(r.executesInProcess(experiment, "start")).To(BeTrue())
+			Expect(r.executesInProcess(experiment, "finish")).To(BeFalse())
+
+			executor.supported = false
+			Expect(r.executesInProcess(experiment, "start")).To(BeFalse())
+
+			Expect((&ExperimentReconciler{}).executesInProcess(experiment, "start")).To(BeFalse())
+		})
+	})
+
+	Context("When a handler is executed in-process", func() {
+		It("its status comes from the executor", func() {
+			handler := "start"
+			Expect(r.GetHandlerStatus(ctx(), experiment, &handler, nil)).To(Equal(HandlerStatusNotLaunched))
+
+			recordHandlerAttempt(experiment, handler, nil)
+			Expect(r.LaunchHandler(ctx(), experiment, handler, nil)).To(Succeed())
+			Expect(executor.executions).To(HaveKey("default-in-process-start"))
+			Expect(r.GetHandlerStatus(ctx(), experiment, &handler, nil)).To(Equal(HandlerStatusRunning))
+
+			Expect(r.TerminateHandler(ctx(), experiment, handler, nil)).To(Succeed())
+			Expect(r.GetHandlerStatus(ctx(), experiment, &handler, nil)).To(Equal(HandlerStatusFailed))
+			Expect(r.describeHandlerFailure(ctx(), experiment, handler, nil)).To(Equal("execution cancelled"))
+		})
+
+		It("the recorded attempts are used when the executor has forgotten the execution", func() {
+			handler := "start"
+			recordHandlerAttempt(experiment, handler, nil)
+			Expect(r.GetHandlerStatus(ctx(), experiment, &handler, nil)).To(Equal(HandlerStatusNotLaunched))
+
+			experiment.Status.MarkHandlerAttempt(handler, nil, v2alpha2.HandlerAttemptComplete)
+			Expect(r.GetHandlerStatus(ctx(), experiment, &handler, nil)).To(Equal(HandlerStatusComplete))
+		})
+	})
+})
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -54,6 +55,12 @@ type ExperimentReconciler struct {
 	HTTP          HTTPTransport
 	ReleaseEvents chan event.GenericEvent
 	JobManager    JobManager
+	// ActionExecutor, if set, executes actions that request it in-process instead of in handler jobs
+	ActionExecutor ActionExecutor
+	// WeightWatcher, if set, watches the weightObjRef of running experiments so that drift is detected promptly
+	WeightWatcher *WeightWatcher
+	// refreshFailures holds, for each experiment, the last reported failure to refresh its metrics
+	refreshFailures sync.Map
 }
 
 /* RBAC roles are handwritten in config/rbac-iter8 so that different roles can be assigned
@@ -61,6 +68,10 @@ type ExperimentReconciler struct {
 // +kubebuilder:rbac:groups=iter8.tools,resources=experiments,verbs=get;list;watch;update;patch;delete
 // +kubebuilder:rbac:groups=iter8.tools,resources=experiments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=iter8.tools.resources=metrics,verbs=get;list;watch
+// +kubebuilder:rbac:groups=iter8.tools,resources=clustermetrics,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list
+// +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
 */
 
 // Reconcile attempts to align the resource with the spec
@@ -81,6 +92,8 @@ func (r *ExperimentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			// we make sure to have deleted all jobs and trigger any waiting experiment
 			r.cleanupDeletedExperiments(ctx, instance)
 			r.triggerWaitingExperiments(ctx, nil)
+			r.unwatchWeights(req.NamespacedName)
+			r.refreshFailures.Delete(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// other error reading instance; return
@@ -155,9 +168,14 @@ func (r *ExperimentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	// If we get here it either hasn't been launched or it has already completed.
 	// We get here many times, but we want to execute the start handler only once.
 	// Use a prerequisite checker to check that it has never been launched before.
+	startStatus, err := r.GetHandlerStatus(ctx, instance, r.GetHandler(instance, HandlerTypeStart), nil)
+	if err != nil {
+		// the status of the start handler is unknown; try again
+		return ctrl.Result{}, err
+	}
 	if stop, result, err := r.launchHandlerWrapper(ctx, instance, HandlerTypeStart,
 		handlerLaunchModifier{prerequisiteCheck: func() bool {
-			return HandlerStatusNotLaunched == r.GetHandlerStatus(ctx, instance, r.GetHandler(instance, HandlerTypeStart), nil)
+			return HandlerStatusNotLaunched == startStatus
 		}}); stop {
 		return result, err
 	}
@@ -173,6 +191,11 @@ func (r *ExperimentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return r.endRequest(ctx, instance)
 	}
 
+	// read the metrics again if any have changed since they were read (see spec.criteria.metricsRefreshPolicy)
+	if refreshed := r.refreshMetrics(ctx, instance); refreshed {
+		return r.endRequest(ctx, instance)
+	}
+
 	// advance stage from Initializing to Running
 	// when we advance for the first time, we've just finished the start handler (if there is one),
 	// so we update Status.CurrentWeightDistribution
@@ -200,6 +223,14 @@ func (r *ExperimentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	// 	redistributeWeight (ctx, instance, instance.Spec.GetWeightDistribution())
 	// }
 
+	// DRIFT DETECTION
+	// Watch the objects that determine the weights of the versions and respond if they are
+	// changed by someone else
+	r.watchWeights(ctx, instance)
+	if stop, result, err := r.checkWeightDrift(ctx, instance); stop {
+		return result, err
+	}
+
 	// EXECUTE ITERATION
 	return r.doIteration(ctx, instance)
 }
@@ -243,11 +274,22 @@ func (r *ExperimentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		}
 	}
 
+	// index experiments by the metrics they use so that changes to metrics can be mapped to experiments
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v2alpha2.Experiment{}, metricsIndexKey, indexExperimentMetrics); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v2alpha2.Experiment{}).
 		Watches(&source.Kind{Type: &batchv1.Job{}},
 			handler.EnqueueRequestsFromMapFunc(jobToExperiment),
 			builder.WithPredicates(jobPredicateFuncs)).
+		Watches(&source.Kind{Type: &v2alpha2.Metric{}},
+			handler.EnqueueRequestsFromMapFunc(r.metricToExperiments),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &v2alpha2.ClusterMetric{}},
+			handler.EnqueueRequestsFromMapFunc(r.metricToExperiments),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Watches(&source.Channel{Source: r.ReleaseEvents}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
@@ -320,6 +362,9 @@ func (r *ExperimentReconciler) endExperiment(ctx context.Context, instance *v2al
 		log.Info("Updating stage advance to: Completed")
 		r.recordExperimentCompleted(ctx, instance, msg)
 		r.updateStatus(ctx, instance)
+		r.unwatchWeights(types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+		r.removeMirroring(ctx, instance)
+		r.removeMatchRoutes(ctx, instance)
 		r.triggerNextExperiment(ctx, instance.Spec.Target, instance)
 	}
 
@@ -345,6 +390,9 @@ func (r *ExperimentReconciler) rollbackExperiment(ctx context.Context, instance
 	log.Info("rollbackExperiment called")
 	defer log.Info("rollbackExperiment ended")
 
+	// traffic of a BlueGreen experiment is reverted immediately rather than by the rollback action
+	r.revertTraffic(ctx, instance)
+
 	if stop, result, err := r.launchHandlerWrapper(ctx, instance, HandlerTypeRollback,
 		handlerLaunchModifier{onSuccessfulLaunch: func() { r.advanceStage(ctx, instance, v2alpha2.ExperimentStageFinishing) }},
 	); stop {
@@ -442,12 +490,32 @@ func (r *ExperimentReconciler) checkHandlerStatus(ctx context.Context, instance
 	dummyResult := ctrl.Result{}
 	stop := true
 
-	switch r.GetHandlerStatus(ctx, instance, handler, handlerInstance) {
+	status, err := r.GetHandlerStatus(ctx, instance, handler, handlerInstance)
+	if err != nil {
+		// the status of the handler is unknown; try again rather than counting a failed attempt
+		return stop, dummyResult, err
+	}
+	remaining, hasTimeout := handlerTimeRemaining(instance, *handler, handlerInstance)
+	if status == HandlerStatusRunning && hasTimeout && remaining <= 0 {
+		// the handler has run too long; terminate it and treat it as failed
+		if err := r.TerminateHandler(ctx, instance, *handler, handlerInstance); err != nil {
+			return stop, dummyResult, err
+		}
+		status = HandlerStatusTimedOut
+	}
+
+	switch status {
 	case HandlerStatusRunning:
 		// exit; keep waiting for handler to complete
+		// if the handler has a timeout, make sure we check again when it expires
+		if hasTimeout {
+			result, err := r.endRequest(ctx, instance, remaining)
+			return stop, result, err
+		}
 		result, err := r.endRequest(ctx, instance)
 		return stop, result, err
 	case HandlerStatusComplete:
+		instance.Status.MarkHandlerAttempt(*handler, handlerInstanceID(handlerInstance), v2alpha2.HandlerAttemptComplete)
 		switch handlerType {
 		case HandlerTypeFinish, HandlerTypeFailure, HandlerTypeRollback:
 			// terminal handler completed; we end the experiment
@@ -464,10 +532,24 @@ func (r *ExperimentReconciler) checkHandlerStatus(ctx context.Context, instance
 			// allow reconcile to continue
 			return !stop, dummyResult, nil
 		}
-	case HandlerStatusFailed:
-		// a handler failed; don't call a failure handler; just stop
+	case HandlerStatusFailed, HandlerStatusTimedOut:
+		reason, attemptResult := v2alpha2.ReasonHandlerFailed, v2alpha2.HandlerAttemptFailed
 		msg := fmt.Sprintf("%s actions failed", handlerType)
-		r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonHandlerFailed, msg)
+		if status == HandlerStatusTimedOut {
+			reason, attemptResult = v2alpha2.ReasonHandlerTimedOut, v2alpha2.HandlerAttemptTimedOut
+			msg = fmt.Sprintf("%s actions timed out", handlerType)
+		} else if details := r.describeHandlerFailure(ctx, instance, *handler, handlerInstance); len(details) > 0 {
+			msg = fmt.Sprintf("%s: %s", msg, details)
+		}
+		instance.Status.MarkHandlerAttempt(*handler, handlerInstanceID(handlerInstance), attemptResult)
+
+		// retry the handler if permitted
+		if shouldRetryHandler(instance, *handler, handlerInstance) {
+			return r.retryHandler(ctx, instance, handlerType, *handler, handlerInstance, msg)
+		}
+
+		// a handler failed; don't call a failure handler; just stop
+		r.recordExperimentFailed(ctx, instance, reason, msg)
 		result, err := r.endExperiment(ctx, instance, msg)
 		return stop, result, err
 	default: // HandlerStatusNotLaunched, HandlerStatusNoHandler:
@@ -476,6 +558,30 @@ func (r *ExperimentReconciler) checkHandlerStatus(ctx context.Context, instance
 
 }
 
+// retryHandler launches a new attempt to execute a handler whose most recent attempt failed
+// The caller should always stop; either to wait for the new attempt or because the launch failed
+func (r *ExperimentReconciler) retryHandler(ctx context.Context, instance *v2alpha2.Experiment,
+	handlerType HandlerType, handler string, handlerInstance *int, cause string) (bool, ctrl.Result, error) {
+
+	log := Logger(ctx)
+	log.Info("retryHandler called", "handlerType", handlerType, "handler", handler)
+	defer log.Info("retryHandler completed")
+
+	stop := true
+
+	attempt := recordHandlerAttempt(instance, handler, handlerInstance)
+	if err := r.LaunchHandler(ctx, instance, handler, handlerInstance); err != nil {
+		// An error occurred trying to launch a handler; recommend immediate termination
+		result, err := r.endExperiment(ctx, instance, fmt.Sprintf("failure launching %s handler", handlerType))
+		return stop, result, err
+	}
+	r.recordExperimentProgress(ctx, instance, v2alpha2.ReasonHandlerRetried, "%s; %s handler '%s' relaunched (attempt %d)", cause, handlerType, handler, attempt.Attempt)
+
+	// tell caller to stop (to wait for handler to complete)
+	result, err := r.endRequest(ctx, instance)
+	return stop, result, err
+}
+
 type handlerLaunchPrerequisiteChecker func() bool
 type handlerLaunchOnSuccess func()
 type handlerLaunchModifier struct {
@@ -528,9 +634,15 @@ func (r *ExperimentReconciler) launchHandlerWrapper(
 		return !stop, dummyResult, nil
 	}
 
+	// record the (first) attempt to execute the handler
+	// if the handler has been launched before, we are relaunching the same job
+	if instance.Status.GetLatestHandlerAttempt(*handler, handlerInstanceID(modifier.loop)) == nil {
+		recordHandlerAttempt(instance, *handler, modifier.loop)
+	}
+
 	if err := r.LaunchHandler(ctx, instance, *handler, modifier.loop); err != nil {
 		// An error occurred trying to launch a handler; recommend immediate termination
-		result, err := r.endExperiment(ctx, instance, "failure executing failure handler")
+		result, err := r.endExperiment(ctx, instance, fmt.Sprintf("failure launching %s handler", handlerType))
 		return stop, result, err
 	}
 
@@ -0,0 +1,200 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// guardrail.go - guardrail objectives (spec.criteria.objectives[].guardrail)
+//    - between iterations, the metrics of the guardrails are queried directly, without the analytics service,
+//      every spec.duration.guardrailIntervalSeconds
+//    - the experiment is rolled back as soon as a version fails a guardrail
+//    - the queries of a check run concurrently and are abandoned after guardrailQueryTimeout, so that
+//      a slow provider does not hold up reconciliation
+//    - a metric that cannot be queried is logged; it does not roll back the experiment
+//    - relative limits are checked only if the metric could be queried for the baseline
+//    - the result of the latest check is recorded in status.guardrails
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/iter8-tools/etc3/metrics"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// guardrailQueryTimeout is the time allowed for the queries of a check of the guardrails
+var guardrailQueryTimeout = 2 * time.Second
+
+// guardrailMeasurement is the result of the query of the metric of a guardrail for a version
+type guardrailMeasurement struct {
+	value float64
+	err   error
+}
+
+// guardrails returns the guardrail objectives of an experiment
+func guardrails(instance *v2alpha2.Experiment) []v2alpha2.Objective {
+	result := []v2alpha2.Objective{}
+	if instance.Spec.Criteria == nil {
+		return result
+	}
+	for _, o := range instance.Spec.Criteria.Objectives {
+		if o.IsGuardrail() {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// validGuardrailMetric returns an error if the metric of a guardrail cannot be queried directly
+func validGuardrailMetric(key string, metricMap map[string]*v2alpha2.MetricInfo) *metricError {
+	info, ok := metricMap[key]
+	if !ok || !metrics.IsMeasurable(info.MetricObj.Spec) {
+		err := newMetricError(v2alpha2.ReasonMetricInvalid, "Metric %s of guardrail cannot be queried without the analytics service", key)
+		if ok {
+			err.resourceVersion = info.MetricObj.ResourceVersion
+		}
+		return err
+	}
+	return nil
+}
+
+// guardIteration checks the guardrails, when they are due, while the experiment waits for its next iteration
+// The experiment is rolled back if a guardrail fails; otherwise the request is requeued for the next check or iteration.
+func (r *ExperimentReconciler) guardIteration(ctx context.Context, instance *v2alpha2.Experiment) (ctrl.Result, error) {
+	log := Logger(ctx)
+	log.Info("guardIteration called")
+	defer log.Info("guardIteration completed")
+
+	if untilGuardrailCheck(instance, time.Now()) <= 0 {
+		if !r.checkGuardrails(ctx, instance, time.Now()) {
+			return r.rollbackExperiment(ctx, instance)
+		}
+	}
+
+	now := time.Now()
+	wait := untilGuardrailCheck(instance, now)
+	if instance.Status.LastUpdateTime != nil {
+		if untilIteration := instance.Status.LastUpdateTime.Add(instance.Spec.GetIntervalAsDuration()).Sub(now); untilIteration < wait {
+			wait = untilIteration
+		}
+	}
+	return r.endRequest(ctx, instance, wait)
+}
+
+// untilGuardrailCheck returns the time until the guardrails are next due to be checked
+func untilGuardrailCheck(instance *v2alpha2.Experiment, now time.Time) time.Duration {
+	if instance.Status.Guardrails == nil {
+		return 0
+	}
+	return instance.Status.Guardrails.LastCheckTime.Add(instance.Spec.GetGuardrailIntervalAsDuration()).Sub(now)
+}
+
+// checkGuardrails queries the metrics of the guardrails for each version and records the result in status.guardrails
+// It returns false if any version fails a guardrail.
+func (r *ExperimentReconciler) checkGuardrails(ctx context.Context, instance *v2alpha2.Experiment, now time.Time) bool {
+	log := Logger(ctx)
+	log.Info("checkGuardrails called")
+	defer log.Info("checkGuardrails completed")
+
+	status := &v2alpha2.GuardrailStatus{LastCheckTime: metav1.NewTime(now)}
+	instance.Status.Guardrails = status
+	if instance.Spec.VersionInfo == nil {
+		return true
+	}
+	start := now
+	if instance.Status.StartTime != nil {
+		start = instance.Status.StartTime.Time
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, guardrailQueryTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+
+	versions := versionDetails(instance)
+	checked := []v2alpha2.Objective{}
+	measurements := [][]guardrailMeasurement{}
+	for _, guardrail := range guardrails(instance) {
+		name := metricInfoName(instance, guardrail.Metric)
+		var metric *v2alpha2.Metric
+		for _, info := range instance.Status.Metrics {
+			if info.Name == name {
+				metric = info.MetricObj.DeepCopy()
+				break
+			}
+		}
+		if metric == nil {
+			log.Info("Metric of guardrail not found", "metric", name)
+			continue
+		}
+		secret, err := r.readMetricSecret(ctx, instance.Namespace, *metric)
+		if err != nil {
+			log.Error(err, "Unable to read secret of metric", "metric", name)
+			continue
+		}
+		results := make([]guardrailMeasurement, len(versions))
+		for i, version := range versions {
+			wg.Add(1)
+			go func(result *guardrailMeasurement, env metrics.Environment) {
+				defer wg.Done()
+				result.value, result.err = metrics.Measure(queryCtx, *metric, env)
+			}(&results[i], metricEnvironment(version, secret, start, now))
+		}
+		checked = append(checked, guardrail)
+		measurements = append(measurements, results)
+	}
+	wg.Wait()
+
+	for g, guardrail := range checked {
+		name := metricInfoName(instance, guardrail.Metric)
+		values := map[string]float64{}
+		quantities := map[string]resource.Quantity{}
+		for i, version := range versions {
+			result := measurements[g][i]
+			if result.err != nil {
+				log.Error(result.err, "Unable to query metric of guardrail", "metric", name, "version", version.Name)
+				continue
+			}
+			q, ok := quantity(result.value)
+			if !ok {
+				// treated as no data
+				continue
+			}
+			values[version.Name] = result.value
+			quantities[version.Name] = q
+		}
+		baseline, measured := values[instance.Spec.VersionInfo.Baseline.Name]
+		for _, version := range versions {
+			value, ok := values[version.Name]
+			if !ok {
+				continue
+			}
+			satisfied := satisfiesObjective(guardrail, value)
+			if guardrail.Relative != nil && version.Name != instance.Spec.VersionInfo.Baseline.Name && measured {
+				satisfied = satisfied && satisfiesRelativeLimits(*guardrail.Relative, value, baseline)
+			}
+			if !satisfied {
+				status.Violations = append(status.Violations, v2alpha2.GuardrailViolation{
+					Metric:  name,
+					Version: version.Name,
+					Value:   quantities[version.Name],
+				})
+				r.recordExperimentProgress(ctx, instance, v2alpha2.ReasonGuardrailViolated, "Version %s failed guardrail on %s: %f", version.Name, name, value)
+			}
+		}
+	}
+	return len(status.Violations) == 0
+}
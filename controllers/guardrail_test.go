@@ -0,0 +1,156 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Guardrails", func() {
+	bldr := func(v1 string, v2 string) *v2alpha2.Experiment {
+		errorRate := v2alpha2.NewMetric("error-rate", "default").
+			WithMock([]v2alpha2.NamedLevel{{Name: "v1", Level: resource.MustParse(v1)}, {Name: "v2", Level: resource.MustParse(v2)}}).
+			Build()
+		latency := v2alpha2.NewMetric("latency", "default").Build()
+		limit := resource.MustParse("0.05")
+		experiment := v2alpha2.NewExperiment("guardrail", "default").
+			WithTarget("target").
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithObjective(*latency, &limit, nil, false).
+			WithGuardrail(*errorRate, &limit, nil).
+			WithGuardrailInterval(2).
+			Build()
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{
+			{Name: "default/latency", MetricObj: *latency},
+			{Name: "default/error-rate", MetricObj: *errorRate},
+		}
+		return experiment
+	}
+	reconciler := func() *ExperimentReconciler {
+		return &ExperimentReconciler{EventRecorder: record.NewFakeRecorder(10)}
+	}
+
+	Context("When the versions satisfy the guardrails", func() {
+		It("the check succeeds and is recorded", func() {
+			experiment := bldr("0.01", "0.02")
+			now := time.Now()
+			Expect(untilGuardrailCheck(experiment, now)).To(BeNumerically("<=", 0))
+			Expect(reconciler().checkGuardrails(ctx(), experiment, now)).To(BeTrue())
+			Expect(experiment.Status.Guardrails.LastCheckTime.Time).To(BeTemporally("~", now, time.Second))
+			Expect(experiment.Status.Guardrails.Violations).To(BeEmpty())
+			Expect(untilGuardrailCheck(experiment, now.Add(time.Second))).To(BeNumerically("~", time.Second, 10*time.Millisecond))
+		})
+	})
+
+	Context("When a version fails a guardrail", func() {
+		It("the check fails and the violation is recorded", func() {
+			experiment := bldr("0.01", "0.30")
+			Expect(reconciler().checkGuardrails(ctx(), experiment, time.Now())).To(BeFalse())
+			violations := experiment.Status.Guardrails.Violations
+			Expect(violations).To(HaveLen(1))
+			Expect(violations[0].Metric).To(Equal("default/error-rate"))
+			Expect(violations[0].Version).To(Equal("v2"))
+			Expect(violations[0].Value.AsApproximateFloat64()).To(BeNumerically("~", 0.30, 0.001))
+		})
+
+		It("the objective is rolled back on failure", func() {
+			experiment := bldr("0.01", "0.30")
+			Expect(experiment.Spec.Criteria.Objectives[0].GetRollbackOnFailure(v2alpha2.DeploymentPatternProgressive)).To(BeFalse())
+			guardrail := experiment.Spec.Criteria.Objectives[1]
+			rollback := false
+			guardrail.RollbackOnFailure = &rollback
+			Expect(guardrail.GetRollbackOnFailure(v2alpha2.DeploymentPatternProgressive)).To(BeTrue())
+		})
+	})
+
+	Context("When a guardrail has limits relative to the baseline", func() {
+		It("the candidates are checked against the value for the baseline", func() {
+			experiment := bldr("0.01", "0.02")
+			guardrail := &experiment.Spec.Criteria.Objectives[1]
+			upper := resource.MustParse("50")
+			guardrail.Relative = &v2alpha2.RelativeLimits{UpperLimit: &upper}
+			Expect(reconciler().checkGuardrails(ctx(), experiment, time.Now())).To(BeFalse())
+			Expect(experiment.Status.Guardrails.Violations[0].Version).To(Equal("v2"))
+		})
+	})
+
+	Context("When the metric of a guardrail cannot be queried", func() {
+		It("the experiment is invalid", func() {
+			experiment := bldr("0.01", "0.02")
+			metricMap := map[string]*v2alpha2.MetricInfo{}
+			for i := range experiment.Status.Metrics {
+				metricMap[experiment.Status.Metrics[i].Name] = &experiment.Status.Metrics[i]
+			}
+			Expect(validGuardrailMetric("default/error-rate", metricMap)).To(BeNil())
+			Expect(validGuardrailMetric("default/latency", metricMap)).ToNot(BeNil())
+		})
+
+		It("the check does not fail", func() {
+			experiment := bldr("0.01", "0.30")
+			experiment.Status.Metrics[1].MetricObj.Spec.Mock = nil
+			Expect(reconciler().checkGuardrails(ctx(), experiment, time.Now())).To(BeTrue())
+		})
+	})
+
+	Context("When the metric of a guardrail is slow to respond", func() {
+		It("the query is abandoned and the check does not fail", func() {
+			done := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-done:
+				case <-time.After(5 * time.Second):
+				}
+				_, _ = w.Write([]byte(`{"value": 0.30}`))
+			}))
+			defer server.Close()
+			defer close(done)
+
+			timeout := guardrailQueryTimeout
+			guardrailQueryTimeout = 100 * time.Millisecond
+			defer func() { guardrailQueryTimeout = timeout }()
+
+			experiment := bldr("0.01", "0.30")
+			metric := &experiment.Status.Metrics[1].MetricObj
+			url, jq := server.URL, ".value"
+			metric.Spec.Mock = nil
+			metric.Spec.URLTemplate = &url
+			metric.Spec.JQExpression = &jq
+
+			start := time.Now()
+			Expect(reconciler().checkGuardrails(ctx(), experiment, start)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+	})
+
+	Context("When the guardrail interval is not set", func() {
+		It("the default is used", func() {
+			experiment := bldr("0.01", "0.02")
+			experiment.Spec.Duration.GuardrailIntervalSeconds = nil
+			experiment.Status.Guardrails = &v2alpha2.GuardrailStatus{LastCheckTime: metav1.Now()}
+			Expect(untilGuardrailCheck(experiment, time.Now())).To(BeNumerically("~", time.Duration(v2alpha2.DefaultGuardrailIntervalSeconds)*time.Second, 100*time.Millisecond))
+		})
+	})
+})
@@ -17,17 +17,25 @@ limitations under the License.
 package controllers
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/iter8-tools/etc3/api/v2alpha2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // HandlerType types of handlers
@@ -94,6 +102,10 @@ func (r *ExperimentReconciler) GetHandler(instance *v2alpha2.Experiment, t Handl
 // JobManager enables mocking of handler jobs during tests
 type JobManager interface {
 	Get(ctx context.Context, ref types.NamespacedName, job *batchv1.Job) error
+	// GetPods returns the pods created for a job
+	GetPods(ctx context.Context, job *batchv1.Job) ([]corev1.Pod, error)
+	// GetPodLogs returns the (most recent) logs of a container of a pod
+	GetPodLogs(ctx context.Context, pod *corev1.Pod, container string) ([]byte, error)
 }
 
 // IsHandlerLaunched returns the handler (job) if one has been launched
@@ -120,6 +132,10 @@ func (r *ExperimentReconciler) LaunchHandler(ctx context.Context, instance *v2al
 	log.Info("LaunchHandler called", "handler", handler)
 	defer log.Info("LaunchHandler completed", "handler", handler)
 
+	if r.executesInProcess(instance, handler) {
+		return r.launchInProcess(ctx, instance, handler, handlerInstance)
+	}
+
 	handlerJobYaml := path.Join(r.Iter8Config.HandlersDir, HandlerYaml)
 	log.Info("launchHandler", "jobYaml", handlerJobYaml)
 	job := batchv1.Job{}
@@ -128,6 +144,13 @@ func (r *ExperimentReconciler) LaunchHandler(ctx context.Context, instance *v2al
 		return err
 	}
 
+	// apply any experiment specific overrides of the job spec
+	// this is done first so that the experiment cannot override the values set below
+	if err := applyHandlerTemplate(&job, instance.Spec.Strategy.HandlerTemplate); err != nil {
+		log.Error(err, "apply handler template failed")
+		return err
+	}
+
 	// update job spec:
 	//   - assign a name unique for this experiment, handler type
 	//   - assign namespace same as namespace of iter8
@@ -194,6 +217,81 @@ func readJobSpec(templateFile string, job *batchv1.Job) error {
 	return nil
 }
 
+// applyHandlerTemplate merges the overrides in an experiment's handler template onto a job
+// The merge is a strategic merge so that, for example, env variables and volumes are merged by name
+// and container level fields are applied to the first container of the job.
+func applyHandlerTemplate(job *batchv1.Job, template *v2alpha2.HandlerTemplate) error {
+	if template == nil {
+		return nil
+	}
+	if len(job.Spec.Template.Spec.Containers) == 0 {
+		return fmt.Errorf("job %s has no containers", job.Name)
+	}
+
+	container := corev1.Container{
+		Name:         job.Spec.Template.Spec.Containers[0].Name,
+		Env:          template.Env,
+		VolumeMounts: template.VolumeMounts,
+	}
+	if template.Image != nil {
+		container.Image = *template.Image
+	}
+	if template.Resources != nil {
+		container.Resources = *template.Resources
+	}
+
+	podSpec := corev1.PodSpec{
+		Containers:   []corev1.Container{container},
+		NodeSelector: template.NodeSelector,
+		Tolerations:  template.Tolerations,
+		Volumes:      template.Volumes,
+	}
+
+	// secret mounts are converted to a (read only) secret volume and a volume mount
+	// volumes are named by the index of the mount; secret names may contain dots and may be mounted more than once
+	for i, s := range template.SecretMounts {
+		volumeName := fmt.Sprintf("iter8-secret-%d", i)
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: s.SecretName},
+			},
+		})
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: s.MountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	original, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": podSpec,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	merged, err := strategicpatch.StrategicMergePatch(original, patch, batchv1.Job{})
+	if err != nil {
+		return err
+	}
+
+	patched := batchv1.Job{}
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return err
+	}
+	*job = patched
+	return nil
+}
+
 func setEnvVariable(env []corev1.EnvVar, name string, value string) []corev1.EnvVar {
 	for i, e := range env {
 		if e.Name == name {
@@ -284,15 +382,220 @@ func HandlerJobFailed(handlerJob *batchv1.Job) bool {
 }
 
 // generate job name
+// If a handler has been retried, the name is that of the job executing the most recent attempt
 func jobName(instance *v2alpha2.Experiment, handler string, handlerInstance *int) string {
+	attempt := int32(1)
+	if latest := instance.Status.GetLatestHandlerAttempt(handler, handlerInstanceID(handlerInstance)); latest != nil {
+		attempt = latest.Attempt
+	}
+	return attemptJobName(instance, handler, handlerInstance, attempt)
+}
+
+// generate job name for a particular attempt to execute a handler
+// The first attempt has no suffix so that job names are unchanged when there are no retries
+func attemptJobName(instance *v2alpha2.Experiment, handler string, handlerInstance *int, attempt int32) string {
 	name := fmt.Sprintf("%s-%s-%s", instance.Namespace, instance.Name, handler)
 	if handlerInstance != nil {
 		name = fmt.Sprintf("%s-%d", name, *handlerInstance)
 	}
+	if attempt > 1 {
+		name = fmt.Sprintf("%s-r%d", name, attempt)
+	}
 
 	return name
 }
 
+// handlerInstanceID converts a handler instance to the form used in status.handlerAttempts
+func handlerInstanceID(handlerInstance *int) *int32 {
+	if handlerInstance == nil {
+		return nil
+	}
+	id := int32(*handlerInstance)
+	return &id
+}
+
+// recordHandlerAttempt records a new attempt to execute a handler in status.handlerAttempts
+func recordHandlerAttempt(instance *v2alpha2.Experiment, handler string, handlerInstance *int) *v2alpha2.HandlerAttempt {
+	attempt := instance.Status.AddHandlerAttempt(handler, handlerInstanceID(handlerInstance))
+	attempt.JobName = attemptJobName(instance, handler, handlerInstance, attempt.Attempt)
+	return attempt
+}
+
+// handlerTimeRemaining returns how much longer the most recent attempt to execute a handler may run
+// The second return value is false if there is no timeout for the handler
+func handlerTimeRemaining(instance *v2alpha2.Experiment, handler string, handlerInstance *int) (time.Duration, bool) {
+	timeout, ok := instance.Spec.GetActionTimeoutAsDuration(handler)
+	if !ok {
+		return 0, false
+	}
+	latest := instance.Status.GetLatestHandlerAttempt(handler, handlerInstanceID(handlerInstance))
+	if latest == nil {
+		// launched before attempts were recorded; nothing to measure against
+		return 0, false
+	}
+	return time.Until(latest.StartTime.Add(timeout)), true
+}
+
+// shouldRetryHandler determines if a failed handler should be launched again
+func shouldRetryHandler(instance *v2alpha2.Experiment, handler string, handlerInstance *int) bool {
+	latest := instance.Status.GetLatestHandlerAttempt(handler, handlerInstanceID(handlerInstance))
+	if latest == nil {
+		return false
+	}
+	return latest.Attempt <= instance.Spec.GetActionRetries(handler)
+}
+
+// TerminateHandler deletes the job executing the most recent attempt to execute a handler
+func (r *ExperimentReconciler) TerminateHandler(ctx context.Context, instance *v2alpha2.Experiment, handler string, handlerInstance *int) error {
+	log := Logger(ctx)
+	log.Info("TerminateHandler called", "handler", handler)
+	defer log.Info("TerminateHandler completed", "handler", handler)
+
+	if r.executesInProcess(instance, handler) {
+		r.ActionExecutor.Cancel(jobName(instance, handler, handlerInstance))
+		return nil
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName(instance, handler, handlerInstance),
+			Namespace: r.Iter8Config.Namespace,
+		},
+	}
+	if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "delete job failed", "job", job.Name)
+		return err
+	}
+	return nil
+}
+
+// HandlerFailure describes why a handler job failed
+type HandlerFailure struct {
+	// PodName is the name of the failed pod
+	PodName string
+	// ExitCode is the exit code of the handler container
+	ExitCode int32
+	// TerminationMessage is the message the handler container wrote to its termination log
+	TerminationMessage string
+	// Log is the last high priority Iter8Log written by the handler container, if any
+	Log *Iter8Log
+}
+
+// String summarizes the failure for use in status.message and conditions
+func (f *HandlerFailure) String() string {
+	msg := fmt.Sprintf("pod %s exited with code %d", f.PodName, f.ExitCode)
+	terminationMessage := strings.TrimSpace(f.TerminationMessage)
+	if len(terminationMessage) > 0 {
+		msg += ": " + terminationMessage
+	}
+	// the task runner usually logs the same error it writes to the termination log; don't repeat it
+	if f.Log != nil && f.Log.Message != terminationMessage {
+		msg += "; " + f.Log.Message
+	}
+	return msg
+}
+
+// describeHandlerFailure describes why the most recent attempt to execute a handler failed
+// Returns an empty string if the reason is not known
+func (r *ExperimentReconciler) describeHandlerFailure(ctx context.Context, instance *v2alpha2.Experiment, handler string, handlerInstance *int) string {
+	if r.executesInProcess(instance, handler) {
+		if _, err := r.ActionExecutor.Status(jobName(instance, handler, handlerInstance)); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+	if failure := r.GetHandlerFailure(ctx, instance, handler, handlerInstance); failure != nil {
+		return failure.String()
+	}
+	return ""
+}
+
+// GetHandlerFailure collects the details of the failure of the job executing a handler from its failed pod.
+// Returns nil if no details can be found.
+func (r *ExperimentReconciler) GetHandlerFailure(ctx context.Context, instance *v2alpha2.Experiment, handler string, handlerInstance *int) *HandlerFailure {
+	log := Logger(ctx)
+	log.Info("GetHandlerFailure called", "handler", handler)
+	defer log.Info("GetHandlerFailure completed", "handler", handler)
+
+	job := &batchv1.Job{}
+	ref := types.NamespacedName{Namespace: r.Iter8Config.Namespace, Name: jobName(instance, handler, handlerInstance)}
+	if err := r.JobManager.Get(ctx, ref, job); err != nil {
+		log.Error(err, "unable to read handler job", "job", ref)
+		return nil
+	}
+	pods, err := r.JobManager.GetPods(ctx, job)
+	if err != nil {
+		log.Error(err, "unable to read handler pods", "job", ref)
+		return nil
+	}
+
+	pod, state := failedHandlerPod(pods)
+	if pod == nil {
+		return nil
+	}
+	failure := &HandlerFailure{
+		PodName:            pod.Name,
+		ExitCode:           state.ExitCode,
+		TerminationMessage: state.Message,
+	}
+
+	logs, err := r.JobManager.GetPodLogs(ctx, pod, pod.Spec.Containers[0].Name)
+	if err != nil {
+		// the failure is still useful without the Iter8Log
+		log.Error(err, "unable to read handler logs", "pod", pod.Name)
+		return failure
+	}
+	failure.Log = lastIter8Log(logs, instance, Iter8LogPriorityHigh)
+	return failure
+}
+
+// failedHandlerPod finds the most recently terminated pod whose handler container exited with an error
+func failedHandlerPod(pods []corev1.Pod) (*corev1.Pod, *corev1.ContainerStateTerminated) {
+	var pod *corev1.Pod
+	var state *corev1.ContainerStateTerminated
+	for i := range pods {
+		if len(pods[i].Spec.Containers) == 0 {
+			continue
+		}
+		for _, cs := range pods[i].Status.ContainerStatuses {
+			if cs.Name != pods[i].Spec.Containers[0].Name {
+				continue
+			}
+			t := cs.State.Terminated
+			if t == nil || t.ExitCode == 0 {
+				continue
+			}
+			if state == nil || state.FinishedAt.Before(&t.FinishedAt) {
+				pod, state = &pods[i], t
+			}
+		}
+	}
+	return pod, state
+}
+
+// lastIter8Log finds the last Iter8Log for an experiment in logs with a priority at least as high as the one given
+func lastIter8Log(logs []byte, instance *v2alpha2.Experiment, priority Iter8LogPriority) *Iter8Log {
+	var last *Iter8Log
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		il := Iter8Log{}
+		if json.Unmarshal([]byte(line), &il) != nil {
+			continue
+		}
+		if il.IsIter8Log &&
+			il.ExperimentName == instance.Name &&
+			il.ExperimentNamespace == instance.Namespace &&
+			il.Priority <= priority {
+			last = &il
+		}
+	}
+	return last
+}
+
 // GetJobCondition is a utility to retrieve a condition from a Job resource
 // returns nil if it is not present
 func GetJobCondition(job *batchv1.Job, condition batchv1.JobConditionType) *batchv1.JobCondition {
@@ -316,48 +619,56 @@ const (
 	HandlerStatusRunning HandlerStatusType = "Running"
 	// HandlerStatusFailed indicates that the handler failed during execution
 	HandlerStatusFailed HandlerStatusType = "Failed"
+	// HandlerStatusTimedOut indicates that the handler was terminated because it exceeded its timeout
+	HandlerStatusTimedOut HandlerStatusType = "TimedOut"
 	// HandlerStatusComplete indicates that the handler has successfully executed to completion
 	HandlerStatusComplete HandlerStatusType = "Complete"
 )
 
 // GetHandlerStatus determines a handlers status
-func (r *ExperimentReconciler) GetHandlerStatus(ctx context.Context, instance *v2alpha2.Experiment, handler *string, handlerInstance *int) HandlerStatusType {
+// An error is returned if the job of the handler could not be read; the status is then unknown.
+func (r *ExperimentReconciler) GetHandlerStatus(ctx context.Context, instance *v2alpha2.Experiment, handler *string, handlerInstance *int) (HandlerStatusType, error) {
 	log := Logger(ctx)
 	log.Info("GetHandlerStatus called", "handler", handler)
 
 	if nil == handler {
 		log.Info("GetHandlerStatus returning", "handler", handler, "status", HandlerStatusNoHandler)
-		return HandlerStatusNoHandler
+		return HandlerStatusNoHandler, nil
 	}
 
 	// has a handler specified
+	if r.executesInProcess(instance, *handler) {
+		status := r.inProcessHandlerStatus(instance, *handler, handlerInstance)
+		log.Info("GetHandlerStatus returning", "handler", handler, "status", status, "executor", v2alpha2.ExecutorInProcess)
+		return status, nil
+	}
+
 	handlerJob, err := r.IsHandlerLaunched(ctx, instance, *handler, handlerInstance)
 	if err != nil {
 		if !errors.IsNotFound(err) {
 			log.Error(err, "Error trying to find handler job.")
-			log.Info("GetHandlerStatus returning", "handler", handler, "status", HandlerStatusFailed)
-			return HandlerStatusFailed
+			return "", err
 		}
 	}
 
 	if handlerJob == nil {
 		// handler job not lauched
 		log.Info("GetHandlerStatus returning", "handler", handler, "status", HandlerStatusNotLaunched)
-		return HandlerStatusNotLaunched
+		return HandlerStatusNotLaunched, nil
 	}
 
 	// handler job has already been launched
 
 	if HandlerJobCompleted(handlerJob) {
 		log.Info("GetHandlerStatus returning", "handler", handler, "status", HandlerStatusComplete)
-		return HandlerStatusComplete
+		return HandlerStatusComplete, nil
 	}
 	if HandlerJobFailed(handlerJob) {
 		log.Info("GetHandlerStatus returning", "handler", handler, "status", HandlerStatusFailed)
-		return HandlerStatusFailed
+		return HandlerStatusFailed, nil
 	}
 
 	// handler job exists and is done
 	log.Info("GetHandlerStatus returning", "handler", handler, "status", HandlerStatusRunning)
-	return HandlerStatusRunning
+	return HandlerStatusRunning, nil
 }
@@ -15,10 +15,16 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -148,3 +154,255 @@ var _ = Describe("Handlers Run", func() {
 	})
 
 })
+
+var _ = Describe("Handler Template", func() {
+	var job batchv1.Job
+	BeforeEach(func() {
+		job = batchv1.Job{}
+		Expect(readJobSpec(CompletePath("../test/handlers", "handler.yaml"), &job)).Should(Succeed())
+	})
+
+	Context("When an experiment has no handler template", func() {
+		It("the job is unchanged", func() {
+			original := job.DeepCopy()
+			Expect(applyHandlerTemplate(&job, nil)).Should(Succeed())
+			Expect(job).To(Equal(*original))
+		})
+	})
+
+	Context("When an experiment has a handler template", func() {
+		It("the template is merged onto the job", func() {
+			image := "iter8/handler:custom"
+			template := v2alpha2.HandlerTemplate{
+				Image: &image,
+				Resources: &corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+				NodeSelector: map[string]string{"network": "special"},
+				Tolerations:  []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+				Env: []corev1.EnvVar{
+					{Name: "EXTRA", Value: "extra"},
+					{Name: "ACTION", Value: "overridden"},
+				},
+				SecretMounts: []v2alpha2.SecretMount{{SecretName: "creds", MountPath: "/creds"}},
+			}
+			Expect(applyHandlerTemplate(&job, &template)).Should(Succeed())
+
+			podSpec := job.Spec.Template.Spec
+			Expect(podSpec.Containers).To(HaveLen(1))
+			container := podSpec.Containers[0]
+			Expect(container.Image).To(Equal(image))
+			Expect(container.Command).To(Equal([]string{"handler"}))
+			Expect(container.Resources.Limits.Memory().String()).To(Equal("1Gi"))
+			Expect(podSpec.NodeSelector).To(HaveKeyWithValue("network", "special"))
+			Expect(podSpec.Tolerations).To(HaveLen(1))
+			Expect(podSpec.Volumes).To(HaveLen(1))
+			Expect(podSpec.Volumes[0].Secret.SecretName).To(Equal("creds"))
+			Expect(container.VolumeMounts).To(HaveLen(1))
+			Expect(container.VolumeMounts[0].ReadOnly).To(BeTrue())
+			// env variables are merged by name
+			Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "EXTRA", Value: "extra"}))
+			Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "EXPERIMENT_NAME", Value: "EXPERIMENT_NAME_VALUE"}))
+			Expect(len(container.Env)).To(Equal(4))
+		})
+
+		It("a secret may be mounted more than once", func() {
+			template := v2alpha2.HandlerTemplate{
+				SecretMounts: []v2alpha2.SecretMount{
+					{SecretName: "tls.creds", MountPath: "/tls"},
+					{SecretName: "tls.creds", MountPath: "/backup"},
+				},
+			}
+			Expect(applyHandlerTemplate(&job, &template)).Should(Succeed())
+
+			podSpec := job.Spec.Template.Spec
+			Expect(podSpec.Volumes).To(HaveLen(2))
+			Expect(podSpec.Volumes[0].Name).To(Equal("iter8-secret-0"))
+			Expect(podSpec.Volumes[1].Name).To(Equal("iter8-secret-1"))
+			Expect(podSpec.Containers[0].VolumeMounts[1].Name).To(Equal("iter8-secret-1"))
+		})
+	})
+})
+
+// unreadableJobManager is a JobManager that cannot read jobs
+type unreadableJobManager struct {
+	JobManager
+}
+
+func (unreadableJobManager) Get(ctx context.Context, ref types.NamespacedName, job *batchv1.Job) error {
+	return fmt.Errorf("connection refused")
+}
+
+var _ = Describe("Handler Retries", func() {
+	var experiment *v2alpha2.Experiment
+	BeforeEach(func() {
+		timeout, retries := int32(60), int32(1)
+		experiment = v2alpha2.NewExperiment("retries", "default").
+			WithTarget("target").
+			WithTestingPattern(v2alpha2.TestingPatternConformance).
+			WithAction("start", []v2alpha2.TaskSpec{}).
+			WithActionPolicy("start", v2alpha2.ActionPolicy{TimeoutSeconds: &timeout, Retries: &retries}).
+			Build()
+	})
+
+	Context("When a handler is retried", func() {
+		It("each attempt has a distinct job name", func() {
+			Expect(jobName(experiment, "start", nil)).To(Equal("default-retries-start"))
+			Expect(shouldRetryHandler(experiment, "start", nil)).To(BeFalse())
+
+			recordHandlerAttempt(experiment, "start", nil)
+			Expect(jobName(experiment, "start", nil)).To(Equal("default-retries-start"))
+			Expect(shouldRetryHandler(experiment, "start", nil)).To(BeTrue())
+
+			a := recordHandlerAttempt(experiment, "start", nil)
+			Expect(a.JobName).To(Equal("default-retries-start-r2"))
+			Expect(jobName(experiment, "start", nil)).To(Equal("default-retries-start-r2"))
+			Expect(shouldRetryHandler(experiment, "start", nil)).To(BeFalse())
+		})
+	})
+
+	Context("When a handler has a timeout", func() {
+		It("the time remaining is measured from the start of the latest attempt", func() {
+			_, ok := handlerTimeRemaining(experiment, "start", nil)
+			Expect(ok).To(BeFalse())
+
+			a := recordHandlerAttempt(experiment, "start", nil)
+			remaining, ok := handlerTimeRemaining(experiment, "start", nil)
+			Expect(ok).To(BeTrue())
+			Expect(remaining > 0).To(BeTrue())
+
+			a.StartTime = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			remaining, ok = handlerTimeRemaining(experiment, "start", nil)
+			Expect(ok).To(BeTrue())
+			Expect(remaining <= 0).To(BeTrue())
+
+			_, ok = handlerTimeRemaining(experiment, "finish", nil)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("When the job of a handler cannot be read", func() {
+		It("the status is unknown and no attempt is counted", func() {
+			r := &ExperimentReconciler{JobManager: unreadableJobManager{}}
+			handler := "start"
+			recordHandlerAttempt(experiment, handler, nil)
+
+			_, err := r.GetHandlerStatus(ctx(), experiment, &handler, nil)
+			Expect(err).To(HaveOccurred())
+
+			stop, _, err := r.checkHandlerStatus(ctx(), experiment, HandlerTypeStart, &handler, nil)
+			Expect(stop).To(BeTrue())
+			Expect(err).To(HaveOccurred())
+			latest := experiment.Status.GetLatestHandlerAttempt(handler, nil)
+			Expect(latest.Attempt).To(Equal(int32(1)))
+			Expect(latest.Result).To(Equal(v2alpha2.HandlerAttemptRunning))
+		})
+	})
+
+	Context("When a failing handler has retries", func() {
+		Specify("the handler is relaunched", func() {
+			retries := int32(1)
+			name := "has-retried-handler"
+			retried := v2alpha2.NewExperiment(name, "default").
+				WithTarget(name).
+				WithTestingPattern(v2alpha2.TestingPatternConformance).
+				WithAction("start", []v2alpha2.TaskSpec{}).
+				WithActionPolicy("start", v2alpha2.ActionPolicy{Retries: &retries}).
+				WithDuration(1, 1, 1).
+				WithBaselineVersion("baseline", nil).
+				Build()
+			Expect(k8sClient.Create(ctx(), retried)).Should(Succeed())
+			Eventually(func() bool {
+				return hasValue(name, "default", func(exp *v2alpha2.Experiment) bool {
+					latest := exp.Status.GetLatestHandlerAttempt("start", nil)
+					return latest != nil && latest.Attempt == 2
+				})
+			}, 10).Should(BeTrue())
+			Expect(issuedEvent("relaunched (attempt 2)")).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("Handler Failures", func() {
+	var experiment *v2alpha2.Experiment
+	BeforeEach(func() {
+		experiment = v2alpha2.NewExperiment("failures", "default").Build()
+	})
+
+	Context("When a handler pod logs Iter8Logs", func() {
+		It("the last high priority log for the experiment is found", func() {
+			logs := []byte(`not json
+{"isIter8Log":true,"experimentName":"failures","experimentNamespace":"default","priority":1,"message":"first"}
+{"isIter8Log":true,"experimentName":"failures","experimentNamespace":"default","priority":1,"message":"second"}
+{"isIter8Log":true,"experimentName":"failures","experimentNamespace":"default","priority":3,"message":"low"}
+{"isIter8Log":true,"experimentName":"other","experimentNamespace":"default","priority":1,"message":"other"}
+`)
+			il := lastIter8Log(logs, experiment, Iter8LogPriorityHigh)
+			Expect(il).ToNot(BeNil())
+			Expect(il.Message).To(Equal("second"))
+			Expect(lastIter8Log([]byte("no logs"), experiment, Iter8LogPriorityHigh)).To(BeNil())
+		})
+	})
+
+	Context("When a handler job has several pods", func() {
+		It("the most recently failed pod is used", func() {
+			pod := func(name string, exitCode int32, finished time.Time) corev1.Pod {
+				return corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: name},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "iter8-handler"}}},
+					Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+						Name: "iter8-handler",
+						State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode:   exitCode,
+							FinishedAt: metav1.NewTime(finished),
+						}},
+					}}},
+				}
+			}
+			now := time.Now()
+			p, state := failedHandlerPod([]corev1.Pod{
+				pod("older", 1, now.Add(-time.Minute)),
+				pod("newer", 2, now),
+				pod("succeeded", 0, now.Add(time.Minute)),
+			})
+			Expect(p.Name).To(Equal("newer"))
+			Expect(state.ExitCode).To(Equal(int32(2)))
+
+			p, _ = failedHandlerPod([]corev1.Pod{pod("succeeded", 0, now)})
+			Expect(p).To(BeNil())
+		})
+	})
+
+	Context("When a handler failure is summarized", func() {
+		It("the log message is not repeated", func() {
+			failure := HandlerFailure{PodName: "pod", ExitCode: 1, TerminationMessage: "boom\n", Log: &Iter8Log{Message: "boom"}}
+			Expect(failure.String()).To(Equal("pod pod exited with code 1: boom"))
+			failure.Log.Message = "task failed"
+			Expect(failure.String()).To(Equal("pod pod exited with code 1: boom; task failed"))
+		})
+	})
+
+	Context("When a handler job fails", func() {
+		Specify("the failure details are in the experiment status", func() {
+			name := "has-failure-details"
+			failed := v2alpha2.NewExperiment(name, "default").
+				WithTarget(name).
+				WithTestingPattern(v2alpha2.TestingPatternConformance).
+				WithAction("start", []v2alpha2.TaskSpec{}).
+				WithDuration(1, 1, 1).
+				WithBaselineVersion("baseline", nil).
+				Build()
+			Expect(k8sClient.Create(ctx(), failed)).Should(Succeed())
+			Eventually(func() bool {
+				return hasValue(name, "default", func(exp *v2alpha2.Experiment) bool {
+					c := exp.Status.GetCondition(v2alpha2.ExperimentConditionExperimentFailed)
+					return c.Message != nil &&
+						strings.Contains(*c.Message, "exited with code 1: task http: POST https://hooks.example.com failed with status 503") &&
+						strings.Contains(*c.Message, "notification to slack channel failed") &&
+						exp.Status.Message != nil &&
+						strings.Contains(*exp.Status.Message, "failed with status 503")
+				})
+			}, 10).Should(BeTrue())
+		})
+	})
+})
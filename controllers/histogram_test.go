@@ -0,0 +1,81 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Histogram Metrics", func() {
+	hists := `{
+		"v1": {"DurationHistogram": {"Data": [{"Start": 0, "End": 0.1, "Count": 50}, {"Start": 0.1, "End": 0.2, "Count": 50}]}},
+		"v2": {"DurationHistogram": {"Data": [{"Start": 0, "End": 0.1, "Count": 10}, {"Start": 0.1, "End": 0.5, "Count": 90}]}}
+	}`
+
+	bldr := func() *v2alpha2.Experiment {
+		latency := v2alpha2.NewMetric("latency", "default").
+			WithProvider(v2alpha2.ProviderFortio).
+			WithPercentiles(50, 95).
+			Build()
+		upper := resource.MustParse("0.25")
+		experiment := v2alpha2.NewExperiment("histogram", "default").
+			WithTarget("target").
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithObjective(*v2alpha2.NewMetric("latency-p95", "default").Build(), &upper, nil, false).
+			Build()
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{{Name: "default/latency", MetricObj: *latency}}
+		experiment.Status.Analysis = &v2alpha2.Analysis{
+			AggregatedBuiltinHists: &v2alpha2.AggregatedBuiltinHists{Data: apiextensionsv1.JSON{Raw: []byte(hists)}},
+		}
+		return experiment
+	}
+
+	Context("When an experiment uses a Histogram metric", func() {
+		It("the distribution of each version is recorded", func() {
+			experiment := bldr()
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data
+			Expect(data).To(HaveKey("default/latency"))
+			histogram := data["default/latency"].Data["v2"].Histogram
+			Expect(histogram).To(HaveLen(2))
+			Expect(histogram[1].Lower.AsApproximateFloat64()).To(BeNumerically("~", 0.1, 1e-6))
+			Expect(histogram[1].Upper.AsApproximateFloat64()).To(BeNumerically("~", 0.5, 1e-6))
+			Expect(histogram[1].Count.AsApproximateFloat64()).To(Equal(90.0))
+		})
+		It("the percentiles of each version are computed", func() {
+			experiment := bldr()
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data
+			Expect(data).To(HaveKey("default/latency-p50"))
+			Expect(data).To(HaveKey("default/latency-p95"))
+			Expect(data["default/latency-p50"].Data["v1"].Value.AsApproximateFloat64()).To(BeNumerically("~", 0.1, 1e-6))
+			Expect(data["default/latency-p95"].Data["v1"].Value.AsApproximateFloat64()).To(BeNumerically("~", 0.19, 1e-6))
+			Expect(data["default/latency-p95"].Data["v2"].Value.AsApproximateFloat64()).To(BeNumerically("~", 0.4777777, 1e-6))
+		})
+		It("objectives on a percentile are assessed", func() {
+			experiment := bldr()
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			assessments := experiment.Status.Analysis.VersionAssessments.Data
+			Expect(assessments["v1"]).To(Equal(v2alpha2.BooleanList{true}))
+			Expect(assessments["v2"]).To(Equal(v2alpha2.BooleanList{false}))
+		})
+	})
+})
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -74,13 +75,27 @@ func (r *ExperimentReconciler) doIteration(ctx context.Context, instance *v2alph
 		return ctrl.Result{}, err
 	}
 
+	// A BlueGreen experiment that has cut over finishes when its rollback window elapses
+	// If it runs out of iterations first, it waits for the window to elapse
+	remaining, cutover := rollbackWindowRemaining(instance)
+	if cutover && remaining <= 0 {
+		return r.finishExperiment(ctx, instance)
+	}
+
 	// If we've already executed as many iterations as requested, we  should finish the experiment
 	// Check here since may have executed a loop handler
 	if !moreIterationsNeeded(instance) {
+		if cutover {
+			return r.endRequest(ctx, instance, remaining)
+		}
 		return r.finishExperiment(ctx, instance)
 	}
 
 	if !r.sufficientTimePassedSincePreviousIteration(ctx, instance) {
+		// guardrail objectives are checked while waiting for the next iteration
+		if len(guardrails(instance)) > 0 {
+			return r.guardIteration(ctx, instance)
+		}
 		// not enough time has passed since the last iteration, wait
 		return ctrl.Result{}, errors.New("insufficient time has passed since previous iteration")
 	}
@@ -110,15 +125,58 @@ func (r *ExperimentReconciler) doIteration(ctx context.Context, instance *v2alph
 	}
 	instance.Status.Analysis = analysis
 
+	// evaluate metrics that use a built-in provider
+	r.evaluateBuiltinMetrics(ctx, instance)
+	evaluateDerivedMetrics(ctx, instance)
+	recordSampleSizes(instance)
+
+	// candidates must also satisfy the limits of objectives relative to the baseline
+	assessRelativeObjectives(ctx, instance)
+
+	// assess the winner using the built-in analysis if criteria.strength is set
+	// or by the composite score of the rewards if there are several
+	assessWinner(ctx, instance)
+	scoreRewards(ctx, instance)
+
 	// Handle failure of objective (possibly rollback)
 	if r.mustRollback(ctx, instance) {
 		return r.rollbackExperiment(ctx, instance)
 	}
 
+	// the controller determines the weights of BlueGreen and Shadow experiments
+	cutoverTo := blueGreenWeights(ctx, instance)
+	shadowWeights(ctx, instance)
+
+	// the weights of an A/B/N experiment may be determined by a multi-armed bandit
+	banditWeights(ctx, instance)
+
+	// bound the recommended weights by the current step of the weight schedule, if any
+	boundWeights(ctx, instance)
+
+	// a dry run records the patches of this iteration only
+	instance.Status.DryRunPatches = nil
+
 	// update weight distribution
+	// if the weights could not be applied, they are left unchanged and the experiment continues
 	if err := redistributeWeight(ctx, instance, r.RestConfig); err != nil {
-		r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonWeightRedistributionFailed, "Failure redistributing weights: %s", err.Error())
-		return r.failExperiment(ctx, instance, err)
+		var notApplied *weightsNotAppliedError
+		if !errors.As(err, &notApplied) {
+			r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonWeightRedistributionFailed, "Failure redistributing weights: %s", err.Error())
+			return r.failExperiment(ctx, instance, err)
+		}
+		r.recordWeightsApplied(ctx, instance, corev1.ConditionFalse, v2alpha2.ReasonWeightsNotApplied, "Unable to apply weights: %s", err.Error())
+	} else if shouldRedistribute(instance) && !instance.Spec.GetDryRun() {
+		r.recordWeightsApplied(ctx, instance, corev1.ConditionTrue, v2alpha2.ReasonWeightsApplied, "")
+		if cutoverTo != nil {
+			r.completeCutover(ctx, instance, *cutoverTo)
+		}
+	}
+
+	// route the requests selected by the match rules of the candidates, if any, to them
+	if err := applyMatchRoutes(ctx, instance, r.RestConfig); err != nil {
+		r.recordWeightsApplied(ctx, instance, corev1.ConditionFalse, v2alpha2.ReasonWeightsNotApplied, "Unable to apply match rules: %s", err.Error())
+	} else if instance.Spec.GetDryRun() {
+		r.recordDryRun(ctx, instance)
 	}
 
 	// after weights have been redistributed, update Status.CurrentWeightDistribution
@@ -127,6 +185,9 @@ func (r *ExperimentReconciler) doIteration(ctx context.Context, instance *v2alph
 		return r.failExperiment(ctx, instance, nil)
 	}
 
+	// the observed weights determine progress through the weight schedule, if any
+	r.advanceWeightSchedule(ctx, instance)
+
 	// update status.versionRecommendedForPromotion if a new winner identified
 	instance.Status.SetVersionRecommendedForPromotion(instance.Spec.VersionInfo.Baseline.Name)
 
@@ -0,0 +1,332 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// matches.go implements the match rules of versions: requests selected by header, cookie or user hash are
+// routed to a candidate regardless of the weights. The built-in weight adapter adds a route for the
+// candidate in front of the weighted routes; the routes are removed when the experiment completes.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// matchRoutePrefix is the prefix of the names of routes added for match rules
+const matchRoutePrefix = "iter8-match-"
+
+// userHashValues is the number of distinct values of a user hash
+const userHashValues int32 = 100
+
+// headerCondition is a condition on the value of a header
+type headerCondition struct {
+	name  string
+	value string
+	regex bool
+}
+
+// matchVersion is a version together with the header conditions derived from its match rules
+// A request is routed to the version if it satisfies all of the conditions in any of the lists.
+type matchVersion struct {
+	name       string
+	backend    v2alpha2.VersionBackend
+	conditions [][]headerCondition
+}
+
+// isMatchRoute determines if a route was added for match rules
+func isMatchRoute(r map[string]interface{}) bool {
+	name, _ := r["name"].(string)
+	return strings.HasPrefix(name, matchRoutePrefix)
+}
+
+// hasMatchRules determines if any candidate of an experiment has match rules
+func hasMatchRules(instance *v2alpha2.Experiment) bool {
+	if instance.Spec.VersionInfo == nil {
+		return false
+	}
+	for _, c := range instance.Spec.VersionInfo.Candidates {
+		if len(c.Match) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validMatchRules verifies that the match rules of the versions can be configured
+func validMatchRules(s v2alpha2.ExperimentSpec) error {
+	if s.VersionInfo == nil {
+		return nil
+	}
+	if len(s.VersionInfo.Baseline.Match) > 0 {
+		return errors.New("match rules may be specified only for candidates")
+	}
+
+	total := int32(0)
+	for _, c := range s.VersionInfo.Candidates {
+		if len(c.Match) == 0 {
+			continue
+		}
+		backend := s.GetWeightBackend()
+		if backend == nil {
+			return errors.New("spec.strategy.weights.backend is required to configure match rules")
+		}
+		adapter, err := getWeightAdapter(*backend)
+		if err != nil {
+			return err
+		}
+		if adapter.matchRoute == nil {
+			return fmt.Errorf("backend %s does not support match rules", *backend)
+		}
+		if c.WeightObjRef == nil {
+			return fmt.Errorf("candidate %s has no weightObjRef", c.Name)
+		}
+
+		userHashes := 0
+		for _, rule := range c.Match {
+			if len(rule.Headers) == 0 && rule.Cookie == nil && rule.UserHash == nil {
+				return fmt.Errorf("match rule of candidate %s has no conditions", c.Name)
+			}
+			for _, h := range rule.Headers {
+				if h.GetType() != v2alpha2.HeaderMatchRegularExpression {
+					continue
+				}
+				if _, err := regexp.Compile(h.Value); err != nil {
+					return fmt.Errorf("invalid regular expression for header %s of candidate %s: %s", h.Name, c.Name, err.Error())
+				}
+			}
+			if rule.UserHash != nil {
+				userHashes++
+				total += rule.UserHash.Percent
+			}
+		}
+		if userHashes > 1 {
+			return fmt.Errorf("candidate %s has more than one userHash match rule", c.Name)
+		}
+	}
+	if total > userHashValues {
+		return fmt.Errorf("userHash match rules select %d%% of users", total)
+	}
+	return nil
+}
+
+// assignUserHashes assigns a range of hash values to each version with a userHash match rule
+// Existing assignments are kept unless the percentage of the version has changed.
+func assignUserHashes(instance *v2alpha2.Experiment) error {
+	percents := map[string]int32{}
+	for _, c := range instance.Spec.VersionInfo.Candidates {
+		for _, rule := range c.Match {
+			if rule.UserHash != nil {
+				percents[c.Name] = rule.UserHash.Percent
+			}
+		}
+	}
+
+	// keep the assignments that are unchanged
+	kept := []v2alpha2.UserHashAssignment{}
+	for _, a := range instance.Status.UserHashAssignments {
+		if percent, ok := percents[a.Version]; ok && a.End-a.Start == percent {
+			kept = append(kept, a)
+			delete(percents, a.Version)
+		}
+	}
+
+	// assign the lowest free range to the others, in the order of the candidates
+	for _, c := range instance.Spec.VersionInfo.Candidates {
+		percent, ok := percents[c.Name]
+		if !ok {
+			continue
+		}
+		start, ok := freeUserHashRange(kept, percent)
+		if !ok {
+			return fmt.Errorf("unable to assign %d%% of users to %s", percent, c.Name)
+		}
+		kept = append(kept, v2alpha2.UserHashAssignment{Version: c.Name, Start: start, End: start + percent})
+	}
+	instance.Status.UserHashAssignments = kept
+	return nil
+}
+
+// freeUserHashRange returns the start of the lowest range of hash values of a given size that is not assigned
+func freeUserHashRange(assignments []v2alpha2.UserHashAssignment, size int32) (int32, bool) {
+	for start := int32(0); start+size <= userHashValues; start++ {
+		free := true
+		for _, a := range assignments {
+			if start < a.End && a.Start < start+size {
+				free = false
+				start = a.End - 1
+				break
+			}
+		}
+		if free {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+// getUserHashAssignment returns the range of hash values assigned to a version, if any
+func getUserHashAssignment(instance *v2alpha2.Experiment, version string) *v2alpha2.UserHashAssignment {
+	for i := range instance.Status.UserHashAssignments {
+		if instance.Status.UserHashAssignments[i].Version == version {
+			return &instance.Status.UserHashAssignments[i]
+		}
+	}
+	return nil
+}
+
+// matchConditions converts a match rule to header conditions
+func matchConditions(rule v2alpha2.MatchRule, assignment *v2alpha2.UserHashAssignment) []headerCondition {
+	conditions := []headerCondition{}
+	for _, h := range rule.Headers {
+		conditions = append(conditions, headerCondition{
+			name:  h.Name,
+			value: h.Value,
+			regex: h.GetType() == v2alpha2.HeaderMatchRegularExpression,
+		})
+	}
+	if rule.Cookie != nil {
+		conditions = append(conditions, headerCondition{name: "cookie", value: cookieRegex(*rule.Cookie), regex: true})
+	}
+	if rule.UserHash != nil && assignment != nil {
+		conditions = append(conditions, headerCondition{name: rule.UserHash.Header, value: userHashRegex(assignment.Start, assignment.End), regex: true})
+	}
+	return conditions
+}
+
+// cookieRegex returns a regular expression that matches a cookie header that includes a cookie
+func cookieRegex(cookie v2alpha2.CookieMatch) string {
+	return `^(.*;\s*)?` + regexp.QuoteMeta(cookie.Name) + "=" + regexp.QuoteMeta(cookie.Value) + `(;.*)?$`
+}
+
+// userHashRegex returns a regular expression that matches the hash values from start up to (but not including) end
+func userHashRegex(start, end int32) string {
+	values := []string{}
+	for v := start; v < end; v++ {
+		values = append(values, strconv.Itoa(int(v)))
+	}
+	return "^(" + strings.Join(values, "|") + ")$"
+}
+
+// matchVersions returns the versions with match rules, grouped by traffic routing object
+func matchVersions(instance *v2alpha2.Experiment) map[corev1.ObjectReference][]matchVersion {
+	result := map[corev1.ObjectReference][]matchVersion{}
+	for _, c := range instance.Spec.VersionInfo.Candidates {
+		if len(c.Match) == 0 || c.WeightObjRef == nil {
+			continue
+		}
+		v := matchVersion{name: c.Name, backend: c.GetBackend()}
+		for _, rule := range c.Match {
+			v.conditions = append(v.conditions, matchConditions(rule, getUserHashAssignment(instance, c.Name)))
+		}
+		key := getKey(*c.WeightObjRef)
+		result[key] = append(result[key], v)
+	}
+	return result
+}
+
+// applyMatchRoutes configures the routes for the match rules of the versions of an experiment
+// If the routes cannot be configured, any changes are rolled back and a weightsNotAppliedError is returned.
+func applyMatchRoutes(ctx context.Context, instance *v2alpha2.Experiment, restCfg *rest.Config) error {
+	log := Logger(ctx)
+	log.Info("applyMatchRoutes called")
+	defer log.Info("applyMatchRoutes completed")
+
+	if !hasMatchRules(instance) {
+		return nil
+	}
+	if err := validMatchRules(instance.Spec); err != nil {
+		return &weightsNotAppliedError{err: err}
+	}
+	if err := assignUserHashes(instance); err != nil {
+		return &weightsNotAppliedError{err: err}
+	}
+	adapter, err := getWeightAdapter(*instance.Spec.GetWeightBackend())
+	if err != nil {
+		return &weightsNotAppliedError{err: err}
+	}
+
+	baseline := instance.Spec.VersionInfo.Baseline.GetBackend()
+	patches := map[corev1.ObjectReference][]patchValue{}
+	for key, versions := range matchVersions(instance) {
+		key := key
+		obj, err := readObject(ctx, &key, instance.Namespace, restCfg)
+		if err != nil {
+			return &weightsNotAppliedError{err: err}
+		}
+		p, err := adapter.matchPatches(obj, baseline, versions)
+		if err != nil {
+			return &weightsNotAppliedError{err: err}
+		}
+		if len(p) > 0 {
+			log.Info("applyMatchRoutes adding patch", "object", key, "patch", p)
+			patches[key] = p
+		}
+	}
+	if len(patches) == 0 {
+		return nil
+	}
+
+	applied, err := applyPatches(ctx, instance, patches, restCfg)
+	if err != nil {
+		if rollbackErr := rollbackPatches(ctx, applied, instance.Namespace, restCfg); rollbackErr != nil {
+			err = fmt.Errorf("%s; rollback failed: %s", err.Error(), rollbackErr.Error())
+		}
+		return &weightsNotAppliedError{err: err}
+	}
+	return nil
+}
+
+// removeMatchRoutes removes the routes added for match rules when an experiment completes
+// Failure is logged; it does not change the outcome of the experiment.
+func (r *ExperimentReconciler) removeMatchRoutes(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("removeMatchRoutes called")
+	defer log.Info("removeMatchRoutes completed")
+
+	if !hasMatchRules(instance) || instance.Spec.GetWeightBackend() == nil {
+		return
+	}
+	adapter, err := getWeightAdapter(*instance.Spec.GetWeightBackend())
+	if err != nil {
+		log.Error(err, "Unable to remove match routes")
+		return
+	}
+
+	patches := map[corev1.ObjectReference][]patchValue{}
+	for key := range matchVersions(instance) {
+		key := key
+		obj, err := readObject(ctx, &key, instance.Namespace, r.RestConfig)
+		if err != nil {
+			log.Error(err, "Unable to remove match routes", "object", key)
+			continue
+		}
+		if p := adapter.unmatchPatches(obj); len(p) > 0 {
+			patches[key] = p
+		}
+	}
+	if len(patches) == 0 {
+		return
+	}
+	if _, err := applyPatches(ctx, instance, patches, r.RestConfig); err != nil {
+		log.Error(err, "Unable to remove match routes")
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"regexp"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Match Rules", func() {
+	objRef := &corev1.ObjectReference{APIVersion: "networking.istio.io/v1beta1", Kind: "VirtualService", Name: "reviews"}
+	bldr := func() *v2alpha2.ExperimentBuilder {
+		return v2alpha2.NewExperiment("match", "default").
+			WithTarget("target").
+			WithTestingPattern(v2alpha2.TestingPatternABN).
+			WithWeightBackend(v2alpha2.WeightBackendVirtualService).
+			WithBaselineVersion("v1", objRef).
+			WithCandidateVersion("v2", objRef).
+			WithCandidateVersion("v3", objRef)
+	}
+	beta := v2alpha2.MatchRule{Headers: []v2alpha2.HeaderMatch{{Name: "x-group", Value: "beta"}}}
+
+	Context("When validating match rules", func() {
+		It("they must be configurable", func() {
+			Expect(validMatchRules(bldr().WithMatch("v2", beta).Build().Spec)).To(Succeed())
+			Expect(validMatchRules(bldr().WithMatch("v1", beta).Build().Spec)).ToNot(Succeed())
+			Expect(validMatchRules(bldr().WithMatch("v2", v2alpha2.MatchRule{}).Build().Spec)).ToNot(Succeed())
+			Expect(validMatchRules(bldr().WithWeightBackend(v2alpha2.WeightBackendTrafficSplit).WithMatch("v2", beta).Build().Spec)).ToNot(Succeed())
+			Expect(validMatchRules(bldr().
+				WithMatch("v2", v2alpha2.MatchRule{UserHash: &v2alpha2.UserHashMatch{Header: "x-user-hash", Percent: 60}}).
+				WithMatch("v3", v2alpha2.MatchRule{UserHash: &v2alpha2.UserHashMatch{Header: "x-user-hash", Percent: 60}}).
+				Build().Spec)).ToNot(Succeed())
+		})
+	})
+
+	Context("When users are assigned by hash", func() {
+		It("the assignment of a version does not change", func() {
+			experiment := bldr().
+				WithMatch("v3", v2alpha2.MatchRule{UserHash: &v2alpha2.UserHashMatch{Header: "x-user-hash", Percent: 20}}).
+				Build()
+			Expect(assignUserHashes(experiment)).To(Succeed())
+			Expect(experiment.Status.UserHashAssignments).To(Equal([]v2alpha2.UserHashAssignment{{Version: "v3", Start: 0, End: 20}}))
+
+			// a later assignment to another version does not move users of v3
+			experiment.Spec.VersionInfo.Candidates[0].Match = []v2alpha2.MatchRule{{UserHash: &v2alpha2.UserHashMatch{Header: "x-user-hash", Percent: 10}}}
+			Expect(assignUserHashes(experiment)).To(Succeed())
+			Expect(experiment.Status.UserHashAssignments).To(Equal([]v2alpha2.UserHashAssignment{
+				{Version: "v3", Start: 0, End: 20},
+				{Version: "v2", Start: 20, End: 30},
+			}))
+		})
+		It("the hash values are matched by a regular expression", func() {
+			re := regexp.MustCompile(userHashRegex(20, 30))
+			Expect(re.MatchString("20")).To(BeTrue())
+			Expect(re.MatchString("29")).To(BeTrue())
+			Expect(re.MatchString("30")).To(BeFalse())
+			Expect(re.MatchString("2")).To(BeFalse())
+		})
+		It("cookies are matched by a regular expression", func() {
+			re := regexp.MustCompile(cookieRegex(v2alpha2.CookieMatch{Name: "group", Value: "beta"}))
+			Expect(re.MatchString("group=beta")).To(BeTrue())
+			Expect(re.MatchString("session=1; group=beta; theme=dark")).To(BeTrue())
+			Expect(re.MatchString("subgroup=beta")).To(BeFalse())
+		})
+	})
+
+	Context("When match rules are configured in a VirtualService", func() {
+		obj := toObject(`
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+spec:
+  http:
+  - name: iter8-match-v2-0
+    match:
+    - headers:
+        x-group:
+          exact: alpha
+    route:
+    - destination:
+        host: reviews-v2
+  - match:
+    - uri:
+        prefix: /api
+    route:
+    - destination:
+        host: reviews-v1
+      weight: 100
+    - destination:
+        host: reviews-v2
+        port:
+          number: 9080
+      weight: 0
+`)
+		adapter, _ := getWeightAdapter(v2alpha2.WeightBackendVirtualService)
+		versions := []matchVersion{{
+			name:       "v2",
+			backend:    v2alpha2.VersionBackend{Name: "reviews-v2"},
+			conditions: [][]headerCondition{matchConditions(beta, nil)},
+		}}
+		It("a route for the candidate replaces the previous one", func() {
+			patches, err := adapter.matchPatches(obj, v2alpha2.VersionBackend{Name: "reviews-v1"}, versions)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patches).To(HaveLen(2))
+			Expect(patches[0].Op).To(Equal("test"))
+			Expect(patches[1].Path).To(Equal("/spec/http"))
+
+			routes := patches[1].Value.([]interface{})
+			Expect(routes).To(HaveLen(2))
+			Expect(sameJSON(routes[0], map[string]interface{}{
+				"name": "iter8-match-v2-0",
+				"match": []interface{}{map[string]interface{}{
+					"uri":     map[string]interface{}{"prefix": "/api"},
+					"headers": map[string]interface{}{"x-group": map[string]interface{}{"exact": "beta"}},
+				}},
+				"route": []interface{}{map[string]interface{}{"destination": map[string]interface{}{"host": "reviews-v2", "port": map[string]interface{}{"number": 9080}}}},
+			})).To(BeTrue())
+		})
+		It("the routes for match rules are not weighted routes", func() {
+			w, err := adapter.weight(obj, v2alpha2.VersionBackend{Name: "reviews-v2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w).To(Equal(int32(0)))
+		})
+		It("the routes for match rules are removed", func() {
+			patches := adapter.unmatchPatches(obj)
+			Expect(patches).To(HaveLen(2))
+			Expect(patches[1].Value).To(HaveLen(1))
+		})
+	})
+
+	Context("When match rules are configured in an HTTPRoute", func() {
+		obj := toObject(`
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+spec:
+  rules:
+  - backendRefs:
+    - name: reviews-v1
+      port: 9080
+    - name: reviews-v2
+      port: 9080
+      weight: 0
+`)
+		adapter, _ := getWeightAdapter(v2alpha2.WeightBackendHTTPRoute)
+		It("a rule for the candidate is added", func() {
+			versions := []matchVersion{{
+				name:       "v2",
+				backend:    v2alpha2.VersionBackend{Name: "reviews-v2"},
+				conditions: [][]headerCondition{matchConditions(v2alpha2.MatchRule{Cookie: &v2alpha2.CookieMatch{Name: "group", Value: "beta"}}, nil)},
+			}}
+			patches, err := adapter.matchPatches(obj, v2alpha2.VersionBackend{Name: "reviews-v1"}, versions)
+			Expect(err).ToNot(HaveOccurred())
+			rules := patches[1].Value.([]interface{})
+			Expect(rules).To(HaveLen(2))
+			Expect(sameJSON(rules[0], map[string]interface{}{
+				"name": "iter8-match-v2-0",
+				"matches": []interface{}{map[string]interface{}{
+					"headers": []interface{}{map[string]interface{}{"type": "RegularExpression", "name": "cookie", "value": cookieRegex(v2alpha2.CookieMatch{Name: "group", Value: "beta"})}},
+				}},
+				"backendRefs": []interface{}{map[string]interface{}{"name": "reviews-v2", "port": 9080}},
+			})).To(BeTrue())
+		})
+	})
+})
@@ -18,60 +18,164 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/iter8-tools/etc3/metrics"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// metricError describes why the metrics of an experiment cannot be read
+type metricError struct {
+	// reason is the reason with which the failure is recorded
+	reason  string
+	message string
+	// resourceVersion is the version of the metric at fault, if it could be read
+	resourceVersion string
+}
+
+func (e *metricError) Error() string {
+	return e.message
+}
+
+func newMetricError(reason string, messageFormat string, messageA ...interface{}) *metricError {
+	return &metricError{reason: reason, message: fmt.Sprintf(messageFormat, messageA...)}
+}
+
+// recordMetricError records the failure of the experiment because its metrics cannot be read
+func (r *ExperimentReconciler) recordMetricError(ctx context.Context, instance *v2alpha2.Experiment, err *metricError) {
+	r.recordExperimentFailed(ctx, instance, err.reason, "%s", err.message)
+}
+
 // ReadMetric reads a metric from the cluster using the name as the key
 // If the name is of the form "namespace/name", look in namespace for name.
-// Otherwise look for name. If not found, look in namespace for name.
-// If not found return NotFound error
-func (r *ExperimentReconciler) ReadMetric(ctx context.Context, instance *v2alpha2.Experiment, namespace string, name string, metricMap map[string]*v2alpha2.Metric) bool {
+// Otherwise look in namespace for name. If not found, look for a ClusterMetric with the name.
+// A Metric therefore shadows a ClusterMetric of the same name.
+// If not found record the failure of the experiment
+// Metrics referenced by the metric (its sampleSize and, for a Derived metric, its references) are also read.
+func (r *ExperimentReconciler) ReadMetric(ctx context.Context, instance *v2alpha2.Experiment, namespace string, name string, metricMap map[string]*v2alpha2.MetricInfo) bool {
+	if err := r.readMetric(ctx, namespace, name, metricMap, nil); err != nil {
+		r.recordMetricError(ctx, instance, err)
+		return false
+	}
+	return true
+}
+
+// readMetric reads a metric and the metrics it references; it does not record failures
+// path is the list of metrics whose references led to this metric; it is used to detect cycles
+func (r *ExperimentReconciler) readMetric(ctx context.Context, namespace string, name string, metricMap map[string]*v2alpha2.MetricInfo, path []string) *metricError {
 	log := Logger(ctx)
 	log.Info("ReadMetric called", "namespace", namespace, "name", name)
 	defer log.Info("ReadMetric completed", "namespace", namespace, "name", name)
 
 	// If the metric name includes a "/" then use the prefix as the namespace
+	// Only unqualified names may be resolved to a ClusterMetric
+	qualified := false
 	splt := strings.Split(name, "/")
 	if len(splt) == 2 {
 		namespace = splt[0]
 		name = splt[1]
+		qualified = true
 	}
 
 	key := namespace + "/" + name
 	log.Info("ReadMetric", "key", key)
 
+	// a metric that references itself, directly or indirectly, cannot be evaluated
+	for _, k := range path {
+		if k == key {
+			return newMetricError(v2alpha2.ReasonMetricInvalid, "Metric %s references itself: %s", key, strings.Join(append(path, key), " -> "))
+		}
+	}
+
 	// if we've already read the metric, then we don't need to proceed; just return true
 	if _, ok := metricMap[key]; ok {
 		log.Info("Already read metric", "key", key)
-		return true
+		return nil
 	}
 
+	source := v2alpha2.MetricSourceMetric
 	metric := &v2alpha2.Metric{}
 	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, metric)
+	if errors.IsNotFound(err) && !qualified {
+		clusterMetric := &v2alpha2.ClusterMetric{}
+		if err = r.Get(ctx, types.NamespacedName{Name: name}, clusterMetric); err == nil {
+			log.Info("Using ClusterMetric", "name", name)
+			source = v2alpha2.MetricSourceClusterMetric
+			metric = clusterMetric.AsMetric()
+		}
+	}
+	if errors.IsNotFound(err) {
+		// the name may reference a percentile of a Histogram metric; for example, latency-p95
+		if base, percentile, ok := v2alpha2.ParsePercentileMetricName(name); ok {
+			return r.readPercentileMetric(ctx, namespace, base, percentile, qualified, metricMap, append(append([]string{}, path...), key))
+		}
+	}
 	if err != nil {
-		// could not read metric; record the problem and indicate that the read did not succeed
-		if errors.IsNotFound(err) {
-			r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonMetricUnavailable, "Unable to find metric %s/%s", namespace, name)
-		} else {
-			r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonMetricsUnreadable, "Unable to load metric %s/%s", namespace, name)
+		// could not read metric; describe the problem
+		if errors.IsNotFound(err) && len(path) > 0 {
+			return newMetricError(v2alpha2.ReasonMetricUnavailable, "Unable to find metric %s/%s referenced by %s", namespace, name, path[len(path)-1])
+		} else if errors.IsNotFound(err) {
+			return newMetricError(v2alpha2.ReasonMetricUnavailable, "Unable to find metric %s/%s", namespace, name)
 		}
-		return false // not ok
+		return newMetricError(v2alpha2.ReasonMetricsUnreadable, "Unable to load metric %s/%s", namespace, name)
 	}
 
 	// add to the map
-	metricMap[key] = metric
+	metricMap[key] = &v2alpha2.MetricInfo{Name: key, MetricObj: *metric, Source: &source}
 
-	// check if this metric references another metric. If so, read it too
+	// check if this metric references other metrics. If so, read them too
+	// A ClusterMetric has no namespace; its references are resolved in the namespace in which it was looked up
+	path = append(append([]string{}, path...), key)
 	if metric.Spec.SampleSize != nil {
-		return r.ReadMetric(ctx, instance, metric.GetObjectMeta().GetNamespace(), *metric.Spec.SampleSize, metricMap)
+		if err := r.readMetric(ctx, namespace, *metric.Spec.SampleSize, metricMap, path); err != nil {
+			return err
+		}
+	}
+	if metric.Spec.IsDerived() {
+		if _, err := metrics.CompileExpression(metric.Spec); err != nil {
+			merr := newMetricError(v2alpha2.ReasonMetricInvalid, "Derived metric %s is invalid: %s", key, err.Error())
+			merr.resourceVersion = metric.ResourceVersion
+			return merr
+		}
+		for _, ref := range metric.Spec.References {
+			if err := r.readMetric(ctx, namespace, ref.Metric, metricMap, path); err != nil {
+				return err
+			}
+		}
 	}
 
 	// must be ok
-	return true
+	return nil
+}
+
+// readPercentileMetric reads the Histogram metric whose percentile is referenced
+// The percentile itself is not added to the map; its value is computed from the distribution of the Histogram metric.
+func (r *ExperimentReconciler) readPercentileMetric(ctx context.Context, namespace string, base string, percentile int32, qualified bool, metricMap map[string]*v2alpha2.MetricInfo, path []string) *metricError {
+	name := base
+	if qualified {
+		name = namespace + "/" + base
+	}
+	if err := r.readMetric(ctx, namespace, name, metricMap, path); err != nil {
+		return err
+	}
+	key := namespace + "/" + base
+	metric := metricMap[key].MetricObj
+	var err *metricError
+	if !metric.Spec.IsHistogram() {
+		err = newMetricError(v2alpha2.ReasonMetricInvalid, "Metric %s is not a Histogram metric; percentile %d cannot be referenced", key, percentile)
+	} else {
+		for _, p := range metric.Spec.GetPercentiles() {
+			if p == percentile {
+				return nil
+			}
+		}
+		err = newMetricError(v2alpha2.ReasonMetricInvalid, "Histogram metric %s does not compute percentile %d", key, percentile)
+	}
+	err.resourceVersion = metric.ResourceVersion
+	return err
 }
 
 // MeticsRead checks if the metrics have already been read and stored in status
@@ -100,23 +204,35 @@ func (r *ExperimentReconciler) ReadMetrics(ctx context.Context, instance *v2alph
 	log.Info("ReadMetrics called")
 	defer log.Info("ReadMetrics completed")
 
+	infos, err := r.loadMetrics(ctx, instance)
+	if err != nil {
+		r.recordMetricError(ctx, instance, err)
+		return false
+	}
+	instance.Status.Metrics = append(instance.Status.Metrics, infos...)
+	return true
+}
+
+// loadMetrics reads and validates the metrics needed by the criteria of an experiment
+// Failures are returned; they are not recorded.
+func (r *ExperimentReconciler) loadMetrics(ctx context.Context, instance *v2alpha2.Experiment) ([]v2alpha2.MetricInfo, *metricError) {
 	criteria := instance.Spec.Criteria
 
 	namespace := instance.GetObjectMeta().GetNamespace()
-	metricsCache := make(map[string]*v2alpha2.Metric)
+	metricsCache := make(map[string]*v2alpha2.MetricInfo)
 
 	// name of request counter
 	if requestCount := instance.Spec.GetRequestCount(); requestCount != nil {
-		if ok := r.ReadMetric(ctx, instance, namespace, *requestCount, metricsCache); !ok {
-			return ok
+		if err := r.readMetric(ctx, namespace, *requestCount, metricsCache, nil); err != nil {
+			return nil, err
 		}
 	}
 
 	// rewards
 	for _, reward := range criteria.Rewards {
 		if metricsCache[reward.Metric] == nil {
-			if ok := r.ReadMetric(ctx, instance, namespace, reward.Metric, metricsCache); !ok {
-				return ok
+			if err := r.readMetric(ctx, namespace, reward.Metric, metricsCache, nil); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -124,24 +240,34 @@ func (r *ExperimentReconciler) ReadMetrics(ctx context.Context, instance *v2alph
 	// indicators
 	for _, indicator := range criteria.Indicators {
 		if metricsCache[indicator] == nil {
-			if ok := r.ReadMetric(ctx, instance, namespace, indicator, metricsCache); !ok {
-				return ok
+			if err := r.readMetric(ctx, namespace, indicator, metricsCache, nil); err != nil {
+				return nil, err
 			}
 		}
 	}
 
 	for _, objective := range criteria.Objectives {
 		if metricsCache[objective.Metric] == nil {
-			if ok := r.ReadMetric(ctx, instance, namespace, objective.Metric, metricsCache); !ok {
-				return ok
+			if err := r.readMetric(ctx, namespace, objective.Metric, metricsCache, nil); err != nil {
+				return nil, err
+			}
+		}
+		// the metric of a guardrail is queried directly by the controller
+		if objective.IsGuardrail() {
+			key := objective.Metric
+			if !strings.Contains(key, "/") {
+				key = namespace + "/" + key
+			}
+			if err := validGuardrailMetric(key, metricsCache); err != nil {
+				return nil, err
 			}
 		}
 	}
 
-	// found all metrics; copy into instance.Status.Metrics
-	for name, obj := range metricsCache {
-		instance.Status.Metrics = append(instance.Status.Metrics,
-			v2alpha2.MetricInfo{Name: name, MetricObj: *obj})
+	// found all metrics
+	infos := []v2alpha2.MetricInfo{}
+	for _, info := range metricsCache {
+		infos = append(infos, *info)
 	}
-	return true
+	return infos, nil
 }
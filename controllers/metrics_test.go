@@ -244,3 +244,185 @@ var _ = Describe("Metrics", func() {
 		})
 	})
 })
+
+var _ = Describe("Cluster Metrics", func() {
+	var jqe string = "expr"
+	var url string = "url"
+	testNamespace := "default"
+
+	bldr := func(name string, namespace string, description string) *v2alpha2.MetricBuilder {
+		return v2alpha2.NewMetric(name, namespace).
+			WithDescription(description).
+			WithType(v2alpha2.CounterMetricType).
+			WithProvider("prometheus").
+			WithJQExpression(&jqe).
+			WithURLTemplate(&url)
+	}
+
+	BeforeEach(func() {
+		k8sClient.DeleteAllOf(ctx(), &v2alpha2.Metric{}, client.InNamespace(testNamespace))
+		k8sClient.DeleteAllOf(ctx(), &v2alpha2.ClusterMetric{})
+
+		By("Providing cluster metrics")
+		Expect(k8sClient.Create(ctx(), bldr("cluster-count", "", "cluster").BuildClusterMetric())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), bldr("shadowed-count", "", "cluster").BuildClusterMetric())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), bldr("cluster-latency", "", "cluster").WithSampleSize("shadowed-count").BuildClusterMetric())).Should(Succeed())
+		By("Providing a namespaced metric with the name of a cluster metric")
+		Expect(k8sClient.Create(ctx(), bldr("shadowed-count", testNamespace, "namespaced").Build())).Should(Succeed())
+	})
+
+	sourceOf := func(experiment *v2alpha2.Experiment, name string) (v2alpha2.MetricSourceType, string) {
+		for _, m := range experiment.Status.Metrics {
+			if m.Name == name {
+				return *m.Source, *m.MetricObj.Spec.Description
+			}
+		}
+		return "", ""
+	}
+
+	Context("When a metric is not found in the namespace of the experiment", func() {
+		It("the cluster metric is used", func() {
+			experiment := v2alpha2.NewExperiment("cluster-metric", testNamespace).
+				WithTarget("target").
+				WithRequestCount("cluster-count").
+				Build()
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeTrue())
+			source, _ := sourceOf(experiment, testNamespace+"/cluster-count")
+			Expect(source).To(Equal(v2alpha2.MetricSourceClusterMetric))
+		})
+	})
+
+	Context("When a namespaced metric has the name of a cluster metric", func() {
+		It("the namespaced metric is used, including for references from cluster metrics", func() {
+			experiment := v2alpha2.NewExperiment("shadowed-metric", testNamespace).
+				WithTarget("target").
+				WithRequestCount("cluster-count").
+				Build()
+			experiment.Spec.Criteria.Indicators = []string{"cluster-latency"}
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeTrue())
+			Expect(len(experiment.Status.Metrics)).To(Equal(3))
+			source, description := sourceOf(experiment, testNamespace+"/shadowed-count")
+			Expect(source).To(Equal(v2alpha2.MetricSourceMetric))
+			Expect(description).To(Equal("namespaced"))
+		})
+	})
+
+	Context("When a metric is referenced with a namespace", func() {
+		It("the cluster metric is not used", func() {
+			experiment := v2alpha2.NewExperiment("qualified-metric", testNamespace).
+				WithTarget("target").
+				WithRequestCount(testNamespace + "/cluster-count").
+				Build()
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("Derived Metric References", func() {
+	testNamespace := "default"
+
+	BeforeEach(func() {
+		k8sClient.DeleteAllOf(ctx(), &v2alpha2.Metric{}, client.InNamespace(testNamespace))
+
+		By("Providing derived metrics")
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("requests", testNamespace).Build())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("errors", testNamespace).Build())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("error-rate", testNamespace).WithExpression("e / r",
+			v2alpha2.MetricReference{Name: "e", Metric: "errors"},
+			v2alpha2.MetricReference{Name: "r", Metric: "requests"}).Build())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("unknown-reference", testNamespace).WithExpression("m",
+			v2alpha2.MetricReference{Name: "m", Metric: "missing"}).Build())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("cycle-a", testNamespace).WithExpression("b",
+			v2alpha2.MetricReference{Name: "b", Metric: "cycle-b"}).Build())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("cycle-b", testNamespace).WithExpression("a",
+			v2alpha2.MetricReference{Name: "a", Metric: "cycle-a"}).Build())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("bad-expression", testNamespace).WithExpression("e / r",
+			v2alpha2.MetricReference{Name: "e", Metric: "errors"}).Build())).Should(Succeed())
+	})
+
+	experimentWith := func(name string, indicator string) *v2alpha2.Experiment {
+		experiment := v2alpha2.NewExperiment(name, testNamespace).
+			WithTarget("target").
+			Build()
+		experiment.Spec.Criteria = &v2alpha2.Criteria{Indicators: []string{indicator}}
+		return experiment
+	}
+
+	Context("When a Derived metric references existing metrics", func() {
+		It("the references are read", func() {
+			experiment := experimentWith("derived-references", "error-rate")
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeTrue())
+			Expect(len(experiment.Status.Metrics)).To(Equal(3))
+		})
+	})
+
+	Context("When a Derived metric references a metric that does not exist", func() {
+		It("the metrics cannot be read", func() {
+			experiment := experimentWith("derived-unknown", "unknown-reference")
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeFalse())
+			Expect(containsSubString(events, "referenced by default/unknown-reference")).To(BeTrue())
+		})
+	})
+
+	Context("When Derived metrics reference each other", func() {
+		It("the cycle is detected", func() {
+			experiment := experimentWith("derived-cycle", "cycle-a")
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeFalse())
+			Expect(containsSubString(events, "default/cycle-a -> default/cycle-b -> default/cycle-a")).To(BeTrue())
+		})
+	})
+
+	Context("When the expression of a Derived metric uses an unknown reference", func() {
+		It("the metric is invalid", func() {
+			experiment := experimentWith("derived-invalid", "bad-expression")
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeFalse())
+			Expect(containsSubString(events, "unknown reference r in expression")).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("Histogram Metric Percentiles", func() {
+	testNamespace := "default"
+
+	BeforeEach(func() {
+		k8sClient.DeleteAllOf(ctx(), &v2alpha2.Metric{}, client.InNamespace(testNamespace))
+
+		By("Providing a histogram metric")
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("latency", testNamespace).
+			WithProvider(v2alpha2.ProviderFortio).WithPercentiles(50, 95).Build())).Should(Succeed())
+		Expect(k8sClient.Create(ctx(), v2alpha2.NewMetric("requests", testNamespace).Build())).Should(Succeed())
+	})
+
+	experimentWith := func(name string, indicator string) *v2alpha2.Experiment {
+		experiment := v2alpha2.NewExperiment(name, testNamespace).
+			WithTarget("target").
+			Build()
+		experiment.Spec.Criteria = &v2alpha2.Criteria{Indicators: []string{indicator}}
+		return experiment
+	}
+
+	Context("When a criterion references a percentile of a Histogram metric", func() {
+		It("the Histogram metric is read", func() {
+			experiment := experimentWith("percentile", "latency-p95")
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeTrue())
+			Expect(len(experiment.Status.Metrics)).To(Equal(1))
+			Expect(experiment.Status.Metrics[0].Name).To(Equal("default/latency"))
+		})
+	})
+
+	Context("When a criterion references a percentile that is not computed", func() {
+		It("the metrics cannot be read", func() {
+			experiment := experimentWith("percentile-missing", "latency-p99")
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeFalse())
+			Expect(containsSubString(events, "does not compute percentile 99")).To(BeTrue())
+		})
+	})
+
+	Context("When a criterion references a percentile of a metric that is not a Histogram metric", func() {
+		It("the metrics cannot be read", func() {
+			experiment := experimentWith("percentile-invalid", "requests-p50")
+			Expect(reconciler.ReadMetrics(ctx(), experiment)).Should(BeFalse())
+			Expect(containsSubString(events, "is not a Histogram metric")).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,284 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// providers.go - evaluation of metrics by the metric providers built into iter8
+//    - metrics that name a built-in provider are evaluated by the controller for each version
+//    - the values replace those reported by the analytics service
+//    - the version assessments of objectives on these metrics are recomputed from the values
+//    - for Histogram metrics, the distributions are recorded and the percentiles are computed from them
+
+package controllers
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/iter8-tools/etc3/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// builtinProvenance identifies analysis computed using the built-in metric providers
+const builtinProvenance = "iter8 controller (built-in metric providers)"
+
+// evaluateBuiltinMetrics evaluates the metrics that use a built-in provider and records the values in the analysis
+// A metric that cannot be evaluated for a version is logged and left without a value.
+func (r *ExperimentReconciler) evaluateBuiltinMetrics(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("evaluateBuiltinMetrics called")
+	defer log.Info("evaluateBuiltinMetrics completed")
+
+	if instance.Spec.VersionInfo == nil || instance.Status.Analysis == nil {
+		return
+	}
+	start, now := time.Now(), time.Now()
+	if instance.Status.StartTime != nil {
+		start = instance.Status.StartTime.Time
+	}
+
+	var builtinHists []byte
+	if instance.Status.Analysis.AggregatedBuiltinHists != nil {
+		builtinHists = instance.Status.Analysis.AggregatedBuiltinHists.Data.Raw
+	}
+
+	evaluated := map[string]map[string]float64{}
+	histograms := map[string]map[string][]metrics.Bucket{}
+	for _, info := range instance.Status.Metrics {
+		if !metrics.IsBuiltin(info.MetricObj.Spec) {
+			continue
+		}
+		secret, err := r.readMetricSecret(ctx, instance.Namespace, info.MetricObj)
+		if err != nil {
+			log.Error(err, "Unable to read secret of metric", "metric", info.Name)
+			continue
+		}
+		if info.MetricObj.Spec.IsHistogram() {
+			histograms[info.Name] = map[string][]metrics.Bucket{}
+			for _, p := range info.MetricObj.Spec.GetPercentiles() {
+				evaluated[v2alpha2.PercentileMetricName(info.Name, p)] = map[string]float64{}
+			}
+		} else {
+			evaluated[info.Name] = map[string]float64{}
+		}
+		for _, version := range versionDetails(instance) {
+			env := metricEnvironment(version, secret, start, now)
+			env.BuiltinHists = builtinHists
+			if info.MetricObj.Spec.IsHistogram() {
+				evaluateHistogram(ctx, info, env, histograms, evaluated)
+				continue
+			}
+			value, err := metrics.Evaluate(ctx, info.MetricObj, env)
+			if err != nil {
+				log.Error(err, "Unable to evaluate metric", "metric", info.Name, "version", version.Name)
+				continue
+			}
+			evaluated[info.Name][version.Name] = value
+		}
+	}
+	if len(evaluated) == 0 {
+		return
+	}
+
+	recordMetricValues(instance, evaluated, builtinProvenance)
+	recordHistograms(instance, histograms)
+	assessObjectives(instance, evaluated)
+}
+
+// evaluateHistogram evaluates a Histogram metric for a version and computes its percentiles
+func evaluateHistogram(ctx context.Context, info v2alpha2.MetricInfo, env metrics.Environment, histograms map[string]map[string][]metrics.Bucket, evaluated map[string]map[string]float64) {
+	log := Logger(ctx)
+	buckets, err := metrics.EvaluateHistogram(ctx, info.MetricObj, env)
+	if err != nil {
+		log.Error(err, "Unable to evaluate metric", "metric", info.Name, "version", env.Version)
+		return
+	}
+	histograms[info.Name][env.Version] = buckets
+	for _, p := range info.MetricObj.Spec.GetPercentiles() {
+		value, err := metrics.Percentile(buckets, float64(p))
+		if err != nil {
+			log.Error(err, "Unable to compute percentile", "metric", info.Name, "version", env.Version, "percentile", p)
+			continue
+		}
+		evaluated[v2alpha2.PercentileMetricName(info.Name, p)][env.Version] = value
+	}
+}
+
+// versionDetails returns the baseline followed by the candidates
+func versionDetails(instance *v2alpha2.Experiment) []v2alpha2.VersionDetail {
+	return append([]v2alpha2.VersionDetail{instance.Spec.VersionInfo.Baseline}, instance.Spec.VersionInfo.Candidates...)
+}
+
+// metricEnvironment returns the information about a version available to the built-in providers
+func metricEnvironment(version v2alpha2.VersionDetail, secret map[string][]byte, start time.Time, now time.Time) metrics.Environment {
+	env := metrics.Environment{
+		Version:   version.Name,
+		Variables: map[string]string{},
+		Secret:    secret,
+		StartTime: start,
+		Now:       now,
+	}
+	for _, v := range version.Variables {
+		env.Variables[v.Name] = v.Value
+	}
+	return env
+}
+
+// readMetricSecret reads the data of the secret referenced by a metric
+// The secret is named either "namespace/name" or "name"; in the latter case it is in the namespace of the metric.
+// A ClusterMetric has no namespace; like its references, its secret is resolved in the namespace of the experiment.
+func (r *ExperimentReconciler) readMetricSecret(ctx context.Context, namespace string, metric v2alpha2.Metric) (map[string][]byte, error) {
+	if metric.Spec.Secret == nil {
+		return nil, nil
+	}
+	if metric.Namespace != "" {
+		namespace = metric.Namespace
+	}
+	key := types.NamespacedName{Namespace: namespace, Name: *metric.Spec.Secret}
+	if splt := strings.Split(*metric.Spec.Secret, "/"); len(splt) == 2 {
+		key = types.NamespacedName{Namespace: splt[0], Name: splt[1]}
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+// recordMetricValues records the values of metrics evaluated by the controller in status.analysis.aggregatedMetrics
+// The provenance is recorded only if the controller creates the aggregated metrics; the provenance of metrics
+// returned by the analytics service is kept.
+func recordMetricValues(instance *v2alpha2.Experiment, evaluated map[string]map[string]float64, provenance string) {
+	analysis := instance.Status.Analysis
+	if analysis.AggregatedMetrics == nil {
+		analysis.AggregatedMetrics = &v2alpha2.AggregatedMetricsAnalysis{
+			AnalysisMetaData: v2alpha2.AnalysisMetaData{Provenance: provenance},
+		}
+	}
+	if analysis.AggregatedMetrics.Data == nil {
+		analysis.AggregatedMetrics.Data = map[string]v2alpha2.AggregatedMetricsData{}
+	}
+	for metric, values := range evaluated {
+		data := v2alpha2.AggregatedMetricsData{Data: map[string]v2alpha2.AggregatedMetricsVersionData{}}
+		for version, value := range values {
+			q, ok := quantity(value)
+			if !ok {
+				// treated as no data
+				continue
+			}
+			data.Data[version] = v2alpha2.AggregatedMetricsVersionData{Value: &q}
+		}
+		analysis.AggregatedMetrics.Data[metric] = data
+	}
+}
+
+// quantity converts a value computed by the controller to a quantity that can be recorded in the status
+// ok is false if the value is not finite (for example, NaN or +Inf) or cannot be represented as a quantity.
+func quantity(value float64) (resource.Quantity, bool) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return resource.Quantity{}, false
+	}
+	q, err := resource.ParseQuantity(strconv.FormatFloat(value, 'g', -1, 64))
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	return q, true
+}
+
+// recordHistograms records the distributions of Histogram metrics evaluated by the controller in status.analysis.aggregatedMetrics
+func recordHistograms(instance *v2alpha2.Experiment, histograms map[string]map[string][]metrics.Bucket) {
+	if len(histograms) == 0 {
+		return
+	}
+	data := instance.Status.Analysis.AggregatedMetrics.Data
+	for metric, versions := range histograms {
+		metricData := v2alpha2.AggregatedMetricsData{Data: map[string]v2alpha2.AggregatedMetricsVersionData{}}
+		for version, buckets := range versions {
+			histogram := []v2alpha2.HistogramBucket{}
+			for _, b := range buckets {
+				lower, lok := quantity(b.Lower)
+				count, cok := quantity(b.Count)
+				if !lok || !cok {
+					continue
+				}
+				bucket := v2alpha2.HistogramBucket{Lower: lower, Count: count}
+				if upper, ok := quantity(b.Upper); ok {
+					bucket.Upper = &upper
+				}
+				histogram = append(histogram, bucket)
+			}
+			metricData.Data[version] = v2alpha2.AggregatedMetricsVersionData{Histogram: histogram}
+		}
+		data[metric] = metricData
+	}
+}
+
+// assessObjectives recomputes the version assessments of the objectives on metrics evaluated by the controller
+// An objective is not satisfied by a version for which the metric could not be evaluated.
+func assessObjectives(instance *v2alpha2.Experiment, evaluated map[string]map[string]float64) {
+	if instance.Spec.Criteria == nil || len(instance.Spec.Criteria.Objectives) == 0 {
+		return
+	}
+	objectives := instance.Spec.Criteria.Objectives
+	analysis := instance.Status.Analysis
+	if analysis.VersionAssessments == nil {
+		analysis.VersionAssessments = &v2alpha2.VersionAssessmentAnalysis{}
+	}
+	if analysis.VersionAssessments.Data == nil {
+		analysis.VersionAssessments.Data = map[string]v2alpha2.BooleanList{}
+	}
+	for _, version := range versionDetails(instance) {
+		assessments := analysis.VersionAssessments.Data[version.Name]
+		if len(assessments) != len(objectives) {
+			assessments = make(v2alpha2.BooleanList, len(objectives))
+		}
+		for i, objective := range objectives {
+			values, ok := evaluated[metricInfoName(instance, objective.Metric)]
+			if !ok {
+				continue
+			}
+			value, ok := values[version.Name]
+			assessments[i] = ok && satisfiesObjective(objective, value)
+		}
+		analysis.VersionAssessments.Data[version.Name] = assessments
+	}
+}
+
+// metricInfoName returns the name under which a metric referenced by the criteria is recorded in status.metrics
+func metricInfoName(instance *v2alpha2.Experiment, metric string) string {
+	if strings.Contains(metric, "/") {
+		return metric
+	}
+	for _, info := range instance.Status.Metrics {
+		if info.Name == metric {
+			return metric
+		}
+	}
+	return instance.Namespace + "/" + metric
+}
+
+// satisfiesObjective returns true if the value is within the limits of the objective
+func satisfiesObjective(objective v2alpha2.Objective, value float64) bool {
+	if objective.UpperLimit != nil && value > objective.UpperLimit.AsApproximateFloat64() {
+		return false
+	}
+	if objective.LowerLimit != nil && value < objective.LowerLimit.AsApproximateFloat64() {
+		return false
+	}
+	return true
+}
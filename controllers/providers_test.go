@@ -0,0 +1,177 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Built-in Metric Providers", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "providers")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "latency.csv"), []byte("version,value\nv1,10\nv2,30\n"), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	bldr := func() (*v2alpha2.Experiment, *v2alpha2.Metric) {
+		latency := v2alpha2.NewMetric("latency", "default").
+			WithFile(v2alpha2.FileProvider{Path: filepath.Join(dir, "latency.csv")}).
+			Build()
+		other := v2alpha2.NewMetric("other", "default").Build()
+		upper := resource.MustParse("20")
+		experiment := v2alpha2.NewExperiment("providers", "default").
+			WithTarget("target").
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithObjective(*other, nil, nil, false).
+			WithObjective(*latency, &upper, nil, false).
+			Build()
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{
+			{Name: "default/latency", MetricObj: *latency},
+			{Name: "default/other", MetricObj: *other},
+		}
+		experiment.Status.Analysis = &v2alpha2.Analysis{
+			VersionAssessments: &v2alpha2.VersionAssessmentAnalysis{
+				Data: map[string]v2alpha2.BooleanList{"v1": {true, false}, "v2": {false, false}},
+			},
+		}
+		return experiment, latency
+	}
+
+	Context("When an experiment uses a metric with a built-in provider", func() {
+		It("the metric is evaluated for each version", func() {
+			experiment, _ := bldr()
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data
+			Expect(data).To(HaveKey("default/latency"))
+			Expect(data).ToNot(HaveKey("default/other"))
+			Expect(data["default/latency"].Data["v1"].Value.AsApproximateFloat64()).To(Equal(10.0))
+			Expect(data["default/latency"].Data["v2"].Value.AsApproximateFloat64()).To(Equal(30.0))
+			Expect(experiment.Status.Analysis.AggregatedMetrics.Provenance).To(Equal(builtinProvenance))
+		})
+		It("only the assessments of objectives on the metric are recomputed", func() {
+			experiment, _ := bldr()
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			assessments := experiment.Status.Analysis.VersionAssessments.Data
+			Expect(assessments["v1"]).To(Equal(v2alpha2.BooleanList{true, true}))
+			Expect(assessments["v2"]).To(Equal(v2alpha2.BooleanList{false, false}))
+		})
+		It("a version without a value does not satisfy the objective", func() {
+			experiment, _ := bldr()
+			Expect(ioutil.WriteFile(filepath.Join(dir, "latency.csv"), []byte("version,value\nv2,5\n"), 0644)).To(Succeed())
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			assessments := experiment.Status.Analysis.VersionAssessments.Data
+			Expect(assessments["v1"]).To(Equal(v2alpha2.BooleanList{true, false}))
+			Expect(assessments["v2"]).To(Equal(v2alpha2.BooleanList{false, true}))
+		})
+	})
+
+	Context("When an experiment uses a mocked metric", func() {
+		It("the values are generated from the mock levels", func() {
+			experiment, latency := bldr()
+			latency.Spec.Mock = []v2alpha2.NamedLevel{
+				{Name: "v1", Level: resource.MustParse("25")},
+				{Name: "v2", Level: resource.MustParse("15")},
+			}
+			experiment.Status.Metrics[0].MetricObj = *latency
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data
+			Expect(data["default/latency"].Data["v1"].Value.AsApproximateFloat64()).To(Equal(25.0))
+			Expect(data["default/latency"].Data["v2"].Value.AsApproximateFloat64()).To(Equal(15.0))
+			assessments := experiment.Status.Analysis.VersionAssessments.Data
+			Expect(assessments["v1"]).To(Equal(v2alpha2.BooleanList{true, false}))
+			Expect(assessments["v2"]).To(Equal(v2alpha2.BooleanList{false, true}))
+		})
+	})
+
+	Context("When a ClusterMetric references a secret by name", func() {
+		It("the secret is read in the namespace of the experiment", func() {
+			experiment, _ := bldr()
+			clusterMetric := v2alpha2.NewMetric("latency", "").
+				WithFile(v2alpha2.FileProvider{Path: filepath.Join(dir, "latency.csv")}).
+				WithSecret("creds").
+				BuildClusterMetric()
+			source := v2alpha2.MetricSourceClusterMetric
+			experiment.Status.Metrics[0] = v2alpha2.MetricInfo{Name: "default/latency", MetricObj: *clusterMetric.AsMetric(), Source: &source}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"},
+				Data:       map[string][]byte{"token": []byte("t0k3n")},
+			}
+			scheme := runtime.NewScheme()
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			Expect(v2alpha2.AddToScheme(scheme)).To(Succeed())
+			r := &ExperimentReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(clusterMetric, secret).Build()}
+
+			data, err := r.readMetricSecret(ctx(), experiment.Namespace, experiment.Status.Metrics[0].MetricObj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(HaveKeyWithValue("token", []byte("t0k3n")))
+			r.evaluateBuiltinMetrics(ctx(), experiment)
+			Expect(experiment.Status.Analysis.AggregatedMetrics.Data).To(HaveKey("default/latency"))
+		})
+	})
+
+	Context("When a metric does not configure its provider", func() {
+		It("the analysis is not changed", func() {
+			experiment, latency := bldr()
+			latency.Spec.File = nil
+			experiment.Status.Metrics[0].MetricObj = *latency
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			Expect(experiment.Status.Analysis.AggregatedMetrics).To(BeNil())
+		})
+	})
+
+	Context("When a value is not a finite number", func() {
+		It("the value is not recorded", func() {
+			experiment, _ := bldr()
+			recordMetricValues(experiment, map[string]map[string]float64{
+				"default/latency": {"v1": math.NaN(), "v2": 0.123456789},
+			}, builtinProvenance)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data
+			Expect(data["default/latency"].Data).ToNot(HaveKey("v1"))
+			// values are not truncated
+			Expect(data["default/latency"].Data["v2"].Value.String()).To(Equal("123456789n"))
+		})
+	})
+
+	Context("When the analytics service has returned aggregated metrics", func() {
+		It("their provenance is kept", func() {
+			experiment, _ := bldr()
+			experiment.Status.Analysis.AggregatedMetrics = &v2alpha2.AggregatedMetricsAnalysis{
+				AnalysisMetaData: v2alpha2.AnalysisMetaData{Provenance: "http://analytics"},
+			}
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			Expect(experiment.Status.Analysis.AggregatedMetrics.Data).To(HaveKey("default/latency"))
+			Expect(experiment.Status.Analysis.AggregatedMetrics.Provenance).To(Equal("http://analytics"))
+		})
+	})
+})
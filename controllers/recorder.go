@@ -56,6 +56,23 @@ func (r *ExperimentReconciler) recordTargetAcquired(ctx context.Context, instanc
 		v2alpha2.ReasonTargetAcquired, messageFormat, messageA...)
 }
 
+func (r *ExperimentReconciler) recordWeightsApplied(ctx context.Context, instance *v2alpha2.Experiment,
+	status corev1.ConditionStatus, reason string, messageFormat string, messageA ...interface{}) {
+	r.recordEvent(ctx, instance,
+		v2alpha2.ExperimentConditionWeightsApplied, status,
+		reason, messageFormat, messageA...)
+}
+
+// recordDryRun reports the patches that were not applied in a dry run
+func (r *ExperimentReconciler) recordDryRun(ctx context.Context, instance *v2alpha2.Experiment) {
+	if len(instance.Status.DryRunPatches) == 0 {
+		r.recordWeightsApplied(ctx, instance, corev1.ConditionFalse, v2alpha2.ReasonDryRun, "Dry run; no patches needed")
+		return
+	}
+	r.recordWeightsApplied(ctx, instance, corev1.ConditionFalse, v2alpha2.ReasonDryRun,
+		"Dry run; patches not applied: %s", describeDryRunPatches(instance.Status.DryRunPatches))
+}
+
 // record the event in a variety of ways. Note that we do not want to report an event more than once
 // in a log message, kubernetes event or notification. Consequently, we must pay attention to whether
 // or not we are recording an event for the first time or repeating it. We do this by first updating
@@ -0,0 +1,144 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// refresh.go - refresh of the metrics cached in status.metrics when the Metric objects change
+//    - active experiments are indexed by the keys of the metrics they use
+//    - a change to a Metric (or ClusterMetric) triggers reconciliation of the experiments that use it
+//    - the metrics are read again as defined by spec.criteria.metricsRefreshPolicy
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// metricsIndexKey is the name of the index of experiments by the metrics they use
+const metricsIndexKey = "status.metrics"
+
+// metricIndexValue returns the value by which experiments using a Metric or ClusterMetric are indexed
+// For a ClusterMetric, the namespace is empty.
+func metricIndexValue(namespace string, name string) string {
+	return namespace + "/" + name
+}
+
+// indexExperimentMetrics returns the index values of the metrics used by an active experiment
+// An experiment that uses a ClusterMetric is also indexed by the Metric that would shadow it.
+func indexExperimentMetrics(obj client.Object) []string {
+	instance, ok := obj.(*v2alpha2.Experiment)
+	if !ok || instance.Spec.GetMetricsRefreshPolicy() == v2alpha2.MetricsRefreshPolicyNever {
+		return nil
+	}
+	// GetCondition() adds missing conditions; objects from the cache must not be modified
+	for _, c := range instance.Status.Conditions {
+		if c.Type == v2alpha2.ExperimentConditionExperimentCompleted && c.Status == corev1.ConditionTrue {
+			return nil
+		}
+	}
+	values := []string{}
+	for _, info := range instance.Status.Metrics {
+		values = append(values, info.Name)
+		if info.Source != nil && *info.Source == v2alpha2.MetricSourceClusterMetric {
+			values = append(values, metricIndexValue("", info.MetricObj.Name))
+		}
+	}
+	return values
+}
+
+// metricToExperiments maps a Metric or ClusterMetric to reconcile requests for the active experiments that use it
+func (r *ExperimentReconciler) metricToExperiments(obj client.Object) []ctrl.Request {
+	experiments := &v2alpha2.ExperimentList{}
+	value := metricIndexValue(obj.GetNamespace(), obj.GetName())
+	if err := r.List(context.Background(), experiments, client.MatchingFields{metricsIndexKey: value}); err != nil {
+		r.Log.Error(err, "Unable to list experiments using metric", "metric", value)
+		return nil
+	}
+	requests := []ctrl.Request{}
+	for _, experiment := range experiments.Items {
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: experiment.Name, Namespace: experiment.Namespace},
+		})
+	}
+	return requests
+}
+
+// changedMetrics returns the names of the metrics in status.metrics whose definitions have changed since they were read
+// A metric that can no longer be read is not considered changed; the definition read earlier continues to be used.
+func (r *ExperimentReconciler) changedMetrics(ctx context.Context, instance *v2alpha2.Experiment) []string {
+	log := Logger(ctx)
+	if instance.Spec.GetMetricsRefreshPolicy() == v2alpha2.MetricsRefreshPolicyNever {
+		return nil
+	}
+	changed := []string{}
+	for _, info := range instance.Status.Metrics {
+		splt := strings.SplitN(info.Name, "/", 2)
+		metric := &v2alpha2.Metric{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: splt[0], Name: splt[1]}, metric)
+		if info.Source != nil && *info.Source == v2alpha2.MetricSourceClusterMetric {
+			if err == nil {
+				// a Metric now shadows the ClusterMetric
+				changed = append(changed, info.Name)
+				continue
+			}
+			clusterMetric := &v2alpha2.ClusterMetric{}
+			if err = r.Get(ctx, types.NamespacedName{Name: info.MetricObj.Name}, clusterMetric); err == nil {
+				metric = clusterMetric.AsMetric()
+			}
+		}
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				log.Error(err, "Unable to read metric", "metric", info.Name)
+			}
+			continue
+		}
+		if !equality.Semantic.DeepEqual(metric.Spec, info.MetricObj.Spec) {
+			changed = append(changed, info.Name)
+		}
+	}
+	return changed
+}
+
+// refreshMetrics reads the metrics again if any of them have changed
+// The result is true if the metrics were read again. If they cannot be read, the experiment is not failed;
+// the metrics read earlier continue to be used and the failure is reported once.
+func (r *ExperimentReconciler) refreshMetrics(ctx context.Context, instance *v2alpha2.Experiment) bool {
+	experiment := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+	changed := r.changedMetrics(ctx, instance)
+	if len(changed) == 0 {
+		r.refreshFailures.Delete(experiment)
+		return false
+	}
+	infos, err := r.loadMetrics(ctx, instance)
+	if err != nil {
+		// the failure is reported again only if it is different or the metric at fault has changed
+		failure := err.message + "@" + err.resourceVersion
+		if reported, ok := r.refreshFailures.Load(experiment); !ok || reported != failure {
+			r.refreshFailures.Store(experiment, failure)
+			r.recordExperimentProgress(ctx, instance, v2alpha2.ReasonMetricsUnreadable, "Metrics not refreshed after change to %s; using metrics read earlier: %s", strings.Join(changed, ", "), err.message)
+		}
+		return false
+	}
+	r.refreshFailures.Delete(experiment)
+	r.recordExperimentProgress(ctx, instance, v2alpha2.ReasonMetricsRefreshed, "Metrics refreshed after change to %s", strings.Join(changed, ", "))
+	instance.Status.Metrics = infos
+	return true
+}
@@ -0,0 +1,129 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics Refresh", func() {
+	metricSource, clusterMetricSource := v2alpha2.MetricSourceMetric, v2alpha2.MetricSourceClusterMetric
+
+	bldr := func(policy v2alpha2.MetricsRefreshPolicyType) *v2alpha2.Experiment {
+		requests := v2alpha2.NewMetric("requests", "default").WithDescription("requests").Build()
+		latency := v2alpha2.NewMetric("latency", "").WithDescription("latency").Build()
+		experiment := v2alpha2.NewExperiment("refresh", "default").
+			WithTarget("target").
+			WithIndicator(*requests).
+			WithMetricsRefreshPolicy(policy).
+			Build()
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{
+			{Name: "default/requests", MetricObj: *requests, Source: &metricSource},
+			{Name: "default/latency", MetricObj: *latency, Source: &clusterMetricSource},
+		}
+		return experiment
+	}
+
+	reconcilerWith := func(objs ...client.Object) *ExperimentReconciler {
+		scheme := runtime.NewScheme()
+		Expect(v2alpha2.AddToScheme(scheme)).To(Succeed())
+		return &ExperimentReconciler{
+			Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+			EventRecorder: record.NewFakeRecorder(10),
+		}
+	}
+
+	Context("When indexing an experiment", func() {
+		It("it is indexed by the metrics it uses", func() {
+			Expect(indexExperimentMetrics(bldr(v2alpha2.MetricsRefreshPolicyOnChange))).To(Equal([]string{"default/requests", "default/latency", "/latency"}))
+		})
+		It("it is not indexed if metrics are never refreshed", func() {
+			Expect(indexExperimentMetrics(bldr(v2alpha2.MetricsRefreshPolicyNever))).To(BeEmpty())
+		})
+		It("it is not indexed once completed", func() {
+			experiment := bldr(v2alpha2.MetricsRefreshPolicyOnChange)
+			experiment.Status.Conditions = []*v2alpha2.ExperimentCondition{{Type: v2alpha2.ExperimentConditionExperimentCompleted, Status: corev1.ConditionTrue}}
+			Expect(indexExperimentMetrics(experiment)).To(BeEmpty())
+		})
+	})
+
+	Context("When the metrics have not changed", func() {
+		It("no metric is changed", func() {
+			r := reconcilerWith(
+				v2alpha2.NewMetric("requests", "default").WithDescription("requests").Build(),
+				v2alpha2.NewMetric("latency", "").WithDescription("latency").BuildClusterMetric())
+			Expect(r.changedMetrics(ctx(), bldr(v2alpha2.MetricsRefreshPolicyOnChange))).To(BeEmpty())
+		})
+	})
+
+	Context("When a metric changes", func() {
+		It("the change is detected", func() {
+			r := reconcilerWith(
+				v2alpha2.NewMetric("requests", "default").WithDescription("fixed requests").Build(),
+				v2alpha2.NewMetric("latency", "").WithDescription("fixed latency").BuildClusterMetric())
+			Expect(r.changedMetrics(ctx(), bldr(v2alpha2.MetricsRefreshPolicyOnChange))).To(Equal([]string{"default/requests", "default/latency"}))
+		})
+		It("the change is ignored if metrics are never refreshed", func() {
+			r := reconcilerWith(v2alpha2.NewMetric("requests", "default").WithDescription("fixed requests").Build())
+			Expect(r.changedMetrics(ctx(), bldr(v2alpha2.MetricsRefreshPolicyNever))).To(BeEmpty())
+		})
+	})
+
+	Context("When a Metric shadows a ClusterMetric in use", func() {
+		It("the change is detected", func() {
+			r := reconcilerWith(
+				v2alpha2.NewMetric("requests", "default").WithDescription("requests").Build(),
+				v2alpha2.NewMetric("latency", "default").WithDescription("latency").Build())
+			Expect(r.changedMetrics(ctx(), bldr(v2alpha2.MetricsRefreshPolicyOnChange))).To(Equal([]string{"default/latency"}))
+		})
+	})
+
+	Context("When a changed metric cannot be read", func() {
+		It("the metrics read earlier continue to be used", func() {
+			r := reconcilerWith(v2alpha2.NewMetric("requests", "default").WithExpression("").Build())
+			experiment := bldr(v2alpha2.MetricsRefreshPolicyOnChange)
+			cached := experiment.DeepCopy().Status.Metrics
+			Expect(r.refreshMetrics(ctx(), experiment)).To(BeFalse())
+			Expect(experiment.Status.Metrics).To(Equal(cached))
+			Expect(experiment.Status.GetCondition(v2alpha2.ExperimentConditionExperimentFailed).IsTrue()).To(BeFalse())
+		})
+		It("the failure is reported once", func() {
+			r := reconcilerWith(v2alpha2.NewMetric("requests", "default").WithExpression("").Build())
+			recorder := r.EventRecorder.(*record.FakeRecorder)
+			experiment := bldr(v2alpha2.MetricsRefreshPolicyOnChange)
+			Expect(r.refreshMetrics(ctx(), experiment)).To(BeFalse())
+			// other progress is recorded between reconciles
+			experiment.Status.MarkCondition(v2alpha2.ExperimentConditionExperimentCompleted, corev1.ConditionFalse, v2alpha2.ReasonIterationCompleted, "iteration")
+			Expect(r.refreshMetrics(ctx(), experiment)).To(BeFalse())
+			Expect(recorder.Events).To(HaveLen(1))
+			Expect(<-recorder.Events).To(ContainSubstring(v2alpha2.ReasonMetricsUnreadable))
+		})
+	})
+
+	Context("When a metric is deleted", func() {
+		It("the metric read earlier continues to be used", func() {
+			r := reconcilerWith(v2alpha2.NewMetric("requests", "default").WithDescription("requests").Build())
+			Expect(r.changedMetrics(ctx(), bldr(v2alpha2.MetricsRefreshPolicyOnChange))).To(BeEmpty())
+		})
+	})
+})
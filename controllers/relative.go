@@ -0,0 +1,101 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// relative.go - objectives with limits relative to the baseline (spec.criteria.objectives[].relative)
+//    - the version assessments of the candidates are recomputed from the values of the metric: a candidate
+//      satisfies the objective if it satisfies both the absolute limits and the limits relative to the baseline
+//    - the baseline is assessed against the absolute limits only
+//    - a candidate does not satisfy the objective if the value for it or for the baseline is unavailable
+
+package controllers
+
+import (
+	"context"
+	"math"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// assessRelativeObjectives recomputes the version assessments of the candidates for objectives with relative limits
+func assessRelativeObjectives(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("assessRelativeObjectives called")
+	defer log.Info("assessRelativeObjectives completed")
+
+	if instance.Spec.Criteria == nil || instance.Spec.VersionInfo == nil || instance.Status.Analysis == nil {
+		return
+	}
+	objectives := instance.Spec.Criteria.Objectives
+	relative := false
+	for _, o := range objectives {
+		relative = relative || o.Relative != nil
+	}
+	if !relative {
+		return
+	}
+
+	analysis := instance.Status.Analysis
+	if analysis.VersionAssessments == nil {
+		analysis.VersionAssessments = &v2alpha2.VersionAssessmentAnalysis{}
+	}
+	if analysis.VersionAssessments.Data == nil {
+		analysis.VersionAssessments.Data = map[string]v2alpha2.BooleanList{}
+	}
+	baseline := instance.Spec.VersionInfo.Baseline.Name
+	for _, candidate := range instance.Spec.VersionInfo.Candidates {
+		assessments := analysis.VersionAssessments.Data[candidate.Name]
+		if len(assessments) != len(objectives) {
+			assessments = make(v2alpha2.BooleanList, len(objectives))
+		}
+		for i, objective := range objectives {
+			if objective.Relative == nil {
+				continue
+			}
+			key := metricInfoName(instance, objective.Metric)
+			b, bok := aggregatedValue(instance, key, baseline)
+			c, cok := aggregatedValue(instance, key, candidate.Name)
+			assessments[i] = bok && cok && satisfiesObjective(objective, c) && satisfiesRelativeLimits(*objective.Relative, c, b)
+		}
+		analysis.VersionAssessments.Data[candidate.Name] = assessments
+	}
+}
+
+// aggregatedValue returns the value of a metric for a version in status.analysis.aggregatedMetrics, if any
+func aggregatedValue(instance *v2alpha2.Experiment, metric string, version string) (float64, bool) {
+	am := instance.Status.Analysis.AggregatedMetrics
+	if am == nil {
+		return 0, false
+	}
+	data := am.Data[metric].Data[version]
+	if data.Value == nil {
+		return 0, false
+	}
+	return data.Value.AsApproximateFloat64(), true
+}
+
+// satisfiesRelativeLimits returns true if the value for a candidate is within the limits relative to the value for the baseline
+// Percentages are of the magnitude of the value for the baseline.
+func satisfiesRelativeLimits(limits v2alpha2.RelativeLimits, value float64, baseline float64) bool {
+	scale := 1.0
+	if limits.GetType() == v2alpha2.RelativeLimitPercent {
+		scale = math.Abs(baseline) / 100
+	}
+	if limits.UpperLimit != nil && value > baseline+scale*limits.UpperLimit.AsApproximateFloat64() {
+		return false
+	}
+	if limits.LowerLimit != nil && value < baseline-scale*limits.LowerLimit.AsApproximateFloat64() {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Relative Objectives", func() {
+	versionData := func(value string) v2alpha2.AggregatedMetricsVersionData {
+		v := resource.MustParse(value)
+		return v2alpha2.AggregatedMetricsVersionData{Value: &v}
+	}
+	quantity := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+
+	bldr := func(limits v2alpha2.RelativeLimits) *v2alpha2.Experiment {
+		latency := v2alpha2.NewMetric("latency", "default").Build()
+		errorRate := v2alpha2.NewMetric("error-rate", "default").Build()
+		experiment := v2alpha2.NewExperiment("relative", "default").
+			WithTarget("target").
+			WithTestingPattern(v2alpha2.TestingPatternABN).
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithCandidateVersion("v3", nil).
+			WithObjective(*errorRate, quantity("0.01"), nil, false).
+			WithRelativeObjective(*latency, limits, false).
+			Build()
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{
+			{Name: "default/latency", MetricObj: *latency},
+			{Name: "default/error-rate", MetricObj: *errorRate},
+		}
+		experiment.Status.Analysis = &v2alpha2.Analysis{
+			AggregatedMetrics: &v2alpha2.AggregatedMetricsAnalysis{
+				Data: map[string]v2alpha2.AggregatedMetricsData{
+					"default/latency": {Data: map[string]v2alpha2.AggregatedMetricsVersionData{
+						"v1": versionData("200"), "v2": versionData("215"), "v3": versionData("230"),
+					}},
+				},
+			},
+			VersionAssessments: &v2alpha2.VersionAssessmentAnalysis{
+				Data: map[string]v2alpha2.BooleanList{"v1": {true, true}, "v2": {false, true}, "v3": {true, true}},
+			},
+		}
+		return experiment
+	}
+
+	Context("When the limits are percentages of the baseline", func() {
+		It("candidates more than the percentage worse than the baseline fail the objective", func() {
+			experiment := bldr(v2alpha2.RelativeLimits{UpperLimit: quantity("10")})
+			assessRelativeObjectives(ctx(), experiment)
+			data := experiment.Status.Analysis.VersionAssessments.Data
+			Expect(data["v1"]).To(Equal(v2alpha2.BooleanList{true, true}))
+			Expect(data["v2"]).To(Equal(v2alpha2.BooleanList{false, true}))
+			Expect(data["v3"]).To(Equal(v2alpha2.BooleanList{true, false}))
+		})
+	})
+
+	Context("When the limits are differences from the baseline", func() {
+		It("candidates outside the differences fail the objective", func() {
+			delta := v2alpha2.RelativeLimitDelta
+			experiment := bldr(v2alpha2.RelativeLimits{Type: &delta, UpperLimit: quantity("10"), LowerLimit: quantity("0")})
+			assessRelativeObjectives(ctx(), experiment)
+			data := experiment.Status.Analysis.VersionAssessments.Data
+			Expect(data["v2"][1]).To(BeFalse())
+			Expect(data["v3"][1]).To(BeFalse())
+		})
+	})
+
+	Context("When the value for the baseline is unavailable", func() {
+		It("the candidates fail the objective", func() {
+			experiment := bldr(v2alpha2.RelativeLimits{UpperLimit: quantity("50")})
+			delete(experiment.Status.Analysis.AggregatedMetrics.Data["default/latency"].Data, "v1")
+			assessRelativeObjectives(ctx(), experiment)
+			data := experiment.Status.Analysis.VersionAssessments.Data
+			Expect(data["v1"][1]).To(BeTrue())
+			Expect(data["v2"][1]).To(BeFalse())
+			Expect(data["v3"][1]).To(BeFalse())
+		})
+	})
+
+	Context("When the baseline value is negative", func() {
+		It("percentages are of its magnitude", func() {
+			Expect(satisfiesRelativeLimits(v2alpha2.RelativeLimits{UpperLimit: quantity("10")}, -95, -100)).To(BeTrue())
+			Expect(satisfiesRelativeLimits(v2alpha2.RelativeLimits{UpperLimit: quantity("10")}, -85, -100)).To(BeFalse())
+			Expect(satisfiesRelativeLimits(v2alpha2.RelativeLimits{LowerLimit: quantity("10")}, -105, -100)).To(BeTrue())
+		})
+	})
+})
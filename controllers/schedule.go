@@ -0,0 +1,176 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// schedule.go implements spec.strategy.weights.schedule, a sequence of steps that bound
+// the total weight of the candidate versions
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// validWeightSchedule verifies that the steps of a weight schedule are increasing weights between 0 and 100
+func validWeightSchedule(s v2alpha2.ExperimentSpec) error {
+	if s.Strategy.Weights == nil || s.Strategy.Weights.Schedule == nil {
+		return nil
+	}
+	schedule := s.Strategy.Weights.Schedule
+	if len(schedule.Steps) == 0 && schedule.Ramp != nil {
+		if err := validWeightRamp(*schedule.Ramp); err != nil {
+			return err
+		}
+	}
+	steps := s.GetWeightSteps()
+	if len(steps) == 0 {
+		return errors.New("weight schedule has no steps")
+	}
+	previous := int32(-1)
+	for _, step := range steps {
+		if step < 0 || step > 100 {
+			return fmt.Errorf("weight schedule step %d is not between 0 and 100", step)
+		}
+		if step <= previous {
+			return errors.New("weight schedule steps must be increasing")
+		}
+		previous = step
+	}
+	return nil
+}
+
+// validWeightRamp verifies that a ramp generates increasing steps
+func validWeightRamp(r v2alpha2.WeightRamp) error {
+	if start := r.GetStart(); start < 1 || start > 100 {
+		return fmt.Errorf("weight ramp start %d is not between 1 and 100", start)
+	}
+	switch r.Type {
+	case v2alpha2.RampLinear:
+		if increment := r.GetIncrement(); increment < 1 || increment > 100 {
+			return fmt.Errorf("weight ramp increment %d is not between 1 and 100", increment)
+		}
+	case v2alpha2.RampExponential:
+		if factor := r.GetFactor(); factor < 2 || factor > 100 {
+			return fmt.Errorf("weight ramp factor %d is not between 2 and 100", factor)
+		}
+	default:
+		return fmt.Errorf("weight ramp type %s is not Linear or Exponential", r.Type)
+	}
+	return nil
+}
+
+// currentWeightStep returns the progress of an experiment through its weight schedule, initializing it if necessary
+// It returns nil if the experiment has no schedule.
+func currentWeightStep(instance *v2alpha2.Experiment) *v2alpha2.WeightScheduleStatus {
+	steps := instance.Spec.GetWeightSteps()
+	if len(steps) == 0 {
+		return nil
+	}
+	if instance.Status.WeightSchedule == nil || int(instance.Status.WeightSchedule.Step) >= len(steps) {
+		instance.Status.WeightSchedule = &v2alpha2.WeightScheduleStatus{
+			Step:               0,
+			MaxCandidateWeight: steps[0],
+		}
+	}
+	return instance.Status.WeightSchedule
+}
+
+// boundWeights reduces the recommended weights of the candidates, if necessary, so that their total
+// is no more than the current step of the weight schedule allows. The baseline receives the remainder.
+// The recommendation in status.analysis is modified so that it records the weights actually applied.
+func boundWeights(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("boundWeights called")
+	defer log.Info("boundWeights completed")
+
+	// a BlueGreen experiment cuts over all traffic at once
+	step := currentWeightStep(instance)
+	if step == nil || !shouldRedistribute(instance) || isBlueGreen(instance) || instance.Spec.VersionInfo == nil ||
+		instance.Status.Analysis == nil || instance.Status.Analysis.Weights == nil {
+		return
+	}
+
+	bounded := boundCandidateWeights(instance.Spec.VersionInfo.Baseline.Name, instance.Status.Analysis.Weights.Data, step.MaxCandidateWeight)
+	log.Info("boundWeights", "max candidate weight", step.MaxCandidateWeight, "recommended", instance.Status.Analysis.Weights.Data, "bounded", bounded)
+	instance.Status.Analysis.Weights.Data = bounded
+}
+
+// boundCandidateWeights scales down the weights of the candidates in proportion so that their total is at most max
+// The baseline receives the remainder so that the weights still add up to the same total.
+// If there is no weight for the baseline, the weights are not changed.
+func boundCandidateWeights(baseline string, weights []v2alpha2.WeightData, max int32) []v2alpha2.WeightData {
+	total := int32(0)
+	baselineIndex := -1
+	for i, w := range weights {
+		if w.Name == baseline {
+			baselineIndex = i
+			continue
+		}
+		total += w.Value
+	}
+	if total <= max || baselineIndex < 0 {
+		return weights
+	}
+
+	bounded := make([]v2alpha2.WeightData, len(weights))
+	candidateTotal := int32(0)
+	for i, w := range weights {
+		bounded[i] = w
+		if i == baselineIndex {
+			continue
+		}
+		bounded[i].Value = int32(int64(w.Value) * int64(max) / int64(total))
+		candidateTotal += bounded[i].Value
+	}
+	bounded[baselineIndex].Value = weights[baselineIndex].Value + total - candidateTotal
+	return bounded
+}
+
+// advanceWeightSchedule counts the iteration toward the dwell of the current step of the weight schedule
+// if the candidates have received the weight of the step. Once the candidates have done so for
+// spec.strategy.weights.schedule.dwellIterations iterations, the next step is allowed.
+func (r *ExperimentReconciler) advanceWeightSchedule(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("advanceWeightSchedule called")
+	defer log.Info("advanceWeightSchedule completed")
+
+	step := currentWeightStep(instance)
+	if step == nil || !shouldRedistribute(instance) || isBlueGreen(instance) || instance.Spec.VersionInfo == nil {
+		return
+	}
+
+	candidateWeight := int32(0)
+	for _, w := range instance.Status.CurrentWeightDistribution {
+		if w.Name != instance.Spec.VersionInfo.Baseline.Name {
+			candidateWeight += w.Value
+		}
+	}
+	if candidateWeight < step.MaxCandidateWeight {
+		log.Info("Candidates have not reached the weight of the current step", "weight", candidateWeight, "step", step)
+		return
+	}
+
+	step.Iterations++
+	steps := instance.Spec.GetWeightSteps()
+	if step.Iterations < instance.Spec.GetDwellIterations() || int(step.Step) >= len(steps)-1 {
+		return
+	}
+	step.Step++
+	step.MaxCandidateWeight = steps[step.Step]
+	step.Iterations = 0
+	r.recordExperimentProgress(ctx, instance, v2alpha2.ReasonWeightStepAdvanced, "Candidates may receive up to %d%% of traffic", step.MaxCandidateWeight)
+}
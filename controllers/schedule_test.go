@@ -0,0 +1,99 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Weight Schedules", func() {
+	bldr := func(schedule v2alpha2.WeightSchedule) *v2alpha2.ExperimentBuilder {
+		return v2alpha2.NewExperiment("schedule", "default").
+			WithTarget("target").
+			WithTestingPattern(v2alpha2.TestingPatternCanary).
+			WithDeploymentPattern(v2alpha2.DeploymentPatternProgressive).
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithWeightSchedule(schedule)
+	}
+
+	Context("When validating a schedule", func() {
+		It("steps must be increasing weights", func() {
+			Expect(validWeightSchedule(bldr(v2alpha2.WeightSchedule{Steps: []int32{5, 10, 25, 50, 100}}).Build().Spec)).To(Succeed())
+			Expect(validWeightSchedule(bldr(v2alpha2.WeightSchedule{Steps: []int32{5, 5, 100}}).Build().Spec)).ToNot(Succeed())
+			Expect(validWeightSchedule(bldr(v2alpha2.WeightSchedule{Steps: []int32{50, 150}}).Build().Spec)).ToNot(Succeed())
+			Expect(validWeightSchedule(bldr(v2alpha2.WeightSchedule{}).Build().Spec)).ToNot(Succeed())
+			Expect(validWeightSchedule(v2alpha2.NewExperiment("schedule", "default").Build().Spec)).To(Succeed())
+		})
+		It("a ramp must generate increasing steps", func() {
+			start, factor, increment := int32(2), int32(1073741824), int32(0)
+			exponential := v2alpha2.WeightRamp{Type: v2alpha2.RampExponential, Start: &start}
+			Expect(validWeightSchedule(bldr(v2alpha2.WeightSchedule{Ramp: &exponential}).Build().Spec)).To(Succeed())
+			exponential.Factor = &factor
+			Expect(validWeightSchedule(bldr(v2alpha2.WeightSchedule{Ramp: &exponential}).Build().Spec)).To(MatchError("weight ramp factor 1073741824 is not between 2 and 100"))
+			linear := v2alpha2.WeightRamp{Type: v2alpha2.RampLinear, Increment: &increment}
+			Expect(validWeightSchedule(bldr(v2alpha2.WeightSchedule{Ramp: &linear}).Build().Spec)).ToNot(Succeed())
+		})
+	})
+
+	Context("When the candidates are recommended more than the step allows", func() {
+		It("their weights are reduced in proportion and the baseline receives the remainder", func() {
+			weights := []v2alpha2.WeightData{{Name: "v1", Value: 40}, {Name: "v2", Value: 40}, {Name: "v3", Value: 20}}
+			Expect(boundCandidateWeights("v1", weights, 30)).To(Equal([]v2alpha2.WeightData{
+				{Name: "v1", Value: 70}, {Name: "v2", Value: 20}, {Name: "v3", Value: 10},
+			}))
+			Expect(boundCandidateWeights("v1", weights, 60)).To(Equal(weights))
+		})
+	})
+
+	Context("When an experiment iterates", func() {
+		var r *ExperimentReconciler
+		BeforeEach(func() {
+			r = &ExperimentReconciler{EventRecorder: record.NewFakeRecorder(10)}
+		})
+		dwell := int32(2)
+		It("the next step is allowed after the candidates dwell at the current step", func() {
+			experiment := bldr(v2alpha2.WeightSchedule{Steps: []int32{5, 25, 100}, DwellIterations: &dwell}).
+				WithRecommendedWeight("v1", 50).
+				WithRecommendedWeight("v2", 50).
+				Build()
+
+			boundWeights(ctx(), experiment)
+			Expect(experiment.Status.Analysis.Weights.Data).To(Equal([]v2alpha2.WeightData{{Name: "v1", Value: 95}, {Name: "v2", Value: 5}}))
+
+			// the candidate has not yet received the weight of the step
+			r.advanceWeightSchedule(ctx(), experiment)
+			Expect(*experiment.Status.WeightSchedule).To(Equal(v2alpha2.WeightScheduleStatus{Step: 0, MaxCandidateWeight: 5, Iterations: 0}))
+
+			experiment.Status.CurrentWeightDistribution = []v2alpha2.WeightData{{Name: "v1", Value: 95}, {Name: "v2", Value: 5}}
+			r.advanceWeightSchedule(ctx(), experiment)
+			Expect(*experiment.Status.WeightSchedule).To(Equal(v2alpha2.WeightScheduleStatus{Step: 0, MaxCandidateWeight: 5, Iterations: 1}))
+			r.advanceWeightSchedule(ctx(), experiment)
+			Expect(*experiment.Status.WeightSchedule).To(Equal(v2alpha2.WeightScheduleStatus{Step: 1, MaxCandidateWeight: 25, Iterations: 0}))
+		})
+		It("the last step is not exceeded", func() {
+			one := int32(1)
+			experiment := bldr(v2alpha2.WeightSchedule{Steps: []int32{5, 25}, DwellIterations: &one}).Build()
+			experiment.Status.CurrentWeightDistribution = []v2alpha2.WeightData{{Name: "v1", Value: 75}, {Name: "v2", Value: 25}}
+			experiment.Status.WeightSchedule = &v2alpha2.WeightScheduleStatus{Step: 1, MaxCandidateWeight: 25}
+			r.advanceWeightSchedule(ctx(), experiment)
+			Expect(experiment.Status.WeightSchedule.Step).To(Equal(int32(1)))
+		})
+	})
+})
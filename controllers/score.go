@@ -0,0 +1,216 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// score.go - assessment of the winner by a composite score when an experiment has several rewards
+//    - the values of each reward are normalized (spec.criteria.rewardNormalization) so that higher is better
+//    - the composite score of a version is the weighted mean of its normalized rewards
+//    - the winner is the version with the best composite score among the versions that satisfy all objectives
+//    - the scores and the normalized rewards of the versions are recorded in the winner assessment
+//    - the composite score is not assessed statistically; the winner is the best version at each iteration,
+//      and spec.criteria.strength may not be set
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// scoreProvenance identifies winner assessments computed from the composite score of several rewards
+const scoreProvenance = "iter8 controller (composite reward score)"
+
+// validRewards verifies that the weights of the rewards are not negative and not all zero
+// and that neither strength nor a bandit is required of the composite score of several rewards
+func validRewards(s v2alpha2.ExperimentSpec) error {
+	if s.Criteria == nil || len(s.Criteria.Rewards) == 0 {
+		return nil
+	}
+	if s.Criteria.Strength != nil && len(s.Criteria.Rewards) > 1 {
+		return errors.New("strength cannot be set when there are several rewards")
+	}
+	if s.GetBandit() != nil && len(s.Criteria.Rewards) > 1 {
+		return errors.New("bandit cannot be set when there are several rewards")
+	}
+	total := 0.0
+	for _, reward := range s.Criteria.Rewards {
+		if reward.GetWeight() < 0 {
+			return fmt.Errorf("weight of reward %s is negative", reward.Metric)
+		}
+		total += reward.GetWeight()
+	}
+	if total == 0 {
+		return errors.New("weights of the rewards are all zero")
+	}
+	return nil
+}
+
+// scoreRewards assesses the winner by the composite score of the rewards if there are several rewards
+func scoreRewards(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("scoreRewards called")
+	defer log.Info("scoreRewards completed")
+
+	criteria := instance.Spec.Criteria
+	if criteria == nil || len(criteria.Rewards) < 2 || instance.Spec.VersionInfo == nil || instance.Status.Analysis == nil {
+		return
+	}
+
+	assessment := &v2alpha2.WinnerAssessmentAnalysis{
+		AnalysisMetaData: v2alpha2.AnalysisMetaData{
+			Provenance: scoreProvenance,
+			Timestamp:  metav1.NewTime(time.Now()),
+		},
+	}
+	instance.Status.Analysis.WinnerAssessment = assessment
+
+	versions := []string{}
+	for _, version := range versionDetails(instance) {
+		if satisfiesObjectives(instance, version.Name) {
+			versions = append(versions, version.Name)
+		}
+	}
+	scores, err := compositeScores(instance, versions)
+	if err == nil && len(scores) == 0 {
+		err = errors.New("no version satisfies the objectives")
+	}
+	if err != nil {
+		log.Info("Unable to score rewards", "reason", err.Error())
+		message := err.Error()
+		assessment.Message = &message
+		return
+	}
+
+	assessment.Data.Scores = map[string]v2alpha2.RewardScore{}
+	winner := versions[0]
+	for _, version := range versions {
+		if scores[version].score > scores[winner].score {
+			winner = version
+		}
+		q, ok := quantity(scores[version].score)
+		if !ok {
+			continue
+		}
+		score := v2alpha2.RewardScore{
+			Score:   q,
+			Rewards: map[string]resource.Quantity{},
+		}
+		for metric, value := range scores[version].rewards {
+			if q, ok := quantity(value); ok {
+				score.Rewards[metric] = q
+			}
+		}
+		assessment.Data.Scores[version] = score
+	}
+	assessment.Data.WinnerFound = true
+	assessment.Data.Winner = &winner
+}
+
+// versionScore is the composite score of a version and its normalized rewards, by metric
+type versionScore struct {
+	score   float64
+	rewards map[string]float64
+}
+
+// compositeScores returns the composite scores of the versions
+func compositeScores(instance *v2alpha2.Experiment, versions []string) (map[string]versionScore, error) {
+	rewards := instance.Spec.Criteria.Rewards
+	scores := map[string]versionScore{}
+	for _, version := range versions {
+		scores[version] = versionScore{rewards: map[string]float64{}}
+	}
+	if len(versions) == 0 {
+		return scores, nil
+	}
+
+	totalWeight := 0.0
+	for _, reward := range rewards {
+		totalWeight += reward.GetWeight()
+	}
+	for _, reward := range rewards {
+		normalized, err := normalizeReward(instance, reward, versions)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			s := scores[version]
+			s.rewards[reward.Metric] = normalized[version]
+			s.score += reward.GetWeight() * normalized[version] / totalWeight
+			scores[version] = s
+		}
+	}
+	return scores, nil
+}
+
+// normalizeReward returns the normalized values of a reward for the versions; higher values are better
+// MinMax scales the values so that the worst version has 0 and the best has 1; if all values are equal, each is 1.
+// Baseline divides the improvement of each version over the baseline by the magnitude of the value of the baseline.
+func normalizeReward(instance *v2alpha2.Experiment, reward v2alpha2.Reward, versions []string) (map[string]float64, error) {
+	value := func(version string) (float64, error) {
+		var data v2alpha2.AggregatedMetricsVersionData
+		if am := instance.Status.Analysis.AggregatedMetrics; am != nil {
+			data = am.Data[metricInfoName(instance, reward.Metric)].Data[version]
+		}
+		if data.Value == nil {
+			return 0, fmt.Errorf("no value of reward %s for version %s", reward.Metric, version)
+		}
+		return data.Value.AsApproximateFloat64(), nil
+	}
+	sign := 1.0
+	if reward.PreferredDirection != v2alpha2.PreferredDirectionHigher {
+		sign = -1.0
+	}
+
+	values := map[string]float64{}
+	for _, version := range versions {
+		v, err := value(version)
+		if err != nil {
+			return nil, err
+		}
+		values[version] = sign * v
+	}
+
+	normalized := map[string]float64{}
+	switch instance.Spec.GetRewardNormalization() {
+	case v2alpha2.RewardNormalizationBaseline:
+		b, err := value(instance.Spec.VersionInfo.Baseline.Name)
+		if err != nil {
+			return nil, err
+		}
+		if b == 0 {
+			return nil, fmt.Errorf("value of reward %s for the baseline is 0", reward.Metric)
+		}
+		for version, v := range values {
+			normalized[version] = (v - sign*b) / math.Abs(b)
+		}
+	default:
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, v := range values {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+		for version, v := range values {
+			normalized[version] = 1
+			if max > min {
+				normalized[version] = (v - min) / (max - min)
+			}
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,159 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Composite Reward Score", func() {
+	versionData := func(value string) v2alpha2.AggregatedMetricsVersionData {
+		v := resource.MustParse(value)
+		return v2alpha2.AggregatedMetricsVersionData{Value: &v}
+	}
+
+	conversion := v2alpha2.NewMetric("conversion", "default").Build()
+	latency := v2alpha2.NewMetric("latency", "default").Build()
+	bldr := func() *v2alpha2.ExperimentBuilder {
+		return v2alpha2.NewExperiment("score", "default").
+			WithTarget("target").
+			WithTestingPattern(v2alpha2.TestingPatternABN).
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithCandidateVersion("v3", nil)
+	}
+	withAnalysis := func(experiment *v2alpha2.Experiment) *v2alpha2.Experiment {
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{
+			{Name: "default/conversion", MetricObj: *conversion},
+			{Name: "default/latency", MetricObj: *latency},
+		}
+		experiment.Status.Analysis = &v2alpha2.Analysis{
+			AggregatedMetrics: &v2alpha2.AggregatedMetricsAnalysis{
+				Data: map[string]v2alpha2.AggregatedMetricsData{
+					"default/conversion": {Data: map[string]v2alpha2.AggregatedMetricsVersionData{
+						"v1": versionData("0.10"), "v2": versionData("0.12"), "v3": versionData("0.14"),
+					}},
+					"default/latency": {Data: map[string]v2alpha2.AggregatedMetricsVersionData{
+						"v1": versionData("100"), "v2": versionData("50"), "v3": versionData("200"),
+					}},
+				},
+			},
+		}
+		return experiment
+	}
+	score := func(experiment *v2alpha2.Experiment, version string) float64 {
+		s := experiment.Status.Analysis.WinnerAssessment.Data.Scores[version].Score
+		return s.AsApproximateFloat64()
+	}
+
+	Context("When there are several rewards", func() {
+		It("the winner has the best composite score", func() {
+			experiment := withAnalysis(bldr().
+				WithReward(*conversion, v2alpha2.PreferredDirectionHigher).
+				WithWeightedReward(*latency, v2alpha2.PreferredDirectionLower, "3").
+				Build())
+			scoreRewards(ctx(), experiment)
+			assessment := experiment.Status.Analysis.WinnerAssessment
+			Expect(assessment.Provenance).To(Equal(scoreProvenance))
+			Expect(*experiment.Status.GetWinner()).To(Equal("v2"))
+			// v2 has the middle conversion and the best latency
+			Expect(score(experiment, "v2")).To(BeNumerically("~", (0.5+3)/4, 0.001))
+			Expect(score(experiment, "v3")).To(BeNumerically("~", 0.25, 0.001))
+			latencyScore := assessment.Data.Scores["v1"].Rewards["default/latency"]
+			Expect(latencyScore.AsApproximateFloat64()).To(BeNumerically("~", 2.0/3, 0.001))
+		})
+
+		It("the rewards may be normalized by the baseline", func() {
+			experiment := withAnalysis(bldr().
+				WithReward(*conversion, v2alpha2.PreferredDirectionHigher).
+				WithReward(*latency, v2alpha2.PreferredDirectionLower).
+				WithRewardNormalization(v2alpha2.RewardNormalizationBaseline).
+				Build())
+			scoreRewards(ctx(), experiment)
+			Expect(*experiment.Status.GetWinner()).To(Equal("v2"))
+			Expect(score(experiment, "v1")).To(BeNumerically("~", 0, 0.001))
+			Expect(score(experiment, "v2")).To(BeNumerically("~", (0.2+0.5)/2, 0.001))
+			Expect(score(experiment, "v3")).To(BeNumerically("~", (0.4-1)/2, 0.001))
+		})
+
+		It("versions that do not satisfy the objectives are not scored", func() {
+			experiment := withAnalysis(bldr().
+				WithReward(*conversion, v2alpha2.PreferredDirectionHigher).
+				WithWeightedReward(*latency, v2alpha2.PreferredDirectionLower, "3").
+				Build())
+			experiment.Status.Analysis.VersionAssessments = &v2alpha2.VersionAssessmentAnalysis{
+				Data: map[string]v2alpha2.BooleanList{"v1": {true}, "v2": {false}, "v3": {true}},
+			}
+			scoreRewards(ctx(), experiment)
+			Expect(*experiment.Status.GetWinner()).To(Equal("v1"))
+			Expect(experiment.Status.Analysis.WinnerAssessment.Data.Scores).ToNot(HaveKey("v2"))
+		})
+
+		It("there is no winner if a reward has no value", func() {
+			experiment := withAnalysis(bldr().
+				WithReward(*conversion, v2alpha2.PreferredDirectionHigher).
+				WithReward(*latency, v2alpha2.PreferredDirectionLower).
+				Build())
+			delete(experiment.Status.Analysis.AggregatedMetrics.Data["default/latency"].Data, "v3")
+			scoreRewards(ctx(), experiment)
+			Expect(experiment.Status.GetWinner()).To(BeNil())
+			Expect(*experiment.Status.Analysis.WinnerAssessment.Message).To(ContainSubstring("default/latency"))
+		})
+	})
+
+	Context("When there is one reward", func() {
+		It("the winner assessment is not changed", func() {
+			experiment := withAnalysis(bldr().WithReward(*conversion, v2alpha2.PreferredDirectionHigher).Build())
+			scoreRewards(ctx(), experiment)
+			Expect(experiment.Status.Analysis.WinnerAssessment).To(BeNil())
+		})
+	})
+
+	Context("When the rewards are validated", func() {
+		It("an A/B/N experiment may have several rewards with weights that are not negative", func() {
+			spec := bldr().
+				WithReward(*conversion, v2alpha2.PreferredDirectionHigher).
+				WithWeightedReward(*latency, v2alpha2.PreferredDirectionLower, "0").
+				Build().Spec
+			Expect(validNumberOfRewards(spec)).To(BeTrue())
+			Expect(validRewards(spec)).To(Succeed())
+			spec.Criteria.Rewards[0].Weight = spec.Criteria.Rewards[1].Weight
+			Expect(validRewards(spec)).ToNot(Succeed())
+			negative := resource.MustParse("-1")
+			spec.Criteria.Rewards[0].Weight = &negative
+			Expect(validRewards(spec)).ToNot(Succeed())
+		})
+		It("strength may not be set when there are several rewards", func() {
+			spec := bldr().
+				WithReward(*conversion, v2alpha2.PreferredDirectionHigher).
+				WithReward(*latency, v2alpha2.PreferredDirectionLower).
+				WithStrength(v2alpha2.Strength{}).
+				Build().Spec
+			Expect(validRewards(spec)).To(MatchError("strength cannot be set when there are several rewards"))
+		})
+		It("a bandit may not be set when there are several rewards", func() {
+			spec := bldr().
+				WithReward(*conversion, v2alpha2.PreferredDirectionHigher).
+				WithReward(*latency, v2alpha2.PreferredDirectionLower).
+				WithBandit(v2alpha2.BanditAlgorithmThompsonSampling).
+				Build().Spec
+			Expect(validRewards(spec)).To(MatchError("bandit cannot be set when there are several rewards"))
+		})
+	})
+})
@@ -0,0 +1,155 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// shadow.go implements the Shadow testing pattern:
+//    - the baseline receives all of the traffic
+//    - the traffic of the baseline is mirrored to the candidate, whose responses are discarded
+//    - mirroring is configured, with the weights, by the built-in weight adapter and removed when the experiment completes
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// shadowProvenance identifies weights determined by the controller for Shadow experiments
+const shadowProvenance = "iter8 controller (Shadow)"
+
+func isShadow(instance *v2alpha2.Experiment) bool {
+	return instance.Spec.Strategy.TestingPattern == v2alpha2.TestingPatternShadow
+}
+
+// validShadow verifies that mirroring can be configured for a Shadow experiment
+func validShadow(s v2alpha2.ExperimentSpec) error {
+	if s.Strategy.TestingPattern != v2alpha2.TestingPatternShadow {
+		return nil
+	}
+	backend := s.GetWeightBackend()
+	if backend == nil {
+		return errors.New("spec.strategy.weights.backend is required to configure mirroring")
+	}
+	adapter, err := getWeightAdapter(*backend)
+	if err != nil {
+		return err
+	}
+	if adapter.mirror == nil {
+		return fmt.Errorf("backend %s does not support mirroring", *backend)
+	}
+	if s.VersionInfo != nil {
+		for _, c := range s.VersionInfo.Candidates {
+			if c.WeightObjRef == nil {
+				return fmt.Errorf("candidate %s has no weightObjRef", c.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// shadowWeights replaces the recommended weights of a Shadow experiment so that all traffic is sent to the baseline
+func shadowWeights(ctx context.Context, instance *v2alpha2.Experiment) {
+	if !isShadow(instance) || instance.Spec.VersionInfo == nil {
+		return
+	}
+	setWeights(instance, singleVersionWeights(instance, instance.Spec.VersionInfo.Baseline.Name), shadowProvenance)
+}
+
+// addMirrorPatches adds the patches that mirror the traffic of the baseline to each candidate
+// The patches are applied, verified and rolled back together with the patches that set the weights.
+func addMirrorPatches(ctx context.Context, instance *v2alpha2.Experiment, restCfg *rest.Config, patchMap *map[corev1.ObjectReference][]patchValue) error {
+	log := Logger(ctx)
+	log.Info("addMirrorPatches called")
+	defer log.Info("addMirrorPatches completed")
+
+	adapter, err := mirrorAdapter(instance)
+	if err != nil {
+		return err
+	}
+	baseline := instance.Spec.VersionInfo.Baseline.GetBackend()
+	for _, c := range instance.Spec.VersionInfo.Candidates {
+		if c.WeightObjRef == nil {
+			continue
+		}
+		obj, err := readObject(ctx, c.WeightObjRef, instance.Namespace, restCfg)
+		if err != nil {
+			return err
+		}
+		patches, err := adapter.mirrorPatches(obj, baseline, c.GetBackend(), instance.Spec.GetMirrorPercent())
+		if err != nil {
+			return fmt.Errorf("unable to mirror traffic to %s: %s", c.Name, err.Error())
+		}
+		if len(patches) == 0 {
+			continue
+		}
+		log.Info("addMirrorPatches adding patch", "version", c.Name, "patch", patches)
+		key := getKey(*c.WeightObjRef)
+		(*patchMap)[key] = append((*patchMap)[key], patches...)
+	}
+	return nil
+}
+
+// removeMirroring stops mirroring traffic to the candidates of a Shadow experiment
+// Failure is logged; it does not change the outcome of the experiment.
+func (r *ExperimentReconciler) removeMirroring(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("removeMirroring called")
+	defer log.Info("removeMirroring completed")
+
+	if !isShadow(instance) || instance.Spec.VersionInfo == nil {
+		return
+	}
+	adapter, err := mirrorAdapter(instance)
+	if err != nil {
+		log.Error(err, "Unable to remove mirroring")
+		return
+	}
+
+	patchMap := map[corev1.ObjectReference][]patchValue{}
+	for _, c := range instance.Spec.VersionInfo.Candidates {
+		if c.WeightObjRef == nil {
+			continue
+		}
+		obj, err := readObject(ctx, c.WeightObjRef, instance.Namespace, r.RestConfig)
+		if err != nil {
+			log.Error(err, "Unable to remove mirroring", "version", c.Name)
+			continue
+		}
+		key := getKey(*c.WeightObjRef)
+		patchMap[key] = append(patchMap[key], adapter.unmirrorPatches(obj, c.GetBackend())...)
+	}
+	for key, patches := range patchMap {
+		if len(patches) == 0 {
+			delete(patchMap, key)
+		}
+	}
+	if len(patchMap) == 0 {
+		return
+	}
+	if _, err := applyPatches(ctx, instance, patchMap, r.RestConfig); err != nil {
+		log.Error(err, "Unable to remove mirroring")
+	}
+}
+
+// mirrorAdapter returns the built-in weight adapter used to configure mirroring
+func mirrorAdapter(instance *v2alpha2.Experiment) (*weightAdapter, error) {
+	if err := validShadow(instance.Spec); err != nil {
+		return nil, err
+	}
+	return getWeightAdapter(*instance.Spec.GetWeightBackend())
+}
@@ -0,0 +1,57 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	corev1 "k8s.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shadow Experiments", func() {
+	objRef := &corev1.ObjectReference{APIVersion: "networking.istio.io/v1beta1", Kind: "VirtualService", Name: "reviews"}
+	bldr := func() *v2alpha2.ExperimentBuilder {
+		return v2alpha2.NewExperiment("shadow", "default").
+			WithTarget("target").
+			WithTestingPattern(v2alpha2.TestingPatternShadow).
+			WithBaselineVersion("v1", objRef).
+			WithCandidateVersion("v2", objRef).
+			WithRecommendedWeight("v1", 50).
+			WithRecommendedWeight("v2", 50)
+	}
+
+	Context("When validating a Shadow experiment", func() {
+		It("a backend that supports mirroring is required", func() {
+			Expect(validShadow(bldr().Build().Spec)).ToNot(Succeed())
+			Expect(validShadow(bldr().WithWeightBackend(v2alpha2.WeightBackendTrafficSplit).Build().Spec)).ToNot(Succeed())
+			Expect(validShadow(bldr().WithWeightBackend(v2alpha2.WeightBackendVirtualService).Build().Spec)).To(Succeed())
+		})
+		It("other testing patterns are not affected", func() {
+			Expect(validShadow(bldr().WithTestingPattern(v2alpha2.TestingPatternCanary).Build().Spec)).To(Succeed())
+		})
+	})
+
+	Context("When the weights are determined", func() {
+		It("all traffic is sent to the baseline", func() {
+			experiment := bldr().WithWeightBackend(v2alpha2.WeightBackendVirtualService).Build()
+			Expect(shouldRedistribute(experiment)).To(BeTrue())
+			shadowWeights(ctx(), experiment)
+			Expect(experiment.Status.Analysis.Weights.Data).To(Equal([]v2alpha2.WeightData{{Name: "v1", Value: 100}, {Name: "v2", Value: 0}}))
+			Expect(experiment.Status.Analysis.Weights.Provenance).To(Equal(shadowProvenance))
+		})
+	})
+})
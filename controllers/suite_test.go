@@ -88,6 +88,10 @@ func (r testRecorder) AnnotatedEventf(object runtime.Object, annotations map[str
 
 type testJobManager struct {
 	jobs map[string]*batchv1.Job
+	// pods by job name
+	pods map[string][]corev1.Pod
+	// logs by pod name
+	logs map[string][]byte
 }
 
 func (j testJobManager) Get(ctx context.Context, ref types.NamespacedName, job *batchv1.Job) error {
@@ -102,6 +106,14 @@ func (j testJobManager) Get(ctx context.Context, ref types.NamespacedName, job *
 	return nil
 }
 
+func (j testJobManager) GetPods(ctx context.Context, job *batchv1.Job) ([]corev1.Pod, error) {
+	return j.pods[job.Name], nil
+}
+
+func (j testJobManager) GetPodLogs(ctx context.Context, pod *corev1.Pod, container string) ([]byte, error) {
+	return j.logs[pod.Name], nil
+}
+
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -180,8 +192,24 @@ var _ = BeforeSuite(func(done Done) {
 	Expect(err).Should(BeNil())
 	job := &batchv1.Job{}
 	Expect(yaml.Unmarshal(data, job)).Should(Succeed())
-	jobMgr := testJobManager{jobs: map[string]*batchv1.Job{}}
+	jobMgr := testJobManager{
+		jobs: map[string]*batchv1.Job{},
+		pods: map[string][]corev1.Pod{},
+		logs: map[string][]byte{},
+	}
 	jobMgr.jobs["iter8/has-failing-handler-start"] = job
+	jobMgr.jobs["iter8/default-has-retried-handler-start"] = job
+
+	path = filepath.Join("..", "test", "data", "failedpod.yaml")
+	data, err = ioutil.ReadFile(path)
+	Expect(err).Should(BeNil())
+	pod := corev1.Pod{}
+	Expect(yaml.Unmarshal(data, &pod)).Should(Succeed())
+	failedJob := job.DeepCopy()
+	failedJob.Name = "default-has-failure-details-start"
+	jobMgr.jobs["iter8/default-has-failure-details-start"] = failedJob
+	jobMgr.pods[failedJob.Name] = []corev1.Pod{pod}
+	jobMgr.logs[pod.Name] = []byte(`{"isIter8Log":true,"experimentName":"has-failure-details","experimentNamespace":"default","source":"task-runner","priority":1,"message":"notification to slack channel failed","precedence":0}`)
 
 	reconciler = &ExperimentReconciler{
 		Client:        k8sClient,
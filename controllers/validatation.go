@@ -28,6 +28,26 @@ import (
 // TODO 3. For ab and abn there is a reward
 // TODO 4. If rollbackOnFailure there is a rollback handler?
 func (r *ExperimentReconciler) IsExperimentValid(ctx context.Context, instance *v2alpha2.Experiment) bool {
+	// Verify that any weight schedule is valid
+	if err := validWeightSchedule(instance.Spec); err != nil {
+		r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonInvalidExperiment, "Invalid weight schedule: %s", err.Error())
+		return false
+	}
+	// Verify that mirroring can be configured for a Shadow experiment
+	if err := validShadow(instance.Spec); err != nil {
+		r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonInvalidExperiment, "Invalid Shadow experiment: %s", err.Error())
+		return false
+	}
+	// Verify that the rewards can be combined into a composite score
+	if err := validRewards(instance.Spec); err != nil {
+		r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonInvalidExperiment, "Invalid rewards: %s", err.Error())
+		return false
+	}
+	// Verify that the match rules of the versions can be configured
+	if err := validMatchRules(instance.Spec); err != nil {
+		r.recordExperimentFailed(ctx, instance, v2alpha2.ReasonInvalidExperiment, "Invalid match rules: %s", err.Error())
+		return false
+	}
 	return r.AreTasksValid(ctx, instance)
 }
 
@@ -81,7 +101,7 @@ func candidatesMatchStrategy(s v2alpha2.ExperimentSpec) bool {
 	switch s.Strategy.TestingPattern {
 	case v2alpha2.TestingPatternConformance:
 		return len(s.VersionInfo.Candidates) == 0
-	case v2alpha2.TestingPatternAB, v2alpha2.TestingPatternCanary:
+	case v2alpha2.TestingPatternAB, v2alpha2.TestingPatternCanary, v2alpha2.TestingPatternShadow:
 		return len(s.VersionInfo.Candidates) == 1
 	case v2alpha2.TestingPatternABN:
 		return len(s.VersionInfo.Candidates) > 0
@@ -125,10 +145,13 @@ func validNumberOfRewards(s v2alpha2.ExperimentSpec) bool {
 		return s.Criteria == nil || len(s.Criteria.Rewards) == 0
 	case v2alpha2.TestingPatternCanary:
 		return s.Criteria == nil || len(s.Criteria.Rewards) == 0
+	case v2alpha2.TestingPatternShadow:
+		// responses of the candidate are never seen by users so there is no reward
+		return s.Criteria == nil || len(s.Criteria.Rewards) == 0
 	case v2alpha2.TestingPatternAB:
-		return s.Criteria != nil && len(s.Criteria.Rewards) == 1
+		return s.Criteria != nil && len(s.Criteria.Rewards) > 0
 	case v2alpha2.TestingPatternABN:
-		return s.Criteria != nil && len(s.Criteria.Rewards) == 1
+		return s.Criteria != nil && len(s.Criteria.Rewards) > 0
 	}
 	return true
 }
@@ -202,6 +202,48 @@ var _ = Describe("Validation of VersionInfo", func() {
 		})
 	})
 
+	Context("Experiment is a Shadow test", func() {
+		var bldr *v2alpha2.ExperimentBuilder
+		BeforeEach(func() {
+			bldr = v2alpha2.NewExperiment("shadow-test", testNamespace).
+				WithTarget("target").
+				WithTestingPattern(v2alpha2.TestingPatternShadow)
+		})
+
+		It("should be invalid when exactly 1 version is specified", func() {
+			experiment := bldr.
+				WithBaselineVersion("baseline", nil).
+				Build()
+			Expect(reconciler.IsVersionInfoValid(ctx, experiment)).Should(BeFalse())
+		})
+
+		It("should be valid when exactly 2 versions are specified", func() {
+			experiment := bldr.
+				WithBaselineVersion("baseline", nil).
+				WithCandidateVersion("candidate-1", nil).
+				Build()
+			Expect(reconciler.IsVersionInfoValid(ctx, experiment)).Should(BeTrue())
+		})
+
+		It("should be invalid when more than 2 version are specified", func() {
+			experiment := bldr.
+				WithBaselineVersion("baseline", nil).
+				WithCandidateVersion("candidate-1", nil).
+				WithCandidateVersion("candidate-2", nil).
+				Build()
+			Expect(reconciler.IsVersionInfoValid(ctx, experiment)).Should(BeFalse())
+		})
+
+		It("should be invalid when there is a reward", func() {
+			experiment := bldr.
+				WithBaselineVersion("baseline", nil).
+				WithCandidateVersion("candidate-1", nil).
+				WithReward(*v2alpha2.NewMetric("metric", "default").WithJQExpression(&jqe).Build(), v2alpha2.PreferredDirectionHigher).
+				Build()
+			Expect(reconciler.IsVersionInfoValid(ctx, experiment)).Should(BeFalse())
+		})
+	})
+
 	Context("Experiment is an ABN test", func() {
 		var bldr *v2alpha2.ExperimentBuilder
 		BeforeEach(func() {
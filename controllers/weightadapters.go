@@ -0,0 +1,595 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// weightadapters.go - built-in logic to set and read version weights in well known traffic routing objects
+// The weights of these objects are inside lists of backends keyed by name so they cannot easily be
+// identified by a fieldpath.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// weightAdapter sets and reads the weights of versions in a kind of traffic routing object.
+// Each object has one or more routes; each route is a list of weighted backends.
+type weightAdapter struct {
+	// routes is the path to the list of routes; nil if the object has a single list of backends
+	routes []string
+	// backends is the path to the list of backends, relative to a route (or to the object if routes is nil)
+	backends []string
+	// matches determines if an entry in a list of backends is the backend of a version
+	matches func(entry map[string]interface{}, backend v2alpha2.VersionBackend) bool
+	// defaultWeight is the weight of a backend that does not specify one
+	defaultWeight int64
+	// mirror returns the patches that mirror the traffic of a route to a backend; nil if mirroring is not supported
+	// entry is the entry for the backend in the route, if there is one.
+	mirror func(r route, entry map[string]interface{}, backend v2alpha2.VersionBackend, percent int32) []patchValue
+	// unmirror returns the patches that stop mirroring the traffic of a route to a backend
+	unmirror func(r route, backend v2alpha2.VersionBackend) []patchValue
+	// matchRoute returns a route, with the given name, that sends the requests of a route that satisfy any of the
+	// conditions to a backend; nil if match rules are not supported
+	// entry is the entry for the backend in the route, if there is one.
+	matchRoute func(r route, conditions [][]headerCondition, entry map[string]interface{}, backend v2alpha2.VersionBackend, name string) map[string]interface{}
+}
+
+// weightAdapters are the built-in weight adapters
+var weightAdapters = map[v2alpha2.WeightBackendType]weightAdapter{
+	v2alpha2.WeightBackendHTTPRoute: {
+		routes:        []string{"spec", "rules"},
+		backends:      []string{"backendRefs"},
+		matches:       nameMatches("name"),
+		defaultWeight: 1,
+		mirror:        httpRouteMirror,
+		unmirror:      httpRouteUnmirror,
+		matchRoute:    httpRouteMatchRoute,
+	},
+	// Linkerd HTTPRoutes (policy.linkerd.io) have the same structure as Gateway API HTTPRoutes
+	// but do not support the RequestMirror filter
+	v2alpha2.WeightBackendLinkerd: {
+		routes:        []string{"spec", "rules"},
+		backends:      []string{"backendRefs"},
+		matches:       nameMatches("name"),
+		defaultWeight: 1,
+	},
+	v2alpha2.WeightBackendTrafficSplit: {
+		backends:      []string{"spec", "backends"},
+		matches:       nameMatches("service"),
+		defaultWeight: 0,
+	},
+	v2alpha2.WeightBackendVirtualService: {
+		routes:        []string{"spec", "http"},
+		backends:      []string{"route"},
+		matches:       destinationMatches,
+		defaultWeight: 0,
+		mirror:        virtualServiceMirror,
+		unmirror:      virtualServiceUnmirror,
+		matchRoute:    virtualServiceMatchRoute,
+	},
+}
+
+// getWeightAdapter returns the built-in adapter for a kind of traffic routing object
+func getWeightAdapter(backend v2alpha2.WeightBackendType) (*weightAdapter, error) {
+	adapter, ok := weightAdapters[backend]
+	if !ok {
+		return nil, fmt.Errorf("no weight adapter for backend %s", backend)
+	}
+	return &adapter, nil
+}
+
+// nameMatches matches backends using a name field
+func nameMatches(field string) func(map[string]interface{}, v2alpha2.VersionBackend) bool {
+	return func(entry map[string]interface{}, backend v2alpha2.VersionBackend) bool {
+		name, _ := entry[field].(string)
+		return name == backend.Name
+	}
+}
+
+// destinationMatches matches the destination host and, if specified, subset of a VirtualService route
+func destinationMatches(entry map[string]interface{}, backend v2alpha2.VersionBackend) bool {
+	destination, _ := entry["destination"].(map[string]interface{})
+	host, _ := destination["host"].(string)
+	if host != backend.Name {
+		return false
+	}
+	if backend.Subset == nil {
+		return true
+	}
+	subset, _ := destination["subset"].(string)
+	return subset == *backend.Subset
+}
+
+// route is a list of backends in an object together with its JSON pointer
+// rule and rulePointer identify the element of the list of routes that contains the backends, if any.
+type route struct {
+	pointer     string
+	backends    []interface{}
+	rule        map[string]interface{}
+	rulePointer string
+}
+
+// getRoutes returns the routes of an object
+// Routes created for match rules are not included; their backends receive all of their traffic regardless of the weights.
+func (a *weightAdapter) getRoutes(obj map[string]interface{}) []route {
+	if a.routes == nil {
+		backends, _ := nestedList(obj, a.backends)
+		return []route{{pointer: pointer(a.backends), backends: backends}}
+	}
+
+	result := []route{}
+	routes, _ := nestedList(obj, a.routes)
+	for i, r := range routes {
+		r, ok := r.(map[string]interface{})
+		if !ok || isMatchRoute(r) {
+			continue
+		}
+		backends, _ := nestedList(r, a.backends)
+		result = append(result, route{
+			pointer:     fmt.Sprintf("%s/%d%s", pointer(a.routes), i, pointer(a.backends)),
+			backends:    backends,
+			rule:        r,
+			rulePointer: fmt.Sprintf("%s/%d", pointer(a.routes), i),
+		})
+	}
+	return result
+}
+
+// patches returns the patches that set the weight of a backend in every route that includes it
+func (a *weightAdapter) patches(obj map[string]interface{}, backend v2alpha2.VersionBackend, weight int32) ([]patchIntValue, error) {
+	patches := []patchIntValue{}
+	for _, r := range a.getRoutes(obj) {
+		for j, b := range r.backends {
+			if b, ok := b.(map[string]interface{}); ok && a.matches(b, backend) {
+				patches = append(patches, patchIntValue{
+					Op:    "add",
+					Path:  fmt.Sprintf("%s/%d/weight", r.pointer, j),
+					Value: weight,
+				})
+			}
+		}
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("backend %s not found", backend.Name)
+	}
+	return patches, nil
+}
+
+// weight returns the percentage of traffic sent to a backend by the first route that includes it
+// Weights in routing objects are relative so the percentage is computed from all the weights in the route.
+func (a *weightAdapter) weight(obj map[string]interface{}, backend v2alpha2.VersionBackend) (int32, error) {
+	for _, r := range a.getRoutes(obj) {
+		found := false
+		var weight, total int64
+		for _, b := range r.backends {
+			b, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			w := a.defaultWeight
+			if v, ok := b["weight"]; ok {
+				if w, ok = toInt64(v); !ok {
+					return 0, fmt.Errorf("unexpected weight %v", v)
+				}
+			}
+			total += w
+			if !found && a.matches(b, backend) {
+				found, weight = true, w
+			}
+		}
+		if !found {
+			continue
+		}
+		if total == 0 {
+			// a single unweighted backend gets all of the traffic
+			if len(r.backends) == 1 {
+				return 100, nil
+			}
+			return 0, nil
+		}
+		return int32(weight * 100 / total), nil
+	}
+	return 0, fmt.Errorf("backend %s not found", backend.Name)
+}
+
+// mirrorPatches returns the patches that mirror the traffic of every route that includes the source backend to the target backend
+// No patches are returned for routes that already mirror their traffic as required.
+func (a *weightAdapter) mirrorPatches(obj map[string]interface{}, source v2alpha2.VersionBackend, target v2alpha2.VersionBackend, percent int32) ([]patchValue, error) {
+	if a.mirror == nil || a.routes == nil {
+		return nil, fmt.Errorf("mirroring is not supported")
+	}
+	patches := []patchValue{}
+	found := false
+	for _, r := range a.getRoutes(obj) {
+		var sourceEntry, targetEntry map[string]interface{}
+		for _, b := range r.backends {
+			if b, ok := b.(map[string]interface{}); ok {
+				if sourceEntry == nil && a.matches(b, source) {
+					sourceEntry = b
+				}
+				if targetEntry == nil && a.matches(b, target) {
+					targetEntry = b
+				}
+			}
+		}
+		if sourceEntry == nil {
+			continue
+		}
+		found = true
+		patches = append(patches, a.mirror(r, targetEntry, target, percent)...)
+	}
+	if !found {
+		return nil, fmt.Errorf("backend %s not found", source.Name)
+	}
+	return patches, nil
+}
+
+// unmirrorPatches returns the patches that stop mirroring traffic to a backend in every route
+func (a *weightAdapter) unmirrorPatches(obj map[string]interface{}, target v2alpha2.VersionBackend) []patchValue {
+	patches := []patchValue{}
+	if a.unmirror == nil || a.routes == nil {
+		return patches
+	}
+	for _, r := range a.getRoutes(obj) {
+		patches = append(patches, a.unmirror(r, target)...)
+	}
+	return patches
+}
+
+// httpRouteMirror adds (or updates) a RequestMirror filter to a Gateway API HTTPRoute rule
+// The backendRef of the filter is copied from the entry for the backend or from an existing filter, if there is one,
+// so that it has the right port.
+func httpRouteMirror(r route, entry map[string]interface{}, backend v2alpha2.VersionBackend, percent int32) []patchValue {
+	filters, _ := r.rule["filters"].([]interface{})
+
+	backendRef := map[string]interface{}{"name": backend.Name}
+	for _, f := range filters {
+		if isRequestMirror(f, backend) {
+			requestMirror, _ := f.(map[string]interface{})["requestMirror"].(map[string]interface{})
+			backendRef, _ = requestMirror["backendRef"].(map[string]interface{})
+			break
+		}
+	}
+	if entry != nil {
+		backendRef = map[string]interface{}{}
+		for k, v := range entry {
+			if k != "weight" && k != "filters" {
+				backendRef[k] = v
+			}
+		}
+	}
+	requestMirror := map[string]interface{}{"backendRef": backendRef}
+	if percent < 100 {
+		requestMirror["percent"] = percent
+	}
+	filter := map[string]interface{}{"type": "RequestMirror", "requestMirror": requestMirror}
+
+	updated := []interface{}{}
+	replaced := false
+	for _, f := range filters {
+		if isRequestMirror(f, backend) {
+			if !replaced {
+				updated = append(updated, filter)
+				replaced = true
+			}
+			continue
+		}
+		updated = append(updated, f)
+	}
+	if !replaced {
+		updated = append(updated, filter)
+	}
+	if sameJSON(filters, updated) {
+		return nil
+	}
+	// the whole list is replaced so that the patch can be undone
+	return []patchValue{{Op: "add", Path: r.rulePointer + "/filters", Value: updated}}
+}
+
+// httpRouteUnmirror removes any RequestMirror filter for a backend from a Gateway API HTTPRoute rule
+func httpRouteUnmirror(r route, backend v2alpha2.VersionBackend) []patchValue {
+	filters, _ := r.rule["filters"].([]interface{})
+	updated := []interface{}{}
+	for _, f := range filters {
+		if !isRequestMirror(f, backend) {
+			updated = append(updated, f)
+		}
+	}
+	if len(updated) == len(filters) {
+		return nil
+	}
+	if len(updated) == 0 {
+		return []patchValue{{Op: "remove", Path: r.rulePointer + "/filters"}}
+	}
+	return []patchValue{{Op: "add", Path: r.rulePointer + "/filters", Value: updated}}
+}
+
+// isRequestMirror determines if an HTTPRoute filter mirrors requests to a backend
+func isRequestMirror(filter interface{}, backend v2alpha2.VersionBackend) bool {
+	f, _ := filter.(map[string]interface{})
+	if t, _ := f["type"].(string); t != "RequestMirror" {
+		return false
+	}
+	requestMirror, _ := f["requestMirror"].(map[string]interface{})
+	backendRef, _ := requestMirror["backendRef"].(map[string]interface{})
+	return nameMatches("name")(backendRef, backend)
+}
+
+// virtualServiceMirror sets the mirror destination and percentage of an Istio VirtualService http route
+// The destination is copied from the entry for the backend or from the existing mirror, if there is one,
+// so that it has the right port.
+func virtualServiceMirror(r route, entry map[string]interface{}, backend v2alpha2.VersionBackend, percent int32) []patchValue {
+	destination, _ := entry["destination"].(map[string]interface{})
+	if mirror, ok := r.rule["mirror"].(map[string]interface{}); ok && destination == nil &&
+		destinationMatches(map[string]interface{}{"destination": mirror}, backend) {
+		destination = mirror
+	}
+	if destination == nil {
+		destination = map[string]interface{}{"host": backend.Name}
+		if backend.Subset != nil {
+			destination["subset"] = *backend.Subset
+		}
+	}
+	mirrorPercentage := map[string]interface{}{"value": percent}
+
+	patches := []patchValue{}
+	if !sameJSON(r.rule["mirror"], destination) {
+		patches = append(patches, patchValue{Op: "add", Path: r.rulePointer + "/mirror", Value: destination})
+	}
+	if !sameJSON(r.rule["mirrorPercentage"], mirrorPercentage) {
+		patches = append(patches, patchValue{Op: "add", Path: r.rulePointer + "/mirrorPercentage", Value: mirrorPercentage})
+	}
+	return patches
+}
+
+// virtualServiceUnmirror removes the mirror destination of an Istio VirtualService http route if it is the backend
+func virtualServiceUnmirror(r route, backend v2alpha2.VersionBackend) []patchValue {
+	mirror, ok := r.rule["mirror"].(map[string]interface{})
+	if !ok || !destinationMatches(map[string]interface{}{"destination": mirror}, backend) {
+		return nil
+	}
+	patches := []patchValue{{Op: "remove", Path: r.rulePointer + "/mirror"}}
+	if _, ok := r.rule["mirrorPercentage"]; ok {
+		patches = append(patches, patchValue{Op: "remove", Path: r.rulePointer + "/mirrorPercentage"})
+	}
+	return patches
+}
+
+// matchPatches returns the patches that add a route for each version with match rules in front of the other routes
+// A route is added for every route that includes the baseline; it selects the same requests as that route
+// that also satisfy the match rules of the version. Routes previously added for match rules are replaced.
+func (a *weightAdapter) matchPatches(obj map[string]interface{}, baseline v2alpha2.VersionBackend, versions []matchVersion) ([]patchValue, error) {
+	if a.matchRoute == nil || a.routes == nil {
+		return nil, fmt.Errorf("match rules are not supported")
+	}
+	routes, _ := nestedList(obj, a.routes)
+
+	added := []interface{}{}
+	for _, v := range versions {
+		n := 0
+		for _, r := range a.getRoutes(obj) {
+			var baselineEntry, entry map[string]interface{}
+			for _, b := range r.backends {
+				if b, ok := b.(map[string]interface{}); ok {
+					if baselineEntry == nil && a.matches(b, baseline) {
+						baselineEntry = b
+					}
+					if entry == nil && a.matches(b, v.backend) {
+						entry = b
+					}
+				}
+			}
+			if baselineEntry == nil {
+				continue
+			}
+			added = append(added, a.matchRoute(r, v.conditions, entry, v.backend, fmt.Sprintf("%s%s-%d", matchRoutePrefix, v.name, n)))
+			n++
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("backend %s not found", baseline.Name)
+		}
+	}
+	return a.replaceMatchRoutes(routes, added), nil
+}
+
+// unmatchPatches returns the patches that remove the routes added for match rules
+func (a *weightAdapter) unmatchPatches(obj map[string]interface{}) []patchValue {
+	if a.matchRoute == nil || a.routes == nil {
+		return nil
+	}
+	routes, _ := nestedList(obj, a.routes)
+	return a.replaceMatchRoutes(routes, []interface{}{})
+}
+
+// replaceMatchRoutes returns the patches that replace the routes added for match rules with new ones
+// The patch tests that the routes are unchanged since they were read so that no concurrent change is overwritten.
+func (a *weightAdapter) replaceMatchRoutes(routes []interface{}, added []interface{}) []patchValue {
+	updated := added
+	for _, r := range routes {
+		if m, ok := r.(map[string]interface{}); ok && isMatchRoute(m) {
+			continue
+		}
+		updated = append(updated, r)
+	}
+	if sameJSON(routes, updated) {
+		return nil
+	}
+	return []patchValue{
+		{Op: "test", Path: pointer(a.routes), Value: routes},
+		{Op: "add", Path: pointer(a.routes), Value: updated},
+	}
+}
+
+// httpRouteMatchRoute returns a Gateway API HTTPRoute rule for match rules
+// Rules are identified by name so the HTTPRoute must support named rules.
+func httpRouteMatchRoute(r route, conditions [][]headerCondition, entry map[string]interface{}, backend v2alpha2.VersionBackend, name string) map[string]interface{} {
+	rule := map[string]interface{}{}
+	for k, v := range r.rule {
+		if k != "name" && k != "matches" && k != "backendRefs" && k != "filters" {
+			rule[k] = v
+		}
+	}
+	rule["name"] = name
+
+	// the traffic of the version is not mirrored to itself
+	filters := []interface{}{}
+	original, _ := r.rule["filters"].([]interface{})
+	for _, f := range original {
+		if t, _ := f.(map[string]interface{})["type"].(string); t != "RequestMirror" {
+			filters = append(filters, f)
+		}
+	}
+	if len(filters) > 0 {
+		rule["filters"] = filters
+	}
+
+	matches := []interface{}{}
+	for _, m := range baseMatches(r.rule["matches"]) {
+		for _, c := range conditions {
+			match := copyMap(m)
+			headers, _ := match["headers"].([]interface{})
+			headers = append([]interface{}{}, headers...)
+			for _, h := range c {
+				headerType := string(v2alpha2.HeaderMatchExact)
+				if h.regex {
+					headerType = string(v2alpha2.HeaderMatchRegularExpression)
+				}
+				headers = append(headers, map[string]interface{}{"type": headerType, "name": h.name, "value": h.value})
+			}
+			match["headers"] = headers
+			matches = append(matches, match)
+		}
+	}
+	rule["matches"] = matches
+
+	backendRef := map[string]interface{}{"name": backend.Name}
+	if entry != nil {
+		backendRef = copyMap(entry)
+		delete(backendRef, "weight")
+	}
+	rule["backendRefs"] = []interface{}{backendRef}
+	return rule
+}
+
+// virtualServiceMatchRoute returns an Istio VirtualService http route for match rules
+func virtualServiceMatchRoute(r route, conditions [][]headerCondition, entry map[string]interface{}, backend v2alpha2.VersionBackend, name string) map[string]interface{} {
+	rule := map[string]interface{}{}
+	for k, v := range r.rule {
+		if k != "name" && k != "match" && k != "route" && k != "mirror" && k != "mirrorPercentage" {
+			rule[k] = v
+		}
+	}
+	rule["name"] = name
+
+	matches := []interface{}{}
+	for _, m := range baseMatches(r.rule["match"]) {
+		for _, c := range conditions {
+			match := copyMap(m)
+			original, _ := match["headers"].(map[string]interface{})
+			headers := copyMap(original)
+			for _, h := range c {
+				if h.regex {
+					headers[h.name] = map[string]interface{}{"regex": h.value}
+				} else {
+					headers[h.name] = map[string]interface{}{"exact": h.value}
+				}
+			}
+			match["headers"] = headers
+			matches = append(matches, match)
+		}
+	}
+	rule["match"] = matches
+
+	destination, _ := entry["destination"].(map[string]interface{})
+	if destination == nil {
+		destination = map[string]interface{}{"host": backend.Name}
+		if backend.Subset != nil {
+			destination["subset"] = *backend.Subset
+		}
+	}
+	rule["route"] = []interface{}{map[string]interface{}{"destination": destination}}
+	return rule
+}
+
+// baseMatches returns the match conditions of a route; a route without conditions matches every request
+func baseMatches(matches interface{}) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	list, _ := matches.([]interface{})
+	for _, m := range list {
+		if m, ok := m.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, map[string]interface{}{})
+	}
+	return result
+}
+
+// copyMap returns a shallow copy of a map
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// sameJSON determines if two values have the same JSON representation
+// Numbers read from JSON are float64 so values cannot be compared directly.
+func sameJSON(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// nestedList returns the list at a path in an object
+func nestedList(obj map[string]interface{}, path []string) ([]interface{}, bool) {
+	var current interface{} = obj
+	for _, field := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = m[field]
+	}
+	list, ok := current.([]interface{})
+	return list, ok
+}
+
+// pointer converts a path to a JSON pointer
+func pointer(path []string) string {
+	p := ""
+	for _, field := range path {
+		p += "/" + field
+	}
+	return p
+}
+
+// toInt64 converts a number read from JSON to an int64
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,247 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/ghodss/yaml"
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func toObject(y string) map[string]interface{} {
+	obj := map[string]interface{}{}
+	Expect(yaml.Unmarshal([]byte(y), &obj)).To(Succeed())
+	return obj
+}
+
+var _ = Describe("Weight Adapters", func() {
+	Context("When the backend is an HTTPRoute", func() {
+		obj := toObject(`
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+spec:
+  rules:
+  - matches:
+    - path:
+        value: /api
+    backendRefs:
+    - name: reviews-v1
+      port: 9080
+      weight: 75
+    - name: reviews-v2
+      port: 9080
+      weight: 25
+  - backendRefs:
+    - name: reviews-v1
+      port: 9080
+`)
+		adapter, err := getWeightAdapter(v2alpha2.WeightBackendHTTPRoute)
+		It("patches every rule that includes the backend", func() {
+			Expect(err).ToNot(HaveOccurred())
+			patches, err := adapter.patches(obj, v2alpha2.VersionBackend{Name: "reviews-v1"}, 40)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patches).To(Equal([]patchIntValue{
+				{Op: "add", Path: "/spec/rules/0/backendRefs/0/weight", Value: 40},
+				{Op: "add", Path: "/spec/rules/1/backendRefs/0/weight", Value: 40},
+			}))
+
+			_, err = adapter.patches(obj, v2alpha2.VersionBackend{Name: "reviews-v3"}, 40)
+			Expect(err).To(HaveOccurred())
+		})
+		It("reads the weight as a percentage of the first rule that includes the backend", func() {
+			w, err := adapter.weight(obj, v2alpha2.VersionBackend{Name: "reviews-v2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w).To(Equal(int32(25)))
+
+			_, err = adapter.weight(obj, v2alpha2.VersionBackend{Name: "reviews-v3"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When the backend is a TrafficSplit", func() {
+		obj := toObject(`
+apiVersion: split.smi-spec.io/v1alpha2
+kind: TrafficSplit
+spec:
+  service: reviews
+  backends:
+  - service: reviews-v1
+    weight: 3
+  - service: reviews-v2
+    weight: 1
+`)
+		adapter, _ := getWeightAdapter(v2alpha2.WeightBackendTrafficSplit)
+		It("patches and reads the backend", func() {
+			patches, err := adapter.patches(obj, v2alpha2.VersionBackend{Name: "reviews-v2"}, 60)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patches).To(Equal([]patchIntValue{{Op: "add", Path: "/spec/backends/1/weight", Value: 60}}))
+
+			w, err := adapter.weight(obj, v2alpha2.VersionBackend{Name: "reviews-v1"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w).To(Equal(int32(75)))
+		})
+	})
+
+	Context("When the backend is a VirtualService", func() {
+		obj := toObject(`
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+spec:
+  hosts:
+  - reviews
+  http:
+  - route:
+    - destination:
+        host: reviews
+        subset: v1
+      weight: 90
+    - destination:
+        host: reviews
+        subset: v2
+      weight: 10
+  - route:
+    - destination:
+        host: ratings
+`)
+		adapter, _ := getWeightAdapter(v2alpha2.WeightBackendVirtualService)
+		v2 := "v2"
+		It("matches destinations by host and subset", func() {
+			patches, err := adapter.patches(obj, v2alpha2.VersionBackend{Name: "reviews", Subset: &v2}, 30)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patches).To(Equal([]patchIntValue{{Op: "add", Path: "/spec/http/0/route/1/weight", Value: 30}}))
+
+			w, err := adapter.weight(obj, v2alpha2.VersionBackend{Name: "reviews", Subset: &v2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w).To(Equal(int32(10)))
+		})
+		It("a single unweighted destination gets all of the traffic", func() {
+			w, err := adapter.weight(obj, v2alpha2.VersionBackend{Name: "ratings"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w).To(Equal(int32(100)))
+		})
+	})
+
+	Context("When traffic is mirrored by an HTTPRoute", func() {
+		obj := toObject(`
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+spec:
+  rules:
+  - backendRefs:
+    - name: reviews-v1
+      port: 9080
+    - name: reviews-v2
+      port: 9080
+      weight: 0
+  - filters:
+    - type: RequestHeaderModifier
+    - type: RequestMirror
+      requestMirror:
+        backendRef:
+          name: reviews-v2
+          port: 9080
+    backendRefs:
+    - name: reviews-v1
+      port: 9080
+  - backendRefs:
+    - name: ratings
+`)
+		adapter, _ := getWeightAdapter(v2alpha2.WeightBackendHTTPRoute)
+		mirror := map[string]interface{}{
+			"type":          "RequestMirror",
+			"requestMirror": map[string]interface{}{"backendRef": map[string]interface{}{"name": "reviews-v2", "port": float64(9080)}},
+		}
+		It("a RequestMirror filter is added to every rule that routes to the baseline", func() {
+			patches, err := adapter.mirrorPatches(obj, v2alpha2.VersionBackend{Name: "reviews-v1"}, v2alpha2.VersionBackend{Name: "reviews-v2"}, 100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patches).To(HaveLen(1))
+			Expect(patches[0].Path).To(Equal("/spec/rules/0/filters"))
+			Expect(sameJSON(patches[0].Value, []interface{}{mirror})).To(BeTrue())
+		})
+		It("an existing RequestMirror filter is updated", func() {
+			patches, err := adapter.mirrorPatches(obj, v2alpha2.VersionBackend{Name: "reviews-v1"}, v2alpha2.VersionBackend{Name: "reviews-v2"}, 10)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patches).To(HaveLen(2))
+			Expect(patches[1].Path).To(Equal("/spec/rules/1/filters"))
+			Expect(patches[1].Value).To(HaveLen(2))
+		})
+		It("the RequestMirror filters are removed", func() {
+			patches := adapter.unmirrorPatches(obj, v2alpha2.VersionBackend{Name: "reviews-v2"})
+			Expect(patches).To(HaveLen(1))
+			Expect(patches[0].Path).To(Equal("/spec/rules/1/filters"))
+			Expect(sameJSON(patches[0].Value, []interface{}{map[string]interface{}{"type": "RequestHeaderModifier"}})).To(BeTrue())
+		})
+	})
+
+	Context("When traffic is mirrored by a VirtualService", func() {
+		obj := toObject(`
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+spec:
+  http:
+  - route:
+    - destination:
+        host: reviews
+        subset: v1
+      weight: 100
+    - destination:
+        host: reviews
+        subset: v2
+      weight: 0
+    mirror:
+      host: reviews
+      subset: v2
+`)
+		adapter, _ := getWeightAdapter(v2alpha2.WeightBackendVirtualService)
+		v1, v2 := "v1", "v2"
+		It("the mirror and its percentage are set", func() {
+			patches, err := adapter.mirrorPatches(obj, v2alpha2.VersionBackend{Name: "reviews", Subset: &v1}, v2alpha2.VersionBackend{Name: "reviews", Subset: &v2}, 50)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patches).To(Equal([]patchValue{
+				{Op: "add", Path: "/spec/http/0/mirrorPercentage", Value: map[string]interface{}{"value": int32(50)}},
+			}))
+		})
+		It("the mirror is removed", func() {
+			Expect(adapter.unmirrorPatches(obj, v2alpha2.VersionBackend{Name: "reviews", Subset: &v2})).To(Equal([]patchValue{
+				{Op: "remove", Path: "/spec/http/0/mirror"},
+			}))
+			Expect(adapter.unmirrorPatches(obj, v2alpha2.VersionBackend{Name: "reviews", Subset: &v1})).To(BeEmpty())
+		})
+	})
+
+	Context("When the backend does not support mirroring", func() {
+		It("mirroring fails", func() {
+			adapter, _ := getWeightAdapter(v2alpha2.WeightBackendTrafficSplit)
+			_, err := adapter.mirrorPatches(map[string]interface{}{}, v2alpha2.VersionBackend{Name: "v1"}, v2alpha2.VersionBackend{Name: "v2"}, 100)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When the backend of a version is not specified", func() {
+		It("the name of the version is used", func() {
+			version := v2alpha2.VersionDetail{Name: "reviews-v1"}
+			Expect(version.GetBackend()).To(Equal(v2alpha2.VersionBackend{Name: "reviews-v1"}))
+		})
+	})
+
+	Context("When the backend is unknown", func() {
+		It("there is no adapter", func() {
+			_, err := getWeightAdapter(v2alpha2.WeightBackendType("Unknown"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
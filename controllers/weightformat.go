@@ -0,0 +1,66 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// weightformat.go - translation between iter8 weights (integer percentages) and the value of weight fields
+// that use other units or types, as described by the weightFormat of a version
+
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// valuePrecision is the inverse of the smallest difference between field values that is preserved
+const valuePrecision float64 = 1e9
+
+// toFieldValue converts the weight of a version to the value of its weight field
+func toFieldValue(weight int32, version v2alpha2.VersionDetail) (interface{}, error) {
+	scale := version.GetWeightScale()
+	if scale <= 0 {
+		return nil, fmt.Errorf("invalid weight scale %v for version %s", scale, version.Name)
+	}
+	// avoid artifacts of floating point arithmetic such as 3 * 0.1 = 0.30000000000000004
+	value := math.Round(float64(weight)*scale*valuePrecision) / valuePrecision
+
+	switch version.GetWeightValueType() {
+	case v2alpha2.WeightValueNumber:
+		return value, nil
+	case v2alpha2.WeightValueString:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	default: // v2alpha2.WeightValueInteger
+		return int64(math.Round(value)), nil
+	}
+}
+
+// fromFieldValue converts the value of the weight field of a version, as read using its fieldpath, to a weight
+// The weight is rounded to the nearest integer percentage.
+func fromFieldValue(value string, version v2alpha2.VersionDetail) (int32, error) {
+	scale := version.GetWeightScale()
+	if scale <= 0 {
+		return 0, fmt.Errorf("invalid weight scale %v for version %s", scale, version.Name)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("weight of version %s is not a number: %s", version.Name, value)
+	}
+	weight := math.Round(f / scale)
+	if weight < math.MinInt32 || weight > math.MaxInt32 {
+		return 0, fmt.Errorf("weight of version %s is out of range: %s", version.Name, value)
+	}
+	return int32(weight), nil
+}
@@ -0,0 +1,80 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func formattedVersion(valueType v2alpha2.WeightValueType, scale string) v2alpha2.VersionDetail {
+	format := &v2alpha2.WeightFormat{ValueType: &valueType}
+	if scale != "" {
+		q := resource.MustParse(scale)
+		format.Scale = &q
+	}
+	return v2alpha2.VersionDetail{Name: "v1", WeightFormat: format}
+}
+
+var _ = Describe("Weight Formats", func() {
+	Context("When no format is specified", func() {
+		It("weights are integer percentages", func() {
+			version := v2alpha2.VersionDetail{Name: "v1"}
+			Expect(toFieldValue(30, version)).To(Equal(int64(30)))
+			Expect(fromFieldValue("30", version)).To(Equal(int32(30)))
+		})
+	})
+
+	Context("When the weight is a fraction", func() {
+		version := formattedVersion(v2alpha2.WeightValueNumber, "0.01")
+		It("weights are converted to and from fractions", func() {
+			Expect(toFieldValue(30, version)).To(Equal(0.3))
+			Expect(toFieldValue(100, version)).To(Equal(1.0))
+			Expect(fromFieldValue("0.3", version)).To(Equal(int32(30)))
+			Expect(fromFieldValue("0.333", version)).To(Equal(int32(33)))
+		})
+	})
+
+	Context("When the weight is an integer in other units", func() {
+		version := formattedVersion(v2alpha2.WeightValueInteger, "10")
+		It("weights are scaled", func() {
+			Expect(toFieldValue(25, version)).To(Equal(int64(250)))
+			Expect(fromFieldValue("255", version)).To(Equal(int32(26)))
+		})
+	})
+
+	Context("When the weight is a string", func() {
+		version := formattedVersion(v2alpha2.WeightValueString, "0.01")
+		It("weights are encoded as decimal numbers", func() {
+			Expect(toFieldValue(5, version)).To(Equal("0.05"))
+			Expect(fromFieldValue("0.05", version)).To(Equal(int32(5)))
+			_, err := fromFieldValue("five", version)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When the scale is not positive", func() {
+		version := formattedVersion(v2alpha2.WeightValueInteger, "0")
+		It("weights cannot be converted", func() {
+			_, err := toFieldValue(5, version)
+			Expect(err).To(HaveOccurred())
+			_, err = fromFieldValue("5", version)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
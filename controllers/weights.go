@@ -22,6 +22,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -38,6 +40,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	jp "k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/retry"
 )
 
 func shouldRedistribute(instance *v2alpha2.Experiment) bool {
@@ -45,6 +48,10 @@ func shouldRedistribute(instance *v2alpha2.Experiment) bool {
 	if experimentType == v2alpha2.TestingPatternConformance {
 		return false
 	}
+	// the weights of a Shadow experiment are fixed but they are applied together with the mirroring configuration
+	if experimentType == v2alpha2.TestingPatternShadow {
+		return true
+	}
 	algorithm := instance.Spec.GetDeploymentPattern()
 	return algorithm != v2alpha2.DeploymentPatternFixedSplit
 }
@@ -65,31 +72,100 @@ func redistributeWeight(ctx context.Context, instance *v2alpha2.Experiment, rest
 	}
 
 	// For each version, get the patch to apply
-	// Add to a map of Object --> []patchIntValue
+	// Add to a map of Object --> []patchValue
 	// Map keys are the kubernetes objects to be modified; values are a list of patches to apply
-	patches := map[corev1.ObjectReference][]patchIntValue{}
-	if err := addPatch(ctx, instance, instance.Spec.VersionInfo.Baseline, &patches); err != nil {
+	patches := map[corev1.ObjectReference][]patchValue{}
+	if err := addPatch(ctx, instance, instance.Spec.VersionInfo.Baseline, restCfg, &patches); err != nil {
 		return err
 	}
 	for _, version := range instance.Spec.VersionInfo.Candidates {
-		if err := addPatch(ctx, instance, version, &patches); err != nil {
+		if err := addPatch(ctx, instance, version, restCfg, &patches); err != nil {
+			return err
+		}
+	}
+	if isShadow(instance) {
+		if err := addMirrorPatches(ctx, instance, restCfg, &patches); err != nil {
 			return err
 		}
 	}
 
+	if len(patches) == 0 {
+		return nil
+	}
+
+	// verify the recommendation is a complete distribution before changing anything
+	if err := validateRecommendedWeights(instance); err != nil {
+		return &weightsNotAppliedError{err: err}
+	}
+
 	// go through map and apply the list of patches to the objects
-	for obj, p := range patches {
-		_, err := patchWeight(ctx, &obj, p, instance.Namespace, restCfg)
-		log.Info("redistributeWeight", "err", err)
-		if err != nil {
-			log.Error(err, "Unable to patch", "object", obj, "patch", p)
+	return applyWeights(ctx, instance, patches, instance.Status.Analysis.Weights.Data, restCfg)
+}
+
+// applyWeights applies patches to set the weights of the versions and verifies that they take effect
+// If any object cannot be patched or the weights do not take effect, the patches already applied are undone.
+func applyWeights(ctx context.Context, instance *v2alpha2.Experiment, patches map[corev1.ObjectReference][]patchValue, weights []v2alpha2.WeightData, restCfg *rest.Config) error {
+	log := Logger(ctx)
+
+	applied, err := applyPatches(ctx, instance, patches, restCfg)
+	// in a dry run, the weights are not expected to change
+	if err == nil && !instance.Spec.GetDryRun() {
+		err = verifyWeights(ctx, instance, weights, restCfg)
+	}
+	if err != nil {
+		log.Error(err, "Unable to apply weights; rolling back", "patches", patches)
+		if rollbackErr := rollbackPatches(ctx, applied, instance.Namespace, restCfg); rollbackErr != nil {
+			err = fmt.Errorf("%s; rollback failed: %s", err.Error(), rollbackErr.Error())
 		}
+		return &weightsNotAppliedError{err: err}
 	}
 
 	return nil
 }
 
-func addPatch(ctx context.Context, instance *v2alpha2.Experiment, version v2alpha2.VersionDetail, patcheMap *map[corev1.ObjectReference][]patchIntValue) error {
+// weightsNotAppliedError indicates that the recommended weights could not be applied to the cluster.
+// Unlike other errors from redistributeWeight, it does not mean the experiment is invalid.
+type weightsNotAppliedError struct {
+	err error
+}
+
+func (e *weightsNotAppliedError) Error() string {
+	return e.err.Error()
+}
+
+// validateRecommendedWeights verifies that each version has a recommended weight between 0 and 100
+// and that the recommended weights add up to 100
+func validateRecommendedWeights(instance *v2alpha2.Experiment) error {
+	if instance.Status.Analysis == nil || instance.Status.Analysis.Weights == nil {
+		return errors.New("no weight recommendation provided")
+	}
+	total := int32(0)
+	for _, version := range versionNames(instance) {
+		weight := getWeightRecommendation(version, instance.Status.Analysis.Weights.Data)
+		if weight == nil {
+			return fmt.Errorf("no weight recommendation for version %s", version)
+		}
+		if *weight < 0 || *weight > 100 {
+			return fmt.Errorf("invalid weight recommendation %d for version %s", *weight, version)
+		}
+		total += *weight
+	}
+	if total != 100 {
+		return fmt.Errorf("recommended weights add up to %d, not 100", total)
+	}
+	return nil
+}
+
+// versionNames returns the names of the baseline and candidate versions
+func versionNames(instance *v2alpha2.Experiment) []string {
+	names := []string{instance.Spec.VersionInfo.Baseline.Name}
+	for _, c := range instance.Spec.VersionInfo.Candidates {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func addPatch(ctx context.Context, instance *v2alpha2.Experiment, version v2alpha2.VersionDetail, restCfg *rest.Config, patcheMap *map[corev1.ObjectReference][]patchValue) error {
 	log := Logger(ctx)
 	//log.Info("addPatch called", "weight recommendations", instance.Status.Analysis.Weights)
 	defer log.Info("addPatch completed")
@@ -100,7 +176,9 @@ func addPatch(ctx context.Context, instance *v2alpha2.Experiment, version v2alph
 		return nil
 	}
 	// verify that the field path is present; again, it might not be -- only n-1 MUST be
-	if version.WeightObjRef.FieldPath == "" {
+	// a field path is not needed if a built-in weight adapter is used
+	backend := instance.Spec.GetWeightBackend()
+	if backend == nil && version.WeightObjRef.FieldPath == "" {
 		log.Info("Unable to update weight; no field specified", "version", version)
 		return nil
 	}
@@ -124,28 +202,63 @@ func addPatch(ctx context.Context, instance *v2alpha2.Experiment, version v2alph
 		return nil
 	}
 
+	// create patch(es)
+	patches, err := versionPatches(ctx, instance, version, *weight, restCfg)
+	if err != nil {
+		return err
+	}
+
+	log.Info("addPatch adding patch", "patch", patches)
+
+	// add patch to patchMap
+	key := getKey(*version.WeightObjRef)
+	(*patcheMap)[key] = append((*patcheMap)[key], patches...)
+
+	return nil
+}
+
+// versionPatches returns the patches that set the weight of a version
+func versionPatches(ctx context.Context, instance *v2alpha2.Experiment, version v2alpha2.VersionDetail, weight int32, restCfg *rest.Config) ([]patchValue, error) {
+	if backend := instance.Spec.GetWeightBackend(); backend != nil {
+		patches, err := backendPatches(ctx, instance, version, *backend, weight, restCfg)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]patchValue, len(patches))
+		for i, p := range patches {
+			result[i] = patchValue{Op: p.Op, Path: p.Path, Value: p.Value}
+		}
+		return result, nil
+	}
+
 	path := strings.Replace(version.WeightObjRef.FieldPath, "[", "/", -1)
 	path = strings.Replace(path, "].", "/", -1)
 	path = strings.Replace(path, ".", "/", -1)
 
-	// create patch
-	patch := patchIntValue{
-		Op:    "add",
-		Path:  path,
-		Value: *weight,
+	// the weight is written in the format expected by the field
+	value, err := toFieldValue(weight, version)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Info("addPatch adding patch", "patch", patch)
+	return []patchValue{{
+		Op:    "add",
+		Path:  path,
+		Value: value,
+	}}, nil
+}
 
-	// add patch to patchMap
-	key := getKey(*version.WeightObjRef)
-	if patchList, ok := (*patcheMap)[key]; !ok {
-		(*patcheMap)[key] = []patchIntValue{patch}
-	} else {
-		(*patcheMap)[key] = append(patchList, patch)
+// backendPatches uses a built-in weight adapter to compute the patches that set the weight of a version
+func backendPatches(ctx context.Context, instance *v2alpha2.Experiment, version v2alpha2.VersionDetail, backend v2alpha2.WeightBackendType, weight int32, restCfg *rest.Config) ([]patchIntValue, error) {
+	adapter, err := getWeightAdapter(backend)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	obj, err := readObject(ctx, version.WeightObjRef, instance.Namespace, restCfg)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.patches(obj, version.GetBackend(), weight)
 }
 
 // key is just the obj without the FieldPath
@@ -223,11 +336,24 @@ type patchIntValue struct {
 	Value int32  `json:"value"`
 }
 
-func patchWeight(ctx context.Context, objRef *corev1.ObjectReference, patches []patchIntValue, namespace string, restCfg *rest.Config) (*unstructured.Unstructured, error) {
+// patchValue is a JSON patch operation with a value of any type
+type patchValue struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// patchWeight applies patches to an object
+// If resourceVersion is not empty, the patch fails with a conflict if the object has been modified since that version.
+func patchWeight(ctx context.Context, objRef *corev1.ObjectReference, patches []patchValue, resourceVersion string, namespace string, restCfg *rest.Config) (*unstructured.Unstructured, error) {
 	log := Logger(ctx)
 	log.Info("patchWeight called")
 	defer log.Info("patchWeight ended")
 
+	if resourceVersion != "" {
+		// the api server rejects a patch that sets a stale resourceVersion
+		patches = append([]patchValue{{Op: "add", Path: "/metadata/resourceVersion", Value: resourceVersion}}, patches...)
+	}
 	data, err := json.Marshal(patches)
 	if err != nil {
 		log.Error(err, "Unable to create JSON patch command")
@@ -244,10 +370,9 @@ func patchWeight(ctx context.Context, objRef *corev1.ObjectReference, patches []
 	return dr.Patch(ctx, objRef.Name, types.JSONPatchType, data, metav1.PatchOptions{})
 }
 
-func observeWeight(ctx context.Context, objRef *corev1.ObjectReference, namespace string, restCfg *rest.Config) (*int32, error) {
+// readObject reads an object from the cluster and converts it to a Go map
+func readObject(ctx context.Context, objRef *corev1.ObjectReference, namespace string, restCfg *rest.Config) (map[string]interface{}, error) {
 	log := Logger(ctx)
-	log.Info("observeWeight called", "objRef", objRef)
-	defer log.Info("observeWeight ended")
 
 	dr, err := getDynamicResourceInterface(restCfg, objRef, namespace)
 	if err != nil {
@@ -261,7 +386,7 @@ func observeWeight(ctx context.Context, objRef *corev1.ObjectReference, namespac
 		log.Error(err, "Unable to read object in cluster", "name", objRef.Name)
 		return nil, err
 	}
-	log.Info("observeWeight", "referenced object", obj)
+	log.Info("readObject", "referenced object", obj)
 
 	// convert unstructured object to JSON object
 	resultJSON, err := obj.MarshalJSON()
@@ -269,7 +394,7 @@ func observeWeight(ctx context.Context, objRef *corev1.ObjectReference, namespac
 		log.Error(err, "Unable to convert resource to JSON object")
 		return nil, err
 	}
-	log.Info("observeWeight", "as JSON", resultJSON)
+	log.Info("readObject", "as JSON", resultJSON)
 
 	// convert JSON object to Go map
 	resultObj := make(map[string]interface{})
@@ -278,37 +403,113 @@ func observeWeight(ctx context.Context, objRef *corev1.ObjectReference, namespac
 		log.Error(err, "Unable to parse JSON object")
 		return nil, err
 	}
-	log.Info("observeWeight", "Go object", resultObj)
+	log.Info("readObject", "Go object", resultObj)
+
+	return resultObj, nil
+}
+
+func observeWeight(ctx context.Context, objRef *corev1.ObjectReference, namespace string, restCfg *rest.Config) (*int32, error) {
+	log := Logger(ctx)
+	log.Info("observeWeight called", "objRef", objRef)
+	defer log.Info("observeWeight ended")
+
+	out, err := readField(ctx, objRef, namespace, restCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// convert value to int32
+	int64Value, err := strconv.ParseInt(out, 10, 32)
+	if err != nil {
+		log.Error(err, "Unexpected type", "value", out)
+		return nil, err
+	}
+	int32Value := int32(int64Value)
+	log.Info("observeWeight", "read value", int32Value)
+
+	return &int32Value, nil
+}
+
+// observeFormattedWeight reads the weight of a version from a field that is not an integer percentage
+func observeFormattedWeight(ctx context.Context, version v2alpha2.VersionDetail, namespace string, restCfg *rest.Config) (*int32, error) {
+	log := Logger(ctx)
+	log.Info("observeFormattedWeight called", "version", version.Name)
+	defer log.Info("observeFormattedWeight ended")
+
+	out, err := readField(ctx, version.WeightObjRef, namespace, restCfg)
+	if err != nil {
+		return nil, err
+	}
+	weight, err := fromFieldValue(out, version)
+	if err != nil {
+		log.Error(err, "Unexpected value", "value", out)
+		return nil, err
+	}
+	log.Info("observeFormattedWeight", "read value", out, "weight", weight)
+
+	return &weight, nil
+}
+
+// readField reads the value of the field identified by the fieldpath of an object reference
+func readField(ctx context.Context, objRef *corev1.ObjectReference, namespace string, restCfg *rest.Config) (string, error) {
+	log := Logger(ctx)
+
+	resultObj, err := readObject(ctx, objRef, namespace, restCfg)
+	if err != nil {
+		return "", err
+	}
 
 	// quit if nothing there
 	if len(objRef.FieldPath) == 0 {
-		log.Error(err, "Unable to read zero length field", "objRef", objRef, "obj", obj)
-		return nil, errors.New("no fieldpath specified in referencing object")
+		log.Error(err, "Unable to read zero length field", "objRef", objRef, "obj", resultObj)
+		return "", errors.New("no fieldpath specified in referencing object")
 	}
 
 	// create JSONPath object and parse template (fieldpath)
 	j := jp.New("observe")
 	if err := j.Parse("{" + objRef.FieldPath + "}"); err != nil {
 		log.Error(err, "Unable to parse", "obj", objRef)
-		return nil, err
+		return "", err
 	}
 
-	// read value and convert to int32
+	// read value
 	buf := new(bytes.Buffer)
 	if err := j.Execute(buf, resultObj); err != nil {
 		log.Error(err, "Unable to find value", "obj", objRef)
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// observeVersionWeight reads the weight of a version from the cluster
+// If a built-in weight adapter is used, the weight is read by the adapter; otherwise it is read using the fieldpath
+func observeVersionWeight(ctx context.Context, instance *v2alpha2.Experiment, version v2alpha2.VersionDetail, restCfg *rest.Config) (*int32, error) {
+	backend := instance.Spec.GetWeightBackend()
+	if backend == nil {
+		if version.WeightFormat == nil {
+			return observeWeight(ctx, version.WeightObjRef, instance.Namespace, restCfg)
+		}
+		return observeFormattedWeight(ctx, version, instance.Namespace, restCfg)
+	}
+
+	log := Logger(ctx)
+	log.Info("observeVersionWeight called", "version", version.Name, "backend", *backend)
+	defer log.Info("observeVersionWeight ended")
+
+	adapter, err := getWeightAdapter(*backend)
+	if err != nil {
 		return nil, err
 	}
-	out := buf.String()
-	int64Value, err := strconv.ParseInt(out, 10, 32)
+	obj, err := readObject(ctx, version.WeightObjRef, instance.Namespace, restCfg)
 	if err != nil {
-		log.Error(err, "Unexpected type", "value", out)
 		return nil, err
 	}
-	int32Value := int32(int64Value)
-	log.Info("observeWeight", "read value", int32Value)
-
-	return &int32Value, nil
+	w, err := adapter.weight(obj, version.GetBackend())
+	if err != nil {
+		log.Error(err, "Unable to find weight", "version", version.Name)
+		return nil, err
+	}
+	return &w, nil
 }
 
 func updateObservedWeights(ctx context.Context, instance *v2alpha2.Experiment, restCfg *rest.Config) error {
@@ -329,7 +530,7 @@ func updateObservedWeights(ctx context.Context, instance *v2alpha2.Experiment, r
 	// baseline
 	b := instance.Spec.VersionInfo.Baseline
 	if b.WeightObjRef != nil {
-		w, err := observeWeight(ctx, b.WeightObjRef, instance.Namespace, restCfg)
+		w, err := observeVersionWeight(ctx, instance, b, restCfg)
 		if err != nil {
 			return err
 		}
@@ -343,7 +544,7 @@ func updateObservedWeights(ctx context.Context, instance *v2alpha2.Experiment, r
 	// candidates
 	for _, c := range instance.Spec.VersionInfo.Candidates {
 		if c.WeightObjRef != nil {
-			w, err := observeWeight(ctx, c.WeightObjRef, instance.Namespace, restCfg)
+			w, err := observeVersionWeight(ctx, instance, c, restCfg)
 			if err != nil {
 				return err
 			}
@@ -373,3 +574,187 @@ func updateObservedWeights(ctx context.Context, instance *v2alpha2.Experiment, r
 	log.Info("updateObservedWeights", "current weight distribution", instance.Status.CurrentWeightDistribution)
 	return nil
 }
+
+// appliedPatch records a patch applied to an object so that it can be undone
+type appliedPatch struct {
+	objRef corev1.ObjectReference
+	// undo restores the values replaced by the patch
+	undo []patchValue
+	// resourceVersion is the version of the object after it was patched
+	resourceVersion string
+}
+
+// applyPatches applies the patches to each object in turn
+// Each object is read and then patched using optimistic concurrency; on conflict, this is retried.
+// The patches successfully applied are returned, even on error, so that they can be rolled back.
+// In a dry run, the patches are recorded in status.dryRunPatches instead.
+func applyPatches(ctx context.Context, instance *v2alpha2.Experiment, patches map[corev1.ObjectReference][]patchValue, restCfg *rest.Config) ([]appliedPatch, error) {
+	log := Logger(ctx)
+	log.Info("applyPatches called")
+	defer log.Info("applyPatches ended")
+	namespace := instance.Namespace
+
+	// apply patches in a consistent order
+	objRefs := make([]corev1.ObjectReference, 0, len(patches))
+	for objRef := range patches {
+		objRefs = append(objRefs, objRef)
+	}
+	sort.Slice(objRefs, func(i, j int) bool {
+		return objRefs[i].String() < objRefs[j].String()
+	})
+
+	if instance.Spec.GetDryRun() {
+		return nil, recordDryRunPatches(ctx, instance, objRefs, patches)
+	}
+
+	applied := []appliedPatch{}
+	for i := range objRefs {
+		objRef := objRefs[i]
+		p := patches[objRef]
+
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			obj, err := readObject(ctx, &objRef, namespace, restCfg)
+			if err != nil {
+				return err
+			}
+			undo := undoPatches(obj, p)
+			result, err := patchWeight(ctx, &objRef, p, resourceVersion(obj), namespace, restCfg)
+			if err != nil {
+				return err
+			}
+			applied = append(applied, appliedPatch{objRef: objRef, undo: undo, resourceVersion: result.GetResourceVersion()})
+			return nil
+		})
+		if err != nil {
+			log.Error(err, "Unable to patch", "object", objRef, "patch", p)
+			return applied, fmt.Errorf("unable to patch %s %s: %s", objRef.Kind, objRef.Name, err.Error())
+		}
+	}
+	return applied, nil
+}
+
+// recordDryRunPatches records the patches that would be applied to each object in status.dryRunPatches
+func recordDryRunPatches(ctx context.Context, instance *v2alpha2.Experiment, objRefs []corev1.ObjectReference, patches map[corev1.ObjectReference][]patchValue) error {
+	log := Logger(ctx)
+	for _, objRef := range objRefs {
+		data, err := json.Marshal(patches[objRef])
+		if err != nil {
+			log.Error(err, "Unable to create JSON patch command")
+			return err
+		}
+		log.Info("Dry run; not patching", "object", objRef, "patch", string(data))
+		instance.Status.DryRunPatches = append(instance.Status.DryRunPatches, v2alpha2.ObjectPatch{ObjRef: objRef, Patch: string(data)})
+	}
+	return nil
+}
+
+// describeDryRunPatches summarizes the patches recorded in a dry run
+func describeDryRunPatches(patches []v2alpha2.ObjectPatch) string {
+	descriptions := []string{}
+	for _, p := range patches {
+		descriptions = append(descriptions, fmt.Sprintf("%s %s: %s", p.ObjRef.Kind, p.ObjRef.Name, p.Patch))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// rollbackPatches undoes applied patches in reverse order
+// A patch is not undone if the object has been modified since it was patched.
+func rollbackPatches(ctx context.Context, applied []appliedPatch, namespace string, restCfg *rest.Config) error {
+	log := Logger(ctx)
+	log.Info("rollbackPatches called")
+	defer log.Info("rollbackPatches ended")
+
+	failed := []string{}
+	for i := len(applied) - 1; i >= 0; i-- {
+		a := applied[i]
+		if _, err := patchWeight(ctx, &a.objRef, a.undo, a.resourceVersion, namespace, restCfg); err != nil {
+			log.Error(err, "Unable to roll back patch", "object", a.objRef)
+			failed = append(failed, a.objRef.Kind+" "+a.objRef.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to roll back %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// verifyWeights reads the weights of the versions back from the cluster and confirms that they match the expected weights
+func verifyWeights(ctx context.Context, instance *v2alpha2.Experiment, weights []v2alpha2.WeightData, restCfg *rest.Config) error {
+	log := Logger(ctx)
+	log.Info("verifyWeights called")
+	defer log.Info("verifyWeights ended")
+
+	for _, version := range patchableVersions(instance) {
+		observed, err := observeVersionWeight(ctx, instance, version, restCfg)
+		if err != nil {
+			return err
+		}
+		expected := getWeightRecommendation(version.Name, weights)
+		if expected != nil && *observed != *expected {
+			return fmt.Errorf("weight of version %s is %d after patching, not %d", version.Name, *observed, *expected)
+		}
+	}
+	return nil
+}
+
+// patchableVersions returns the versions whose weights can be set and read using their weightObjRef
+func patchableVersions(instance *v2alpha2.Experiment) []v2alpha2.VersionDetail {
+	versions := []v2alpha2.VersionDetail{}
+	for _, version := range append([]v2alpha2.VersionDetail{instance.Spec.VersionInfo.Baseline}, instance.Spec.VersionInfo.Candidates...) {
+		if version.WeightObjRef == nil || (instance.Spec.GetWeightBackend() == nil && version.WeightObjRef.FieldPath == "") {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// undoPatches computes the patches that restore the values an object has at the paths modified by patches
+func undoPatches(obj map[string]interface{}, patches []patchValue) []patchValue {
+	undo := make([]patchValue, 0, len(patches))
+	// undo in reverse order so that a value added and then modified is restored correctly
+	for i := len(patches) - 1; i >= 0; i-- {
+		// a test does not modify the object
+		if patches[i].Op == "test" {
+			continue
+		}
+		if value, ok := valueAt(obj, patches[i].Path); ok {
+			undo = append(undo, patchValue{Op: "add", Path: patches[i].Path, Value: value})
+		} else {
+			undo = append(undo, patchValue{Op: "remove", Path: patches[i].Path})
+		}
+	}
+	return undo
+}
+
+// valueAt returns the value at a JSON pointer in an object
+func valueAt(obj map[string]interface{}, pointer string) (interface{}, bool) {
+	var current interface{} = obj
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch c := current.(type) {
+		case map[string]interface{}:
+			v, ok := c[token]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			current = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// resourceVersion returns the resourceVersion of an object
+func resourceVersion(obj map[string]interface{}) string {
+	v, _ := valueAt(obj, "/metadata/resourceVersion")
+	rv, _ := v.(string)
+	return rv
+}
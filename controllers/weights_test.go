@@ -280,7 +280,7 @@ var _ = Describe("patch", func() {
 						Timestamp:  metav1.Now(),
 					},
 					Data: []v2alpha2.WeightData{
-						{Name: "v1", Value: 14},
+						{Name: "v1", Value: 84},
 						{Name: "v2", Value: 16},
 					},
 				},
@@ -343,8 +343,8 @@ var _ = Describe("Weight Patching", func() {
 			WithBaselineVersion("baseline", nil).
 			Build()
 		It("Should not add a patch", func() {
-			patches := map[corev1.ObjectReference][]patchIntValue{}
-			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, &patches)
+			patches := map[corev1.ObjectReference][]patchValue{}
+			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, cfg, &patches)
 			Expect(err).Should(BeNil())
 			Expect(patches).Should(BeEmpty())
 		})
@@ -363,8 +363,8 @@ var _ = Describe("Weight Patching", func() {
 			}).
 			Build()
 		It("Should not add a patch", func() {
-			patches := map[corev1.ObjectReference][]patchIntValue{}
-			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, &patches)
+			patches := map[corev1.ObjectReference][]patchValue{}
+			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, cfg, &patches)
 			Expect(err).Should(BeNil())
 			Expect(patches).Should(BeEmpty())
 		})
@@ -384,8 +384,8 @@ var _ = Describe("Weight Patching", func() {
 			}).
 			Build()
 		It("Should not fail and not add a patch", func() {
-			patches := map[corev1.ObjectReference][]patchIntValue{}
-			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, &patches)
+			patches := map[corev1.ObjectReference][]patchValue{}
+			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, cfg, &patches)
 			Expect(err).Should(MatchError("no weight recommendation provided"))
 			Expect(patches).Should(BeEmpty())
 		})
@@ -407,8 +407,8 @@ var _ = Describe("Weight Patching", func() {
 			WithRecommendedWeight("baseline", int32(25)).
 			Build()
 		It("Should not fail and not add a patch", func() {
-			patches := map[corev1.ObjectReference][]patchIntValue{}
-			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, &patches)
+			patches := map[corev1.ObjectReference][]patchValue{}
+			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, cfg, &patches)
 			Expect(err).Should(BeNil())
 			Expect(patches).Should(BeEmpty())
 		})
@@ -429,8 +429,8 @@ var _ = Describe("Weight Patching", func() {
 			WithRecommendedWeight("baseline", int32(50)).
 			Build()
 		It("Should add a patch", func() {
-			patches := map[corev1.ObjectReference][]patchIntValue{}
-			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, &patches)
+			patches := map[corev1.ObjectReference][]patchValue{}
+			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, cfg, &patches)
 			Expect(err).Should(BeNil())
 			Expect(len(patches)).Should(Equal(1))
 		})
@@ -460,12 +460,12 @@ var _ = Describe("Weight Patching", func() {
 			WithRecommendedWeight("candidate", int32(65)).
 			Build()
 		It("There are multiple patches for one object", func() {
-			patches := map[corev1.ObjectReference][]patchIntValue{}
-			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, &patches)
+			patches := map[corev1.ObjectReference][]patchValue{}
+			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, cfg, &patches)
 			Expect(err).Should(BeNil())
 			Expect(len(patches)).Should(Equal(1))
 			for _, version := range experiment.Spec.VersionInfo.Candidates {
-				Expect(addPatch(ctx, experiment, version, &patches)).Should(Succeed())
+				Expect(addPatch(ctx, experiment, version, cfg, &patches)).Should(Succeed())
 			}
 			Expect(len(patches)).Should(Equal(1))
 			key := getKey(*experiment.Spec.VersionInfo.Baseline.WeightObjRef)
@@ -500,15 +500,103 @@ var _ = Describe("Weight Patching", func() {
 			WithRecommendedWeight("candidate", int32(65)).
 			Build()
 		It("There is one patch for each object", func() {
-			patches := map[corev1.ObjectReference][]patchIntValue{}
-			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, &patches)
+			patches := map[corev1.ObjectReference][]patchValue{}
+			err := addPatch(ctx, experiment, experiment.Spec.VersionInfo.Baseline, cfg, &patches)
 			Expect(err).Should(BeNil())
 			Expect(len(patches)).Should(Equal(1))
 			for _, version := range experiment.Spec.VersionInfo.Candidates {
-				Expect(addPatch(ctx, experiment, version, &patches)).Should(Succeed())
+				Expect(addPatch(ctx, experiment, version, cfg, &patches)).Should(Succeed())
 			}
 			Expect(len(patches)).Should(Equal(2))
 		})
 	})
 
 })
+
+var _ = Describe("Applying Weights", func() {
+	Context("When validating recommended weights", func() {
+		bldr := func() *v2alpha2.ExperimentBuilder {
+			return v2alpha2.NewExperiment("validate", "default").
+				WithTarget("target").
+				WithTestingPattern(v2alpha2.TestingPatternCanary).
+				WithBaselineVersion("v1", nil).
+				WithCandidateVersion("v2", nil)
+		}
+		It("weights that add up to 100 are valid", func() {
+			experiment := bldr().WithRecommendedWeight("v1", 70).WithRecommendedWeight("v2", 30).Build()
+			Expect(validateRecommendedWeights(experiment)).To(Succeed())
+		})
+		It("weights that do not add up to 100 are invalid", func() {
+			experiment := bldr().WithRecommendedWeight("v1", 70).WithRecommendedWeight("v2", 20).Build()
+			Expect(validateRecommendedWeights(experiment)).ToNot(Succeed())
+		})
+		It("weights out of range are invalid", func() {
+			experiment := bldr().WithRecommendedWeight("v1", 110).WithRecommendedWeight("v2", -10).Build()
+			Expect(validateRecommendedWeights(experiment)).ToNot(Succeed())
+		})
+		It("a missing weight is invalid", func() {
+			experiment := bldr().WithRecommendedWeight("v1", 100).Build()
+			Expect(validateRecommendedWeights(experiment)).ToNot(Succeed())
+		})
+	})
+
+	Context("When undoing patches", func() {
+		obj := toObject(`
+metadata:
+  resourceVersion: "42"
+spec:
+  backends:
+  - service: reviews-v1
+    weight: 75
+  - service: reviews-v2
+`)
+		It("values are read using JSON pointers", func() {
+			Expect(resourceVersion(obj)).To(Equal("42"))
+			value, ok := valueAt(obj, "/spec/backends/0/weight")
+			Expect(ok).To(BeTrue())
+			Expect(value).To(BeEquivalentTo(75))
+			_, ok = valueAt(obj, "/spec/backends/1/weight")
+			Expect(ok).To(BeFalse())
+			_, ok = valueAt(obj, "/spec/backends/2/weight")
+			Expect(ok).To(BeFalse())
+		})
+		It("replaced values are restored and added values are removed", func() {
+			undo := undoPatches(obj, []patchValue{
+				{Op: "add", Path: "/spec/backends/0/weight", Value: 40},
+				{Op: "add", Path: "/spec/backends/1/weight", Value: 60},
+			})
+			Expect(undo).To(HaveLen(2))
+			Expect(undo[0]).To(Equal(patchValue{Op: "remove", Path: "/spec/backends/1/weight"}))
+			Expect(undo[1].Op).To(Equal("add"))
+			Expect(undo[1].Path).To(Equal("/spec/backends/0/weight"))
+			Expect(undo[1].Value).To(BeEquivalentTo(75))
+		})
+	})
+})
+
+var _ = Describe("Dry Run", func() {
+	Context("When an experiment is a dry run", func() {
+		It("the patches are recorded rather than applied", func() {
+			ctx := context.WithValue(context.Background(), LoggerKey, ctrl.Log)
+			experiment := v2alpha2.NewExperiment("dryrun", "default").
+				WithTarget("target").
+				WithTestingPattern(v2alpha2.TestingPatternCanary).
+				WithDryRun(true).
+				Build()
+			objRef := corev1.ObjectReference{APIVersion: "split.smi-spec.io/v1alpha2", Kind: "TrafficSplit", Name: "reviews"}
+			patches := map[corev1.ObjectReference][]patchValue{
+				objRef: {{Op: "add", Path: "/spec/backends/0/weight", Value: 40}},
+			}
+
+			// no rest configuration is needed since the cluster is not accessed
+			applied, err := applyPatches(ctx, experiment, patches, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applied).To(BeEmpty())
+			Expect(experiment.Status.DryRunPatches).To(Equal([]v2alpha2.ObjectPatch{{
+				ObjRef: objRef,
+				Patch:  `[{"op":"add","path":"/spec/backends/0/weight","value":40}]`,
+			}}))
+			Expect(describeDryRunPatches(experiment.Status.DryRunPatches)).To(Equal(`TrafficSplit reviews: [{"op":"add","path":"/spec/backends/0/weight","value":40}]`))
+		})
+	})
+})
@@ -0,0 +1,176 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// winner.go - assessment of the winner by the analysis built into iter8
+//    - used when spec.criteria.strength is set and there is one reward; it replaces the winner assessment
+//      of the analytics service
+//    - the first reward is analyzed for the versions that satisfy all objectives
+//    - the sample size of a reward that is not reported is the value of the metric named by its spec.sampleSize
+//    - the confidence in each version and the interval of its reward are recorded in the analysis
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/iter8-tools/etc3/analysis"
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// analysisProvenance identifies winner assessments computed by the built-in analysis
+const analysisProvenance = "iter8 controller (built-in analysis)"
+
+// assessWinner assesses the winner using the built-in analysis if spec.criteria.strength is set
+func assessWinner(ctx context.Context, instance *v2alpha2.Experiment) {
+	log := Logger(ctx)
+	log.Info("assessWinner called")
+	defer log.Info("assessWinner completed")
+
+	criteria := instance.Spec.Criteria
+	if criteria == nil || criteria.Strength == nil || len(criteria.Rewards) != 1 ||
+		instance.Spec.VersionInfo == nil || instance.Status.Analysis == nil {
+		return
+	}
+	reward := criteria.Rewards[0]
+	key := metricInfoName(instance, reward.Metric)
+	strength := criteria.Strength
+
+	assessment := &v2alpha2.WinnerAssessmentAnalysis{
+		AnalysisMetaData: v2alpha2.AnalysisMetaData{
+			Provenance: analysisProvenance,
+			Timestamp:  metav1.NewTime(time.Now()),
+		},
+	}
+	instance.Status.Analysis.WinnerAssessment = assessment
+
+	samples, err := rewardSamples(instance, key, strength.GetModel())
+	if err == nil && len(samples) == 0 {
+		err = fmt.Errorf("no version satisfies the objectives")
+	}
+	var result *analysis.Result
+	if err == nil {
+		result, err = analysis.Assess(samples, analysis.Options{
+			Method:         strength.GetMethod(),
+			Model:          strength.GetModel(),
+			Confidence:     strength.GetConfidence(),
+			MinSampleSize:  int64(strength.GetMinSampleSize()),
+			HigherIsBetter: reward.PreferredDirection == v2alpha2.PreferredDirectionHigher,
+			Looks:          int(instance.Spec.GetIterationsPerLoop() * instance.Spec.GetMaxLoops()),
+			Seed:           uint64(instance.Status.GetCompletedIterations()),
+		})
+	}
+	if err != nil {
+		log.Info("Unable to assess winner", "reason", err.Error())
+		message := err.Error()
+		assessment.Message = &message
+		return
+	}
+
+	assessment.Data.Confidence = map[string]resource.Quantity{}
+	data := instance.Status.Analysis.AggregatedMetrics.Data[key]
+	for version, a := range result.Assessments {
+		if confidence, ok := quantity(a.Confidence); ok {
+			assessment.Data.Confidence[version] = confidence
+		}
+		lower, lok := quantity(a.Lower)
+		upper, uok := quantity(a.Upper)
+		if !lok || !uok {
+			continue
+		}
+		versionData := data.Data[version]
+		versionData.CredibleInterval = &v2alpha2.Interval{Lower: lower, Upper: upper}
+		data.Data[version] = versionData
+	}
+	if result.Winner != nil {
+		assessment.Data.WinnerFound = true
+		assessment.Data.Winner = result.Winner
+	}
+}
+
+// recordSampleSizes records the sample sizes of metrics that name a sampleSize metric
+// The sample size of a version is the value of the sampleSize metric for the version;
+// sample sizes reported by the analytics service are not replaced.
+func recordSampleSizes(instance *v2alpha2.Experiment) {
+	if instance.Status.Analysis == nil || instance.Status.Analysis.AggregatedMetrics == nil {
+		return
+	}
+	data := instance.Status.Analysis.AggregatedMetrics.Data
+	for _, info := range instance.Status.Metrics {
+		if info.MetricObj.Spec.SampleSize == nil {
+			continue
+		}
+		sizes := data[referenceKey(info.Name, *info.MetricObj.Spec.SampleSize)]
+		for version, versionData := range data[info.Name].Data {
+			size := sizes.Data[version].Value
+			if versionData.SampleSize != nil || size == nil {
+				continue
+			}
+			sampleSize := int32(math.Round(size.AsApproximateFloat64()))
+			versionData.SampleSize = &sampleSize
+			data[info.Name].Data[version] = versionData
+		}
+	}
+}
+
+// rewardSamples returns the samples of the reward for the versions that satisfy all objectives
+func rewardSamples(instance *v2alpha2.Experiment, key string, model v2alpha2.RewardModelType) ([]analysis.Sample, error) {
+	samples := []analysis.Sample{}
+	for _, version := range versionDetails(instance) {
+		if !satisfiesObjectives(instance, version.Name) {
+			continue
+		}
+		var data v2alpha2.AggregatedMetricsVersionData
+		if am := instance.Status.Analysis.AggregatedMetrics; am != nil {
+			data = am.Data[key].Data[version.Name]
+		}
+		if data.Value == nil {
+			return nil, fmt.Errorf("no value of reward %s for version %s", key, version.Name)
+		}
+		if data.SampleSize == nil {
+			return nil, fmt.Errorf("no sample size of reward %s for version %s", key, version.Name)
+		}
+		sample := analysis.Sample{
+			Version: version.Name,
+			Value:   data.Value.AsApproximateFloat64(),
+			Size:    int64(*data.SampleSize),
+		}
+		if model == v2alpha2.RewardModelNormal {
+			if data.StdDev == nil {
+				return nil, fmt.Errorf("no standard deviation of reward %s for version %s", key, version.Name)
+			}
+			sample.StdDev = data.StdDev.AsApproximateFloat64()
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// satisfiesObjectives returns true if the version satisfies all objectives in the latest version assessments
+func satisfiesObjectives(instance *v2alpha2.Experiment, version string) bool {
+	va := instance.Status.Analysis.VersionAssessments
+	if va == nil {
+		return true
+	}
+	for _, satisfied := range va.Data[version] {
+		if !satisfied {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Built-in Winner Assessment", func() {
+	versionData := func(value string, stdDev string, sampleSize int32) v2alpha2.AggregatedMetricsVersionData {
+		v, sd := resource.MustParse(value), resource.MustParse(stdDev)
+		return v2alpha2.AggregatedMetricsVersionData{Value: &v, StdDev: &sd, SampleSize: &sampleSize}
+	}
+
+	bldr := func(strength *v2alpha2.Strength) *v2alpha2.Experiment {
+		reward := v2alpha2.NewMetric("revenue", "default").Build()
+		experiment := v2alpha2.NewExperiment("winner", "default").
+			WithTarget("target").
+			WithBaselineVersion("v1", nil).
+			WithCandidateVersion("v2", nil).
+			WithCandidateVersion("v3", nil).
+			WithReward(*reward, v2alpha2.PreferredDirectionHigher).
+			Build()
+		if strength != nil {
+			// revenue is the mean of its observations
+			normal := v2alpha2.RewardModelNormal
+			strength.Model = &normal
+		}
+		experiment.Spec.Criteria.Strength = strength
+		experiment.Status.Metrics = []v2alpha2.MetricInfo{{Name: "default/revenue", MetricObj: *reward}}
+		winner := "v1"
+		experiment.Status.Analysis = &v2alpha2.Analysis{
+			AggregatedMetrics: &v2alpha2.AggregatedMetricsAnalysis{
+				Data: map[string]v2alpha2.AggregatedMetricsData{
+					"default/revenue": {Data: map[string]v2alpha2.AggregatedMetricsVersionData{
+						"v1": versionData("100", "20", 100),
+						"v2": versionData("110", "20", 100),
+						"v3": versionData("150", "20", 100),
+					}},
+				},
+			},
+			WinnerAssessment: &v2alpha2.WinnerAssessmentAnalysis{
+				Data: v2alpha2.WinnerAssessmentData{WinnerFound: true, Winner: &winner},
+			},
+			VersionAssessments: &v2alpha2.VersionAssessmentAnalysis{
+				Data: map[string]v2alpha2.BooleanList{"v1": {true}, "v2": {true}, "v3": {false}},
+			},
+		}
+		return experiment
+	}
+
+	Context("When criteria.strength is not set", func() {
+		It("the winner assessment of the analytics service is used", func() {
+			experiment := bldr(nil)
+			assessWinner(ctx(), experiment)
+			Expect(*experiment.Status.GetWinner()).To(Equal("v1"))
+		})
+	})
+
+	Context("When criteria.strength is set", func() {
+		It("the best version that satisfies the objectives is the winner", func() {
+			experiment := bldr(&v2alpha2.Strength{})
+			assessWinner(ctx(), experiment)
+			Expect(*experiment.Status.GetWinner()).To(Equal("v2"))
+			assessment := experiment.Status.Analysis.WinnerAssessment
+			Expect(assessment.Provenance).To(Equal(analysisProvenance))
+			Expect(assessment.Data.Confidence).To(HaveKey("v1"))
+			Expect(assessment.Data.Confidence).ToNot(HaveKey("v3"))
+			confidence := assessment.Data.Confidence["v2"]
+			Expect(confidence.AsApproximateFloat64()).To(BeNumerically(">", 0.95))
+			interval := experiment.Status.Analysis.AggregatedMetrics.Data["default/revenue"].Data["v2"].CredibleInterval
+			Expect(interval).ToNot(BeNil())
+			Expect(interval.Lower.AsApproximateFloat64()).To(BeNumerically("~", 106.08, 0.01))
+			Expect(interval.Upper.AsApproximateFloat64()).To(BeNumerically("~", 113.92, 0.01))
+		})
+		It("no winner is declared until the strength is met", func() {
+			confidence := resource.MustParse("0.99999")
+			experiment := bldr(&v2alpha2.Strength{Confidence: &confidence})
+			assessWinner(ctx(), experiment)
+			Expect(experiment.Status.GetWinner()).To(BeNil())
+			Expect(experiment.Status.Analysis.WinnerAssessment.Data.Confidence).To(HaveKey("v2"))
+		})
+		It("no winner is declared without the data required by the model", func() {
+			experiment := bldr(&v2alpha2.Strength{})
+			experiment.Status.Analysis.AggregatedMetrics.Data["default/revenue"].Data["v2"] = v2alpha2.AggregatedMetricsVersionData{}
+			assessWinner(ctx(), experiment)
+			Expect(experiment.Status.GetWinner()).To(BeNil())
+			Expect(*experiment.Status.Analysis.WinnerAssessment.Message).To(Equal("no value of reward default/revenue for version v2"))
+		})
+	})
+})
+
+var _ = Describe("Built-in Winner Assessment of Built-in Metrics", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "winner")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "conversion.csv"), []byte("version,value\nv1,0.10\nv2,0.15\n"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "users.csv"), []byte("version,value\nv1,2000\nv2,2000\n"), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("When the reward names a sampleSize metric", func() {
+		It("the winner is assessed from the values of the metrics", func() {
+			users := v2alpha2.NewMetric("users", "default").
+				WithFile(v2alpha2.FileProvider{Path: filepath.Join(dir, "users.csv")}).
+				Build()
+			conversion := v2alpha2.NewMetric("conversion", "default").
+				WithFile(v2alpha2.FileProvider{Path: filepath.Join(dir, "conversion.csv")}).
+				WithSampleSize("users").
+				Build()
+			experiment := v2alpha2.NewExperiment("winner", "default").
+				WithTarget("target").
+				WithBaselineVersion("v1", nil).
+				WithCandidateVersion("v2", nil).
+				WithReward(*conversion, v2alpha2.PreferredDirectionHigher).
+				WithStrength(v2alpha2.Strength{}).
+				Build()
+			experiment.Status.Metrics = []v2alpha2.MetricInfo{
+				{Name: "default/conversion", MetricObj: *conversion},
+				{Name: "default/users", MetricObj: *users},
+			}
+			experiment.Status.Analysis = &v2alpha2.Analysis{}
+
+			(&ExperimentReconciler{}).evaluateBuiltinMetrics(ctx(), experiment)
+			recordSampleSizes(experiment)
+			data := experiment.Status.Analysis.AggregatedMetrics.Data["default/conversion"].Data
+			Expect(*data["v1"].SampleSize).To(Equal(int32(2000)))
+
+			assessWinner(ctx(), experiment)
+			Expect(experiment.Status.Analysis.WinnerAssessment.Message).To(BeNil())
+			Expect(*experiment.Status.GetWinner()).To(Equal("v2"))
+		})
+	})
+})
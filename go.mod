@@ -6,6 +6,7 @@ require (
 	github.com/antonmedv/expr v1.9.0
 	github.com/ghodss/yaml v1.0.0
 	github.com/go-logr/logr v0.4.0
+	github.com/itchyny/gojq v0.12.5
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/olekukonko/tablewriter v0.0.0-20170122224234-a0225b3f23b5
@@ -16,6 +17,8 @@ require (
 	github.com/spf13/cobra v1.2.1
 	github.com/spf13/viper v1.8.1
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/exp v0.0.0-20200224162631-6cc2880d07d6
+	gonum.org/v1/gonum v0.9.3
 	gopkg.in/inf.v0 v0.9.1
 	k8s.io/api v0.22.0
 	k8s.io/apiextensions-apiserver v0.22.0
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	expr "github.com/iter8-tools/etc3/iter8ctl/experiment"
+	"github.com/iter8-tools/etc3/iter8ctl/metric"
+	"github.com/spf13/cobra"
+)
+
+var versionName string
+var execute bool
+
+// metricCmd represents the metric command
+var metricCmd = &cobra.Command{
+	Use:   "metric",
+	Short: "Work with Iter8 metrics",
+	Long:  `Commands that help author and troubleshoot Iter8 metrics.`,
+}
+
+// metricTestCmd represents the metric test command
+var metricTestCmd = &cobra.Command{
+	Use:   "test metric-name",
+	Short: "Test the query of an Iter8 metric",
+	Long:  `Render the query of a metric for the versions of an experiment using the same variables as the analytics service, and print the rendered query for each version. With --execute, the query is sent to the provider, the jq expression is applied to the response, and the resulting value, or a precise error, is printed. When experiment-name is omitted, the experiment with the latest creation timestamp in the cluster is used. The metric name may be of the form namespace/name; otherwise the metric is read from the namespace of the experiment or, if not found there, from the ClusterMetric with that name.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("exactly one metric name must be supplied")
+		}
+		latest = (expName == "")
+		// get experiment from cluster
+		var err error
+		if exp, err = expr.GetExperiment(latest, expName, expNamespace); err != nil {
+			return err
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := metric.GetMetric(args[0], exp.Namespace)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		secret, err := metric.GetSecret(m)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		tester := &metric.Tester{
+			Metric:     m,
+			Experiment: exp,
+			Secret:     secret,
+			Execute:    execute,
+			Now:        time.Now(),
+		}
+		results, err := tester.Test(context.Background(), versionName)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		metric.Print(os.Stdout, m, results)
+		if metric.Failed(results) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metricCmd)
+	metricCmd.AddCommand(metricTestCmd)
+	metricTestCmd.Flags().StringVarP(&expName, "experiment", "e", "", "name of the experiment whose versions are used; the latest experiment is used if not specified")
+	metricTestCmd.Flags().StringVar(&versionName, "version", "", "name of the version; all versions of the experiment are tested if not specified")
+	metricTestCmd.Flags().BoolVarP(&execute, "execute", "x", false, "send the query to the provider and print the resulting value")
+}
@@ -10,6 +10,8 @@ import (
 	expr "github.com/iter8-tools/etc3/iter8ctl/experiment"
 	"github.com/iter8-tools/etc3/taskrunner/core"
 	"github.com/olekukonko/tablewriter"
+	"gopkg.in/inf.v0"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Result struct contains fields that store intermediate results associated with an invocation of 'iter8ctl describe' subcommand.
@@ -100,6 +102,8 @@ func (d *Result) printWinnerAssessment() *Result {
 			switch d.experiment.Spec.Strategy.TestingPattern {
 			case v2alpha2.TestingPatternCanary:
 				explanation = "> If the candidate version satisfies the experiment objectives, then it is the winner.\n> Otherwise, if the baseline version satisfies the experiment objectives, it is the winner.\n> Otherwise, there is no winner.\n"
+			case v2alpha2.TestingPatternShadow:
+				explanation = "> The candidate version receives a mirror of the traffic of the baseline version.\n> If the candidate version satisfies the experiment objectives, then it is the winner.\n> Otherwise, if the baseline version satisfies the experiment objectives, it is the winner.\n> Otherwise, there is no winner.\n"
 			case v2alpha2.TestingPatternConformance:
 				explanation = "> If the version being validated; i.e., the baseline version, satisfies the experiment objectives, it is the winner.\n> Otherwise, there is no winner.\n"
 			default:
@@ -118,6 +122,19 @@ func (d *Result) printWinnerAssessment() *Result {
 			} else {
 				d.description.WriteString("Winning version: not found\n")
 			}
+			if len(w.Data.Confidence) > 0 {
+				confidence := []string{}
+				for _, version := range d.experiment.GetVersions() {
+					if c, ok := w.Data.Confidence[version]; ok {
+						z := new(inf.Dec).Round(c.AsDec(), 3, inf.RoundCeil)
+						confidence = append(confidence, fmt.Sprintf("%s: %s", version, z.String()))
+					}
+				}
+				d.description.WriteString(fmt.Sprintf("Confidence in each version: %s\n", strings.Join(confidence, ", ")))
+			}
+			if w.Message != nil {
+				d.description.WriteString(fmt.Sprintf("Message: %s\n", *w.Message))
+			}
 
 			if d.experiment.Spec.Strategy.TestingPattern != v2alpha2.TestingPatternConformance &&
 				d.experiment.Status.VersionRecommendedForPromotion != nil {
@@ -155,6 +172,58 @@ func (d *Result) printRewardAssessment() *Result {
 	return d
 }
 
+// printRewardScores prints a matrix of normalized reward values for each reward-version pair,
+// followed by the composite score of each version.
+// Rows correspond to experiment rewards. Columns correspond to versions.
+// The scores are printed only if the experiment has several rewards.
+func (d *Result) printRewardScores() *Result {
+	if d.err != nil ||
+		d.experiment.Status.Analysis == nil ||
+		d.experiment.Status.Analysis.WinnerAssessment == nil ||
+		len(d.experiment.Status.Analysis.WinnerAssessment.Data.Scores) == 0 {
+		return d
+	}
+	scores := d.experiment.Status.Analysis.WinnerAssessment.Data.Scores
+
+	d.description.WriteString("\n****** Reward Scores ******\n")
+	d.description.WriteString(fmt.Sprintf("> Normalized (%s) values of reward metrics for each version, and their weighted mean. Higher is better.\n", d.experiment.Spec.GetRewardNormalization()))
+	table := tablewriter.NewWriter(&d.description)
+	table.SetRowLine(true)
+	versions := d.experiment.GetVersions()
+	table.SetHeader(append([]string{"Reward"}, versions...))
+	score := func(q *resource.Quantity) string {
+		if q == nil {
+			return "unavailable"
+		}
+		return new(inf.Dec).Round(q.AsDec(), 3, inf.RoundCeil).String()
+	}
+	for _, reward := range d.experiment.Spec.Criteria.Rewards {
+		row := []string{expr.StringifyReward(reward)}
+		for _, version := range versions {
+			var q *resource.Quantity
+			if s, ok := scores[version]; ok {
+				if r, ok := s.Rewards[reward.Metric]; ok {
+					q = &r
+				}
+			}
+			row = append(row, score(q))
+		}
+		table.Append(row)
+	}
+	row := []string{"Score"}
+	for _, version := range versions {
+		var q *resource.Quantity
+		if s, ok := scores[version]; ok {
+			q = &s.Score
+		}
+		row = append(row, score(q))
+	}
+	table.Append(row)
+	table.Render()
+
+	return d
+}
+
 // printObjectiveAssessment prints a matrix of boolean values into d's description buffer.
 // Rows correspond to experiment objectives, columns correspond to versions, and entry [i, j] indicates if objective i is satisfied by version j.
 // Objective assessments are printed in the same sequence as in the experiment's spec.criteria.objectives section.
@@ -210,6 +279,16 @@ func (d *Result) printMetrics() *Result {
 			versions := d.experiment.GetVersions()
 			table.SetHeader(append([]string{"Metric"}, versions...))
 			for _, metricInfo := range d.experiment.Status.Metrics {
+				// a Histogram metric is summarized by its percentiles
+				if metricInfo.MetricObj.Spec.IsHistogram() {
+					for _, p := range metricInfo.MetricObj.Spec.GetPercentiles() {
+						percentileInfo := metricInfo
+						percentileInfo.Name = v2alpha2.PercentileMetricName(metricInfo.Name, p)
+						row := []string{expr.GetMetricNameAndUnits(percentileInfo)}
+						table.Append(append(row, d.experiment.GetMetricStrs(percentileInfo.Name)...))
+					}
+					continue
+				}
 				row := []string{expr.GetMetricNameAndUnits(metricInfo)}
 				table.Append(append(row, d.experiment.GetMetricStrs(metricInfo.Name)...))
 			}
@@ -228,6 +307,7 @@ func (d *Result) PrintAnalysis() *Result {
 	if d.experiment.Started() {
 		d.printWinnerAssessment().
 			printRewardAssessment().
+			printRewardScores().
 			printVersionAssessment().
 			printMetrics()
 	}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	expr "github.com/iter8-tools/etc3/iter8ctl/experiment"
 	"github.com/iter8-tools/etc3/iter8ctl/utils"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 /* Tests */
@@ -50,6 +53,75 @@ func TestPrintMetrics(t *testing.T) {
 	}
 }
 
+func TestPrintHistogramMetrics(t *testing.T) {
+	latency := v2alpha2.NewMetric("latency", "default").WithUnits("sec").WithProvider(v2alpha2.ProviderFortio).WithPercentiles(50, 95).Build()
+	e := v2alpha2.NewExperiment("test", "default").
+		WithTarget("target").
+		WithBaselineVersion("v1", nil).
+		Build()
+	p95 := resource.MustParse("0.25")
+	e.Status.Metrics = []v2alpha2.MetricInfo{{Name: "default/latency", MetricObj: *latency}}
+	e.Status.Analysis = &v2alpha2.Analysis{AggregatedMetrics: &v2alpha2.AggregatedMetricsAnalysis{
+		Data: map[string]v2alpha2.AggregatedMetricsData{
+			"default/latency-p95": {Data: map[string]v2alpha2.AggregatedMetricsVersionData{"v1": {Value: &p95}}},
+		},
+	}}
+	d := Builder().WithExperiment(&expr.Experiment{Experiment: *e})
+	d.printMetrics()
+	assert.NoError(t, d.Error())
+	assert.Contains(t, d.description.String(), "default/latency-p50 (sec)")
+	assert.Regexp(t, `default/latency-p95 \(sec\)\s+\|\s+0.25`, d.description.String())
+}
+
+func TestPrintWinnerConfidence(t *testing.T) {
+	e := v2alpha2.NewExperiment("test", "default").
+		WithTarget("target").
+		WithBaselineVersion("v1", nil).
+		WithCandidateVersion("v2", nil).
+		Build()
+	winner := "v2"
+	e.Status.Analysis = &v2alpha2.Analysis{WinnerAssessment: &v2alpha2.WinnerAssessmentAnalysis{
+		Data: v2alpha2.WinnerAssessmentData{
+			WinnerFound: true,
+			Winner:      &winner,
+			Confidence:  map[string]resource.Quantity{"v1": resource.MustParse("0.025"), "v2": resource.MustParse("0.975")},
+		},
+	}}
+	d := Builder().WithExperiment(&expr.Experiment{Experiment: *e})
+	d.printWinnerAssessment()
+	assert.NoError(t, d.Error())
+	assert.Contains(t, d.description.String(), "Confidence in each version: v1: 0.025, v2: 0.975\n")
+}
+
+func TestPrintRewardScores(t *testing.T) {
+	conversion := v2alpha2.NewMetric("conversion", "default").Build()
+	revenue := v2alpha2.NewMetric("revenue", "default").Build()
+	e := v2alpha2.NewExperiment("test", "default").
+		WithTarget("target").
+		WithBaselineVersion("v1", nil).
+		WithCandidateVersion("v2", nil).
+		WithWeightedReward(*conversion, v2alpha2.PreferredDirectionHigher, "3").
+		WithReward(*revenue, v2alpha2.PreferredDirectionHigher).
+		Build()
+	winner := "v2"
+	e.Status.Analysis = &v2alpha2.Analysis{WinnerAssessment: &v2alpha2.WinnerAssessmentAnalysis{
+		Data: v2alpha2.WinnerAssessmentData{
+			WinnerFound: true,
+			Winner:      &winner,
+			Scores: map[string]v2alpha2.RewardScore{
+				"v1": {Score: resource.MustParse("0.25"), Rewards: map[string]resource.Quantity{"default/conversion": resource.MustParse("0"), "default/revenue": resource.MustParse("1")}},
+				"v2": {Score: resource.MustParse("0.75"), Rewards: map[string]resource.Quantity{"default/conversion": resource.MustParse("1"), "default/revenue": resource.MustParse("0")}},
+			},
+		},
+	}}
+	d := Builder().WithExperiment(&expr.Experiment{Experiment: *e})
+	d.printRewardScores()
+	assert.NoError(t, d.Error())
+	assert.Contains(t, d.description.String(), "Reward Scores")
+	assert.Contains(t, d.description.String(), "better) (weight 3)")
+	assert.Regexp(t, `Score\s*\|\s*0\.250\s*\|\s*0\.750`, d.description.String())
+}
+
 func TestPrintRewardAssessments(t *testing.T) {
 	for i := 1; i <= 12; i++ {
 		d := Builder().FromFile(utils.CompletePath("../", fmt.Sprintf("testdata/experiment%v.yaml", i)))
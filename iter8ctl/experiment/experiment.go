@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -17,6 +18,8 @@ import (
 	tasks "github.com/iter8-tools/etc3/taskrunner/core"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/inf.v0"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
@@ -60,7 +63,7 @@ var GetConfig = func() (*rest.Config, error) {
 }
 
 // GetClient constructs and returns a K8s client.
-// The returned client has experiment types registered.
+// The returned client has experiment, metric and core types registered.
 var GetClient = func() (rc client.Client, err error) {
 	var restConf *rest.Config
 	restConf, err = GetConfig()
@@ -73,7 +76,11 @@ var GetClient = func() (rc client.Client, err error) {
 		metav1.AddToGroupVersion(scheme, v2alpha2.GroupVersion)
 		scheme.AddKnownTypes(v2alpha2.GroupVersion, &v2alpha2.Experiment{})
 		scheme.AddKnownTypes(v2alpha2.GroupVersion, &v2alpha2.ExperimentList{})
-		return nil
+		scheme.AddKnownTypes(v2alpha2.GroupVersion, &v2alpha2.Metric{})
+		scheme.AddKnownTypes(v2alpha2.GroupVersion, &v2alpha2.MetricList{})
+		scheme.AddKnownTypes(v2alpha2.GroupVersion, &v2alpha2.ClusterMetric{})
+		scheme.AddKnownTypes(v2alpha2.GroupVersion, &v2alpha2.ClusterMetricList{})
+		return corev1.AddToScheme(scheme)
 	}
 
 	var schemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
@@ -227,6 +234,7 @@ func GetMetricNameAndUnits(metricInfo v2alpha2.MetricInfo) string {
 }
 
 // ConditionFromObjective returns a string representation of condition in the objective
+// Limits relative to the baseline are rendered as "<= baseline + 10%" (Percent) or "<= baseline + 0.500" (Delta).
 func ConditionFromObjective(objective v2alpha2.Objective) string {
 	c := ""
 	l := ""
@@ -245,6 +253,26 @@ func ConditionFromObjective(objective v2alpha2.Objective) string {
 		c = l + "; " + u
 	}
 
+	if r := objective.Relative; r != nil {
+		relative := func(limit *resource.Quantity) string {
+			if r.GetType() == v2alpha2.RelativeLimitPercent {
+				return fmt.Sprintf("%g%%", limit.AsApproximateFloat64())
+			}
+			return new(inf.Dec).Round(limit.AsDec(), 3, inf.RoundCeil).String()
+		}
+		conditions := []string{}
+		if c != "" {
+			conditions = append(conditions, c)
+		}
+		if r.LowerLimit != nil {
+			conditions = append(conditions, ">= baseline - "+relative(r.LowerLimit))
+		}
+		if r.UpperLimit != nil {
+			conditions = append(conditions, "<= baseline + "+relative(r.UpperLimit))
+		}
+		c = strings.Join(conditions, "; ")
+	}
+
 	return c
 }
 
@@ -285,6 +313,9 @@ func StringifyReward(reward v2alpha2.Reward) string {
 	} else {
 		r += " (lower better)"
 	}
+	if reward.Weight != nil {
+		r += fmt.Sprintf(" (weight %s)", reward.Weight.String())
+	}
 	return r
 }
 
@@ -12,6 +12,7 @@ import (
 	tasks "github.com/iter8-tools/etc3/taskrunner/core"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // getExp is a helper function for extracting an experiment object from experiment filenamePrefix
@@ -113,6 +114,17 @@ func TestConditionFromObjective(t *testing.T) {
 	assert.Equal(t, objectives, objs)
 }
 
+func TestConditionFromRelativeObjective(t *testing.T) {
+	upper, lower, absolute := resource.MustParse("12.5"), resource.MustParse("0.01"), resource.MustParse("500")
+	delta := v2alpha2.RelativeLimitDelta
+	assert.Equal(t,
+		"<= baseline + 12.5%",
+		ConditionFromObjective(v2alpha2.Objective{Metric: "latency", Relative: &v2alpha2.RelativeLimits{UpperLimit: &upper}}))
+	assert.Equal(t,
+		"<= 500.000; >= baseline - 0.010",
+		ConditionFromObjective(v2alpha2.Objective{Metric: "latency", UpperLimit: &absolute, Relative: &v2alpha2.RelativeLimits{Type: &delta, LowerLimit: &lower}}))
+}
+
 func TestStringifyReward(t *testing.T) {
 	assert.Equal(t,
 		"reward (lower better)",
@@ -120,6 +132,10 @@ func TestStringifyReward(t *testing.T) {
 	assert.Equal(t,
 		"reward (higher better)",
 		StringifyReward(v2alpha2.Reward{Metric: "reward", PreferredDirection: "High"}))
+	weight := resource.MustParse("2")
+	assert.Equal(t,
+		"reward (higher better) (weight 2)",
+		StringifyReward(v2alpha2.Reward{Metric: "reward", PreferredDirection: "High", Weight: &weight}))
 }
 
 func TestGetAnnotatedMetricStrs(t *testing.T) {
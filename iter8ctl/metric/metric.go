@@ -0,0 +1,259 @@
+// Package metric implements the `iter8ctl metric test` subcommand.
+package metric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	expr "github.com/iter8-tools/etc3/iter8ctl/experiment"
+	"github.com/iter8-tools/etc3/metrics"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Result is the outcome of testing a metric for a version.
+type Result struct {
+	// Version is the name of the version
+	Version string
+	// Query is the rendered query
+	Query string
+	// Value is the value of the metric, if the query was executed successfully
+	Value *float64
+	// Percentiles are the percentiles of a Histogram metric, if the query was executed successfully
+	Percentiles map[int32]float64
+	// Err is the error encountered rendering or executing the query, if any
+	Err error
+}
+
+// Tester renders, and optionally executes, the query of a metric for the versions of an experiment.
+type Tester struct {
+	// Metric is the metric tested
+	Metric *v2alpha2.Metric
+	// Experiment supplies the versions and their variables
+	Experiment *expr.Experiment
+	// Secret is the data of the secret referenced by the metric, if any
+	Secret map[string][]byte
+	// Execute indicates whether the query is sent to the provider
+	Execute bool
+	// Client is the HTTP client used to execute the query; if nil, a default client is used
+	Client *http.Client
+	// Now is the time of the test; elapsedTime is computed relative to it
+	Now time.Time
+}
+
+// Test tests the metric for the named version, or for every version of the experiment if version is empty.
+func (t *Tester) Test(ctx context.Context, version string) ([]Result, error) {
+	if t.Experiment.Spec.VersionInfo == nil {
+		return nil, errors.New("experiment has no versionInfo")
+	}
+	vi := t.Experiment.Spec.VersionInfo
+	versions := append([]v2alpha2.VersionDetail{vi.Baseline}, vi.Candidates...)
+	results := []Result{}
+	for _, v := range versions {
+		if version == "" || v.Name == version {
+			results = append(results, t.test(ctx, v))
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("version %s not found in experiment %s", version, t.Experiment.Name)
+	}
+	return results, nil
+}
+
+// environment returns the information about a version used to render the query, as the analytics service would.
+func (t *Tester) environment(v v2alpha2.VersionDetail) metrics.Environment {
+	start := t.Now
+	if t.Experiment.Status.StartTime != nil {
+		start = t.Experiment.Status.StartTime.Time
+	}
+	env := metrics.Environment{
+		Version:   v.Name,
+		Variables: map[string]string{},
+		Secret:    t.Secret,
+		StartTime: start,
+		Now:       t.Now,
+	}
+	for _, variable := range v.Variables {
+		env.Variables[variable.Name] = variable.Value
+	}
+	if a := t.Experiment.Status.Analysis; a != nil && a.AggregatedBuiltinHists != nil {
+		env.BuiltinHists = a.AggregatedBuiltinHists.Data.Raw
+	}
+	return env
+}
+
+// test tests the metric for a single version.
+func (t *Tester) test(ctx context.Context, v v2alpha2.VersionDetail) Result {
+	spec := t.Metric.Spec
+	env := t.environment(v)
+	result := Result{Version: v.Name}
+
+	// metrics evaluated by a built-in provider, including mocked metrics
+	if metrics.IsBuiltin(spec) {
+		result.Query = metrics.DescribeQuery(spec, env)
+		if t.Execute && spec.IsHistogram() {
+			buckets, err := metrics.EvaluateHistogram(ctx, *t.Metric, env)
+			result.setPercentiles(spec, buckets, err)
+		} else if t.Execute {
+			value, err := metrics.Evaluate(ctx, *t.Metric, env)
+			result.setValue(value, err)
+		}
+		return result
+	}
+
+	// Derived metrics are not queried; they are computed from the metrics they reference
+	if spec.IsDerived() {
+		result.Query = "derived: "
+		if spec.Expression != nil {
+			result.Query += *spec.Expression
+		}
+		for _, ref := range spec.References {
+			result.Query += fmt.Sprintf("\n%s: %s", ref.Name, ref.Metric)
+		}
+		if _, err := metrics.CompileExpression(spec); err != nil {
+			result.Err = err
+		}
+		return result
+	}
+
+	// templated HTTP metrics
+	req, err := metrics.RenderRequest(spec, env)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Query = req.String()
+	if !t.Execute {
+		return result
+	}
+	body, err := req.Do(ctx, t.Client)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	jq := ""
+	if spec.JQExpression != nil {
+		jq = *spec.JQExpression
+	}
+	if jq == "" {
+		jq = "."
+	}
+	result.setValue(metrics.ExtractValue(jq, body))
+	return result
+}
+
+// setValue records the value of the metric or the error encountered computing it
+func (r *Result) setValue(value float64, err error) {
+	if err != nil {
+		r.Err = err
+		return
+	}
+	r.Value = &value
+}
+
+// setPercentiles records the percentiles of a Histogram metric or the error encountered computing its distribution
+func (r *Result) setPercentiles(spec v2alpha2.MetricSpec, buckets []metrics.Bucket, err error) {
+	if err != nil {
+		r.Err = err
+		return
+	}
+	r.Percentiles = map[int32]float64{}
+	for _, p := range spec.GetPercentiles() {
+		value, err := metrics.Percentile(buckets, float64(p))
+		if err != nil {
+			r.Err = err
+			return
+		}
+		r.Percentiles[p] = value
+	}
+}
+
+// Print writes the results of a test.
+func Print(w io.Writer, m *v2alpha2.Metric, results []Result) {
+	if m.Namespace == "" {
+		fmt.Fprintf(w, "ClusterMetric: %s\n", m.Name)
+	} else {
+		fmt.Fprintf(w, "Metric: %s/%s\n", m.Namespace, m.Name)
+	}
+	for _, r := range results {
+		fmt.Fprintf(w, "\nVersion: %s\n", r.Version)
+		for _, line := range strings.Split(strings.TrimRight(r.Query, "\n"), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(w, "Error: %s\n", r.Err.Error())
+		case r.Value != nil:
+			fmt.Fprintf(w, "Value: %v\n", *r.Value)
+		case r.Percentiles != nil:
+			for _, p := range m.Spec.GetPercentiles() {
+				fmt.Fprintf(w, "%s: %v\n", v2alpha2.PercentileMetricName(m.Name, p), r.Percentiles[p])
+			}
+		}
+	}
+}
+
+// Failed returns true if testing the metric failed for any version.
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMetric gets the metric from the cluster, resolving the name as the controller does.
+// If the name is of the form "namespace/name", the metric is read from namespace.
+// Otherwise it is read from namespace or, if not found there, from the ClusterMetric with the name.
+func GetMetric(name string, namespace string) (*v2alpha2.Metric, error) {
+	qualified := false
+	if splt := strings.Split(name, "/"); len(splt) == 2 {
+		namespace, name = splt[0], splt[1]
+		qualified = true
+	}
+	rc, err := expr.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	m := &v2alpha2.Metric{}
+	err = rc.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, m)
+	if k8serrors.IsNotFound(err) && !qualified {
+		cm := &v2alpha2.ClusterMetric{}
+		if err = rc.Get(context.Background(), types.NamespacedName{Name: name}, cm); err == nil {
+			return cm.AsMetric(), nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get metric %s/%s: %s", namespace, name, err.Error())
+	}
+	return m, nil
+}
+
+// GetSecret gets the data of the secret referenced by the metric, if any.
+// The secret is named either "namespace/name" or "name"; in the latter case it is in the namespace of the metric.
+func GetSecret(m *v2alpha2.Metric) (map[string][]byte, error) {
+	if m.Spec.Secret == nil {
+		return nil, nil
+	}
+	key := types.NamespacedName{Namespace: m.Namespace, Name: *m.Spec.Secret}
+	if splt := strings.Split(*m.Spec.Secret, "/"); len(splt) == 2 {
+		key = types.NamespacedName{Namespace: splt[0], Name: splt[1]}
+	}
+	rc, err := expr.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	secret := &corev1.Secret{}
+	if err := rc.Get(context.Background(), key, secret); err != nil {
+		return nil, fmt.Errorf("unable to get secret %s: %s", key.String(), err.Error())
+	}
+	return secret.Data, nil
+}
@@ -0,0 +1,109 @@
+package metric
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	expr "github.com/iter8-tools/etc3/iter8ctl/experiment"
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func testExperiment() *expr.Experiment {
+	e := v2alpha2.NewExperiment("test", "default").
+		WithTarget("target").
+		WithBaselineVersion("v1", nil).
+		WithCandidateVersion("v2", nil).
+		Build()
+	e.Spec.VersionInfo.Baseline.Variables = []v2alpha2.NamedValue{{Name: "revision", Value: "rev-1"}}
+	e.Spec.VersionInfo.Candidates[0].Variables = []v2alpha2.NamedValue{{Name: "revision", Value: "rev-2"}}
+	return &expr.Experiment{Experiment: *e}
+}
+
+func TestRenderOnly(t *testing.T) {
+	m := v2alpha2.NewMetric("latency", "default").WithURLTemplate(stringPointer("http://prometheus:9090/api/v1/query")).
+		WithParams([]v2alpha2.NamedValue{{Name: "query", Value: "latency{revision='$revision'}"}}).Build()
+	tester := &Tester{Metric: m, Experiment: testExperiment(), Now: time.Now()}
+
+	results, err := tester.Test(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Contains(t, results[0].Query, "rev-1")
+	assert.Contains(t, results[1].Query, "rev-2")
+	assert.Nil(t, results[0].Value)
+	assert.False(t, Failed(results))
+
+	results, err = tester.Test(context.Background(), "v2")
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	_, err = tester.Test(context.Background(), "v3")
+	assert.EqualError(t, err, "version v3 not found in experiment test")
+}
+
+func TestExecute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == "rev-2" {
+			w.Write([]byte(`{"data":{"result":[]}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"result":[{"value":[1600000000,"12.5"]}]}}`))
+	}))
+	defer server.Close()
+
+	m := v2alpha2.NewMetric("latency", "default").WithURLTemplate(&server.URL).
+		WithJQExpression(stringPointer(".data.result[0].value[1] | tonumber")).
+		WithParams([]v2alpha2.NamedValue{{Name: "query", Value: "$revision"}}).Build()
+	tester := &Tester{Metric: m, Experiment: testExperiment(), Execute: true, Now: time.Now()}
+
+	results, err := tester.Test(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 12.5, *results[0].Value)
+	assert.Error(t, results[1].Err)
+	assert.True(t, Failed(results))
+
+	var out bytes.Buffer
+	Print(&out, m, results)
+	assert.Contains(t, out.String(), "Value: 12.5")
+	assert.Contains(t, out.String(), "Error: jqExpression")
+}
+
+func TestMock(t *testing.T) {
+	m := v2alpha2.NewMetric("latency", "default").Build()
+	m.Spec.Mock = []v2alpha2.NamedLevel{{Name: "v1", Level: resource.MustParse("20")}}
+	tester := &Tester{Metric: m, Experiment: testExperiment(), Execute: true, Now: time.Now()}
+
+	results, err := tester.Test(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, *results[0].Value)
+	assert.EqualError(t, results[1].Err, "no mock level for version v2")
+}
+
+func TestHistogram(t *testing.T) {
+	m := v2alpha2.NewMetric("latency", "default").WithProvider(v2alpha2.ProviderFortio).WithPercentiles(50, 90).Build()
+	e := testExperiment()
+	e.Status.Analysis = &v2alpha2.Analysis{AggregatedBuiltinHists: &v2alpha2.AggregatedBuiltinHists{
+		Data: apiextensionsv1.JSON{Raw: []byte(`{"v1":{"DurationHistogram":{"Data":[{"Start":0,"End":0.2,"Count":10}]}}}`)},
+	}}
+	tester := &Tester{Metric: m, Experiment: e, Execute: true, Now: time.Now()}
+
+	results, err := tester.Test(context.Background(), "")
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.1, results[0].Percentiles[50], 1e-9)
+	assert.InDelta(t, 0.18, results[0].Percentiles[90], 1e-9)
+	assert.Error(t, results[1].Err)
+
+	var out bytes.Buffer
+	Print(&out, m, results)
+	assert.Contains(t, out.String(), "latency-p50: 0.1\n")
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,7 +43,9 @@ import (
 
 	v2alpha2 "github.com/iter8-tools/etc3/api/v2alpha2"
 	"github.com/iter8-tools/etc3/controllers"
+	"github.com/iter8-tools/etc3/taskrunner/executor"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -54,6 +57,8 @@ var (
 const (
 	// Iter8Controller string constant used to label event recorder
 	Iter8Controller = "iter8"
+	// podLogTailLines is the number of lines of a failed handler pod's log searched for Iter8Logs
+	podLogTailLines = 100
 )
 
 func init() {
@@ -85,21 +90,44 @@ func (m *iter8Http) Post(url, contentType string, payload []byte) (resp []byte,
 
 type iter8JobManager struct {
 	Client client.Client
+	// Reader reads directly from the API server so that pods are not cached by the manager
+	Reader    client.Reader
+	Clientset kubernetes.Interface
 }
 
 func (j iter8JobManager) Get(ctx context.Context, ref types.NamespacedName, job *batchv1.Job) error {
 	return j.Client.Get(ctx, ref, job)
 }
 
+func (j iter8JobManager) GetPods(ctx context.Context, job *batchv1.Job) ([]corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	if err := j.Reader.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func (j iter8JobManager) GetPodLogs(ctx context.Context, pod *corev1.Pod, container string) ([]byte, error) {
+	tailLines := int64(podLogTailLines)
+	return j.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tailLines,
+	}).DoRaw(ctx)
+}
+
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var actionWorkers int
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&actionWorkers, "action-workers", executor.DefaultWorkers,
+		"The number of actions the controller executes concurrently in-process. "+
+			"Set to 0 to execute all actions in handler jobs.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -134,6 +162,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	// actions that request it are executed in-process instead of in handler jobs
+	var actionExecutor controllers.ActionExecutor
+	if actionWorkers > 0 {
+		ex := executor.New(actionWorkers, executor.DefaultQueueSize)
+		if err := mgr.Add(ex); err != nil {
+			setupLog.Error(err, "unable to add action executor")
+			os.Exit(1)
+		}
+		actionExecutor = ex
+	}
+
+	// changes to the objects that determine the weights of versions trigger reconciliation
+	releaseEvents := make(chan event.GenericEvent)
+	weightWatcher, err := controllers.NewWeightWatcher(restCfg, releaseEvents)
+	if err != nil {
+		setupLog.Error(err, "unable to create weight watcher")
+		os.Exit(1)
+	}
+	if err := mgr.Add(weightWatcher); err != nil {
+		setupLog.Error(err, "unable to add weight watcher")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.ExperimentReconciler{
 		Client:        mgr.GetClient(),
 		Log:           ctrl.Log.WithName("controllers").WithName("Experiment"),
@@ -142,10 +193,14 @@ func main() {
 		EventRecorder: mgr.GetEventRecorderFor(Iter8Controller),
 		Iter8Config:   cfg,
 		HTTP:          &iter8Http{},
-		ReleaseEvents: make(chan event.GenericEvent),
+		ReleaseEvents: releaseEvents,
 		JobManager: iter8JobManager{
-			Client: mgr.GetClient(),
+			Client:    mgr.GetClient(),
+			Reader:    mgr.GetAPIReader(),
+			Clientset: kubernetes.NewForConfigOrDie(mgr.GetConfig()),
 		},
+		ActionExecutor: actionExecutor,
+		WeightWatcher:  weightWatcher,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Experiment")
 		os.Exit(1)
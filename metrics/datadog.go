@@ -0,0 +1,88 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// Datadog evaluates metrics using the Datadog metrics query API
+// The API and application keys are read from the keys apiKey and appKey of the secret of the metric.
+type Datadog struct {
+	// Client is the HTTP client; if nil, a default client is used
+	Client *http.Client
+}
+
+// datadogResponse is the subset of a Datadog query response used by the provider
+type datadogResponse struct {
+	Status string   `json:"status"`
+	Errors []string `json:"errors"`
+	Error  string   `json:"error"`
+	Series []struct {
+		Pointlist [][]*float64 `json:"pointlist"`
+	} `json:"series"`
+}
+
+// Query evaluates a metrics query over the duration of the experiment
+// The value is the latest point of the first series returned.
+func (p *Datadog) Query(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) (float64, error) {
+	if spec.Datadog == nil {
+		return 0, errors.New("datadog configuration missing")
+	}
+	params := url.Values{}
+	params.Set("query", Interpolate(spec.Datadog.Query, env))
+	params.Set("from", strconv.FormatInt(env.StartTime.Unix(), 10))
+	params.Set("to", strconv.FormatInt(env.Now.Unix(), 10))
+	u := strings.TrimSuffix(spec.Datadog.GetURL(), "/") + "/api/v1/query?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("DD-API-KEY", string(env.Secret["apiKey"]))
+	req.Header.Set("DD-APPLICATION-KEY", string(env.Secret["appKey"]))
+	resp, err := httpClient(p.Client).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	response := datadogResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("unable to decode response from datadog: %s", err.Error())
+	}
+	if resp.StatusCode >= 400 || response.Status == "error" {
+		return 0, fmt.Errorf("datadog query failed: %s %s", response.Error, strings.Join(response.Errors, "; "))
+	}
+	if len(response.Series) == 0 {
+		return 0, ErrNoData
+	}
+	points := response.Series[0].Pointlist
+	for i := len(points) - 1; i >= 0; i-- {
+		if len(points[i]) == 2 && points[i][1] != nil {
+			return *points[i][1], nil
+		}
+	}
+	return 0, ErrNoData
+}
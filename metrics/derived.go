@@ -0,0 +1,163 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+	"github.com/antonmedv/expr/vm"
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// baselineIdentifier is the identifier used in expressions to refer to the values of the baseline
+const baselineIdentifier = "baseline"
+
+// Expression is the compiled expression of a Derived metric
+type Expression struct {
+	program *vm.Program
+
+	// Uses are the names of the references whose values for the version are used
+	Uses []string
+
+	// BaselineUses are the names of the references whose values for the baseline are used
+	BaselineUses []string
+}
+
+// identifiers collects the identifiers used in an expression
+type identifiers struct {
+	uses         map[string]bool
+	baselineUses map[string]bool
+}
+
+// Enter records identifiers and properties of the baseline identifier
+func (v *identifiers) Enter(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		v.uses[n.Value] = true
+	case *ast.PropertyNode:
+		if id, ok := n.Node.(*ast.IdentifierNode); ok && id.Value == baselineIdentifier {
+			v.baselineUses[n.Property] = true
+		}
+	}
+}
+
+// Exit ..
+func (v *identifiers) Exit(node *ast.Node) {}
+
+// CompileExpression compiles the expression of a Derived metric
+// Every identifier in the expression must be the name of a reference, or baseline.<name of a reference>.
+func CompileExpression(spec v2alpha2.MetricSpec) (*Expression, error) {
+	if spec.Expression == nil || *spec.Expression == "" {
+		return nil, errors.New("a Derived metric requires an expression")
+	}
+	names := map[string]bool{}
+	for _, ref := range spec.References {
+		if ref.Name == baselineIdentifier {
+			return nil, fmt.Errorf("%s may not be used as the name of a reference", baselineIdentifier)
+		}
+		if names[ref.Name] {
+			return nil, fmt.Errorf("reference %s is defined more than once", ref.Name)
+		}
+		names[ref.Name] = true
+	}
+
+	tree, err := parser.Parse(*spec.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %s", err.Error())
+	}
+	v := &identifiers{uses: map[string]bool{}, baselineUses: map[string]bool{}}
+	ast.Walk(&tree.Node, v)
+	delete(v.uses, baselineIdentifier)
+
+	e := &Expression{}
+	for name := range v.uses {
+		if !names[name] {
+			return nil, fmt.Errorf("unknown reference %s in expression", name)
+		}
+		e.Uses = append(e.Uses, name)
+	}
+	for name := range v.baselineUses {
+		if !names[name] {
+			return nil, fmt.Errorf("unknown reference %s.%s in expression", baselineIdentifier, name)
+		}
+		e.BaselineUses = append(e.BaselineUses, name)
+	}
+	sort.Strings(e.Uses)
+	sort.Strings(e.BaselineUses)
+
+	e.program, err = expr.Compile(*spec.Expression, expr.Env(environment(names, names)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %s", err.Error())
+	}
+	return e, nil
+}
+
+// environment returns the environment in which an expression is evaluated
+func environment(values map[string]bool, baseline map[string]bool) map[string]interface{} {
+	env := map[string]interface{}{}
+	for name := range values {
+		env[name] = float64(0)
+	}
+	b := map[string]interface{}{}
+	for name := range baseline {
+		b[name] = float64(0)
+	}
+	env[baselineIdentifier] = b
+	return env
+}
+
+// Evaluate evaluates the expression given the values of the references for a version and for the baseline
+// ErrNoData is returned if a value used by the expression is not available
+// or if the expression does not produce a finite number (for example, 0/0 when there is no traffic).
+func (e *Expression) Evaluate(values map[string]float64, baseline map[string]float64) (float64, error) {
+	env := map[string]interface{}{}
+	for _, name := range e.Uses {
+		v, ok := values[name]
+		if !ok {
+			return 0, ErrNoData
+		}
+		env[name] = v
+	}
+	b := map[string]interface{}{}
+	for _, name := range e.BaselineUses {
+		v, ok := baseline[name]
+		if !ok {
+			return 0, ErrNoData
+		}
+		b[name] = v
+	}
+	env[baselineIdentifier] = b
+
+	out, err := expr.Run(e.program, env)
+	if err != nil {
+		return 0, err
+	}
+	switch value := out.(type) {
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, ErrNoData
+		}
+		return value, nil
+	case int:
+		return float64(value), nil
+	}
+	return 0, fmt.Errorf("expression produced %v; expected a number", out)
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func derivedSpec(expression string, names ...string) v2alpha2.MetricSpec {
+	references := []v2alpha2.MetricReference{}
+	for _, name := range names {
+		references = append(references, v2alpha2.MetricReference{Name: name, Metric: name})
+	}
+	return v2alpha2.NewMetric("derived", "default").WithExpression(expression, references...).Build().Spec
+}
+
+func TestDerivedRatio(t *testing.T) {
+	e, err := CompileExpression(derivedSpec("errors / requests", "errors", "requests"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"errors", "requests"}, e.Uses)
+
+	value, err := e.Evaluate(map[string]float64{"errors": 5, "requests": 50}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.1, value)
+
+	_, err = e.Evaluate(map[string]float64{"errors": 5}, nil)
+	assert.Equal(t, ErrNoData, err)
+}
+
+func TestDerivedNotFinite(t *testing.T) {
+	e, err := CompileExpression(derivedSpec("errors / requests", "errors", "requests"))
+	assert.NoError(t, err)
+
+	_, err = e.Evaluate(map[string]float64{"errors": 0, "requests": 0}, nil)
+	assert.Equal(t, ErrNoData, err)
+
+	_, err = e.Evaluate(map[string]float64{"errors": 5, "requests": 0}, nil)
+	assert.Equal(t, ErrNoData, err)
+}
+
+func TestDerivedBaseline(t *testing.T) {
+	e, err := CompileExpression(derivedSpec("latency - baseline.latency", "latency"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"latency"}, e.BaselineUses)
+
+	value, err := e.Evaluate(map[string]float64{"latency": 120}, map[string]float64{"latency": 100})
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value)
+
+	_, err = e.Evaluate(map[string]float64{"latency": 120}, nil)
+	assert.Equal(t, ErrNoData, err)
+}
+
+func TestDerivedConstant(t *testing.T) {
+	e, err := CompileExpression(derivedSpec("100 * errors", "errors"))
+	assert.NoError(t, err)
+	value, err := e.Evaluate(map[string]float64{"errors": 0.5}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, value)
+}
+
+func TestDerivedInvalid(t *testing.T) {
+	_, err := CompileExpression(derivedSpec("", "errors"))
+	assert.EqualError(t, err, "a Derived metric requires an expression")
+
+	_, err = CompileExpression(derivedSpec("errors / requests", "errors"))
+	assert.EqualError(t, err, "unknown reference requests in expression")
+
+	_, err = CompileExpression(derivedSpec("errors - baseline.requests", "errors"))
+	assert.EqualError(t, err, "unknown reference baseline.requests in expression")
+
+	_, err = CompileExpression(derivedSpec("errors", "errors", "errors"))
+	assert.EqualError(t, err, "reference errors is defined more than once")
+
+	_, err = CompileExpression(derivedSpec("baseline", "baseline"))
+	assert.EqualError(t, err, "baseline may not be used as the name of a reference")
+
+	_, err = CompileExpression(derivedSpec("errors /", "errors"))
+	assert.Error(t, err)
+
+	e, err := CompileExpression(derivedSpec(`errors > 1 ? "high" : "low"`, "errors"))
+	assert.NoError(t, err)
+	_, err = e.Evaluate(map[string]float64{"errors": 2}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// File evaluates metrics by reading them from a CSV file; it is intended for tests
+type File struct{}
+
+// readRows reads the file and returns its rows (without the header) and the index of each named column
+func (p *File) readRows(spec v2alpha2.MetricSpec, env Environment, columns ...string) ([][]string, map[string]int, error) {
+	f, err := os.Open(Interpolate(spec.File.Path, env))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, ErrNoData
+	}
+	index := map[string]int{}
+	for i, column := range rows[0] {
+		index[column] = i
+	}
+	for _, column := range columns {
+		if _, ok := index[column]; !ok {
+			return nil, nil, fmt.Errorf("columns %s are required", strings.Join(columns, ", "))
+		}
+	}
+	return rows[1:], index, nil
+}
+
+// Query returns the value in the last row of the file for the version
+func (p *File) Query(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) (float64, error) {
+	if spec.File == nil {
+		return 0, errors.New("file configuration missing")
+	}
+	rows, index, err := p.readRows(spec, env, spec.File.GetVersionColumn(), spec.File.GetValueColumn())
+	if err != nil {
+		return 0, err
+	}
+	versionColumn, valueColumn := index[spec.File.GetVersionColumn()], index[spec.File.GetValueColumn()]
+	for i := len(rows) - 1; i >= 0; i-- {
+		if rows[i][versionColumn] == env.Version {
+			return strconv.ParseFloat(rows[i][valueColumn], 64)
+		}
+	}
+	return 0, ErrNoData
+}
+
+// QueryHistogram returns the buckets of the version; each row is a bucket whose count is in the value column
+func (p *File) QueryHistogram(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) ([]Bucket, error) {
+	if spec.File == nil {
+		return nil, errors.New("file configuration missing")
+	}
+	rows, index, err := p.readRows(spec, env, spec.File.GetVersionColumn(), spec.File.GetLowerColumn(), spec.File.GetUpperColumn(), spec.File.GetValueColumn())
+	if err != nil {
+		return nil, err
+	}
+	buckets := []Bucket{}
+	for _, row := range rows {
+		if row[index[spec.File.GetVersionColumn()]] != env.Version {
+			continue
+		}
+		bucket := Bucket{}
+		for column, value := range map[string]*float64{
+			spec.File.GetLowerColumn(): &bucket.Lower,
+			spec.File.GetUpperColumn(): &bucket.Upper,
+			spec.File.GetValueColumn(): &bucket.Count,
+		} {
+			if *value, err = strconv.ParseFloat(row[index[column]], 64); err != nil {
+				return nil, err
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	if len(buckets) == 0 {
+		return nil, ErrNoData
+	}
+	return buckets, nil
+}
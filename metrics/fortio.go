@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// Fortio returns the latency histograms collected by the builtin metrics/collect task
+// Durations are in seconds.
+type Fortio struct{}
+
+// fortioResult is the subset of the result of the metrics/collect task for a version used by the provider
+type fortioResult struct {
+	DurationHistogram struct {
+		Data []struct {
+			Start float64
+			End   float64
+			Count int
+		}
+	}
+}
+
+// Query is not supported; the fortio provider is used only for Histogram metrics
+func (p *Fortio) Query(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) (float64, error) {
+	return 0, errors.New("the fortio provider supports only Histogram metrics")
+}
+
+// QueryHistogram returns the latency histogram of the version
+func (p *Fortio) QueryHistogram(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) ([]Bucket, error) {
+	if len(env.BuiltinHists) == 0 {
+		return nil, ErrNoData
+	}
+	results := map[string]fortioResult{}
+	if err := json.Unmarshal(env.BuiltinHists, &results); err != nil {
+		return nil, fmt.Errorf("unable to decode builtin histograms: %s", err.Error())
+	}
+	result, ok := results[env.Version]
+	if !ok || len(result.DurationHistogram.Data) == 0 {
+		return nil, ErrNoData
+	}
+	buckets := []Bucket{}
+	for _, sample := range result.DurationHistogram.Data {
+		buckets = append(buckets, Bucket{Lower: sample.Start, Upper: sample.End, Count: float64(sample.Count)})
+	}
+	return buckets, nil
+}
@@ -0,0 +1,105 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// Bucket is a bucket of the distribution of a Histogram metric
+type Bucket struct {
+	// Lower is the lower bound of the bucket
+	Lower float64
+
+	// Upper is the upper bound of the bucket; it may be +Inf
+	Upper float64
+
+	// Count is the number of observations in the bucket
+	Count float64
+}
+
+// HistogramProvider is a provider that returns the distribution of Histogram metrics
+type HistogramProvider interface {
+	QueryHistogram(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) ([]Bucket, error)
+}
+
+// EvaluateHistogram evaluates a Histogram metric for a version using its built-in provider
+// The buckets are returned in increasing order.
+func EvaluateHistogram(ctx context.Context, metric v2alpha2.Metric, env Environment) ([]Bucket, error) {
+	provider, ok := GetProvider(metric.Spec).(HistogramProvider)
+	if !ok {
+		return nil, fmt.Errorf("metric %s is not evaluated by a built-in provider that supports histograms", metric.Name)
+	}
+	buckets, err := provider.QueryHistogram(ctx, metric.Spec, env)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(buckets, func(i, j int) bool { return buckets[i].Lower < buckets[j].Lower })
+	return buckets, nil
+}
+
+// Percentile returns the percentile of a distribution
+// Observations are assumed to be uniformly distributed within a bucket; the percentile is interpolated accordingly.
+// Within a bucket with no upper bound, the lower bound is used.
+func Percentile(buckets []Bucket, percentile float64) (float64, error) {
+	total := 0.0
+	for _, b := range buckets {
+		total += b.Count
+	}
+	if total <= 0 {
+		return 0, ErrNoData
+	}
+	target := percentile / 100 * total
+	seen := 0.0
+	for _, b := range buckets {
+		if b.Count <= 0 {
+			continue
+		}
+		if seen+b.Count >= target {
+			if math.IsInf(b.Upper, 1) {
+				return b.Lower, nil
+			}
+			return b.Lower + (b.Upper-b.Lower)*(target-seen)/b.Count, nil
+		}
+		seen += b.Count
+	}
+	last := buckets[len(buckets)-1]
+	if math.IsInf(last.Upper, 1) {
+		return last.Lower, nil
+	}
+	return last.Upper, nil
+}
+
+// cumulativeBuckets converts cumulative counts, by upper bound, into buckets
+// The first bucket starts at zero.
+func cumulativeBuckets(cumulative map[float64]float64) []Bucket {
+	bounds := []float64{}
+	for le := range cumulative {
+		bounds = append(bounds, le)
+	}
+	sort.Float64s(bounds)
+	buckets := []Bucket{}
+	lower, seen := 0.0, 0.0
+	for _, le := range bounds {
+		buckets = append(buckets, Bucket{Lower: lower, Upper: le, Count: math.Max(cumulative[le]-seen, 0)})
+		lower, seen = le, cumulative[le]
+	}
+	return buckets
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	buckets := []Bucket{
+		{Lower: 0, Upper: 10, Count: 50},
+		{Lower: 10, Upper: 20, Count: 40},
+		{Lower: 20, Upper: math.Inf(1), Count: 10},
+	}
+	for p, expected := range map[float64]float64{50: 10, 25: 5, 70: 15, 95: 20, 100: 20} {
+		value, err := Percentile(buckets, p)
+		assert.NoError(t, err)
+		assert.InDelta(t, expected, value, 1e-9, "p%v", p)
+	}
+
+	_, err := Percentile([]Bucket{{Lower: 0, Upper: 10}}, 50)
+	assert.Equal(t, ErrNoData, err)
+}
+
+func TestPrometheusHistogram(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"le":"+Inf"},"value":[1600000000,"100"]},
+			{"metric":{"le":"0.1"},"value":[1600000000,"50"]},
+			{"metric":{"le":"0.5"},"value":[1600000000,"90"]}]}}`))
+	}))
+	defer server.Close()
+
+	m := v2alpha2.NewMetric("latency", "default").WithPrometheus(v2alpha2.PrometheusProvider{URL: server.URL}).WithPercentiles(50, 95).Build()
+	buckets, err := EvaluateHistogram(context.Background(), *m, testEnvironment())
+	assert.NoError(t, err)
+	assert.Equal(t, []Bucket{
+		{Lower: 0, Upper: 0.1, Count: 50},
+		{Lower: 0.1, Upper: 0.5, Count: 40},
+		{Lower: 0.5, Upper: math.Inf(1), Count: 10},
+	}, buckets)
+}
+
+func TestFileHistogram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "latency.csv")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("version,lower,upper,value\nv1,10,20,5\nv2,0,10,3\nv1,0,10,1\n"), 0644))
+
+	m := v2alpha2.NewMetric("latency", "default").WithFile(v2alpha2.FileProvider{Path: path}).WithPercentiles().Build()
+	buckets, err := EvaluateHistogram(context.Background(), *m, testEnvironment())
+	assert.NoError(t, err)
+	assert.Equal(t, []Bucket{{Lower: 0, Upper: 10, Count: 1}, {Lower: 10, Upper: 20, Count: 5}}, buckets)
+}
+
+func TestFortio(t *testing.T) {
+	m := v2alpha2.NewMetric("latency", "default").WithProvider(v2alpha2.ProviderFortio).WithPercentiles().Build()
+	assert.True(t, IsBuiltin(m.Spec))
+
+	env := testEnvironment()
+	_, err := EvaluateHistogram(context.Background(), *m, env)
+	assert.Equal(t, ErrNoData, err)
+
+	env.BuiltinHists = []byte(`{"v1":{"DurationHistogram":{"Count":3,"Data":[{"Start":0.001,"End":0.002,"Count":2},{"Start":0.002,"End":0.004,"Count":1}]}}}`)
+	buckets, err := EvaluateHistogram(context.Background(), *m, env)
+	assert.NoError(t, err)
+	assert.Equal(t, []Bucket{{Lower: 0.001, Upper: 0.002, Count: 2}, {Lower: 0.002, Upper: 0.004, Count: 1}}, buckets)
+
+	_, err = Evaluate(context.Background(), *m, env)
+	assert.Error(t, err)
+}
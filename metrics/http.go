@@ -0,0 +1,233 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/itchyny/gojq"
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// redacted replaces header values that are computed from the secret of a metric when a request is printed
+const redacted = "<redacted>"
+
+// Request is the HTTP request of a templated metric, rendered for a version
+// The templates are rendered in the same way as by the analytics service:
+//   - params and body are rendered using the version information ($name, $elapsedTime and the variables of the version)
+//   - the URL and header templates are rendered as Go templates using the data of the secret, if any
+type Request struct {
+	// Method is the HTTP method
+	Method string
+
+	// URL is the URL, including the params
+	URL string
+
+	// Header are the headers, including those required by the authentication type
+	Header http.Header
+
+	// Body is the body of a POST request
+	Body string
+
+	// sensitive are the headers computed from the secret
+	sensitive map[string]bool
+}
+
+// RenderRequest renders the HTTP request of a templated metric for a version
+func RenderRequest(spec v2alpha2.MetricSpec, env Environment) (*Request, error) {
+	if spec.URLTemplate == nil {
+		return nil, errors.New("urlTemplate is required")
+	}
+	req := &Request{
+		Method:    string(v2alpha2.GETMethodType),
+		Header:    http.Header{},
+		sensitive: map[string]bool{},
+	}
+	if spec.Method != nil {
+		req.Method = string(*spec.Method)
+	}
+
+	secret := map[string]string{}
+	for k, v := range env.Secret {
+		secret[k] = string(v)
+	}
+
+	u, err := renderSecretTemplate("urlTemplate", *spec.URLTemplate, spec.Secret != nil, secret)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("urlTemplate: %s", err.Error())
+	}
+	params := parsed.Query()
+	for _, p := range spec.Params {
+		params.Add(p.Name, Interpolate(p.Value, env))
+	}
+	parsed.RawQuery = params.Encode()
+	req.URL = parsed.String()
+
+	for _, h := range spec.HeaderTemplates {
+		value, err := renderSecretTemplate("headerTemplates "+h.Name, h.Value, spec.Secret != nil, secret)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(h.Name, value)
+		req.sensitive[http.CanonicalHeaderKey(h.Name)] = value != h.Value
+	}
+
+	if spec.AuthType != nil {
+		if err := req.authenticate(*spec.AuthType, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec.Body != nil {
+		req.Body = Interpolate(*spec.Body, env)
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	return req, nil
+}
+
+// renderSecretTemplate renders a template using the data of the secret
+// Templates are rendered only if the metric references a secret; a missing key is an error.
+func renderSecretTemplate(field string, text string, hasSecret bool, secret map[string]string) (string, error) {
+	if !hasSecret {
+		return text, nil
+	}
+	t, err := template.New(field).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", field, err.Error())
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, secret); err != nil {
+		return "", fmt.Errorf("%s: %s", field, err.Error())
+	}
+	return b.String(), nil
+}
+
+// authenticate adds the headers required by the authentication type
+// Basic authentication uses the keys username and password of the secret; Bearer uses the key token.
+// APIKey authentication is configured using the header templates.
+func (r *Request) authenticate(authType v2alpha2.AuthType, secret map[string]string) error {
+	switch authType {
+	case v2alpha2.BasicAuthType:
+		username, ok1 := secret["username"]
+		password, ok2 := secret["password"]
+		if !ok1 || !ok2 {
+			return errors.New("basic authentication requires the keys username and password in the secret")
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(username, password)
+		r.Header.Set("Authorization", req.Header.Get("Authorization"))
+	case v2alpha2.BearerAuthType:
+		token, ok := secret["token"]
+		if !ok {
+			return errors.New("bearer authentication requires the key token in the secret")
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+	case v2alpha2.APIKeyAuthType:
+		return nil
+	default:
+		return fmt.Errorf("unsupported authType %s", authType)
+	}
+	r.sensitive["Authorization"] = true
+	return nil
+}
+
+// String returns the request with the headers computed from the secret redacted
+func (r *Request) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", r.Method, r.URL)
+	names := []string{}
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if r.sensitive[name] {
+			value = redacted
+		}
+		fmt.Fprintf(&b, "%s: %s\n", name, value)
+	}
+	if r.Body != "" {
+		fmt.Fprintf(&b, "\n%s\n", r.Body)
+	}
+	return b.String()
+}
+
+// Do sends the request and returns the body of the response
+func (r *Request) Do(ctx context.Context, client *http.Client) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, strings.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+	resp, err := httpClient(client).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return body, fmt.Errorf("query failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// ExtractValue applies a jq expression to a JSON response and returns the resulting value
+// The expression must produce a single number, or a string that is a number.
+func ExtractValue(expression string, body []byte) (float64, error) {
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return 0, fmt.Errorf("jqExpression: %s", err.Error())
+	}
+	var input interface{}
+	if err := json.Unmarshal(body, &input); err != nil {
+		return 0, fmt.Errorf("response is not JSON: %s", err.Error())
+	}
+	iter := query.Run(input)
+	v, ok := iter.Next()
+	if !ok {
+		return 0, errors.New("jqExpression produced no value")
+	}
+	if err, ok := v.(error); ok {
+		return 0, fmt.Errorf("jqExpression: %s", err.Error())
+	}
+	if i, ok := v.(int); ok {
+		return float64(i), nil
+	}
+	value, err := parseValue(v)
+	if err == ErrNoData {
+		return 0, errors.New("jqExpression produced null")
+	}
+	return value, err
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderRequest(t *testing.T) {
+	secret := "creds"
+	authType := v2alpha2.BearerAuthType
+	spec := v2alpha2.MetricSpec{
+		URLTemplate:     stringPointer("https://{{.host}}/api/v1/query"),
+		Params:          []v2alpha2.NamedValue{{Name: "query", Value: "sum(x{revision='$revision'}[${elapsedTime}s])"}},
+		HeaderTemplates: []v2alpha2.NamedValue{{Name: "X-Scope", Value: "{{.scope}}"}, {Name: "Accept", Value: "application/json"}},
+		AuthType:        &authType,
+		Secret:          &secret,
+	}
+	env := testEnvironment()
+	env.Secret = map[string][]byte{"host": []byte("prom.example.com"), "scope": []byte("team"), "token": []byte("t0k3n")}
+
+	req, err := RenderRequest(spec, env)
+	assert.NoError(t, err)
+	u, _ := url.Parse(req.URL)
+	assert.Equal(t, "prom.example.com", u.Host)
+	assert.Equal(t, "sum(x{revision='rev-1'}[90s])", u.Query().Get("query"))
+	assert.Equal(t, "team", req.Header.Get("X-Scope"))
+	assert.Equal(t, "Bearer t0k3n", req.Header.Get("Authorization"))
+	assert.NotContains(t, req.String(), "t0k3n")
+	assert.NotContains(t, req.String(), "team")
+	assert.Contains(t, req.String(), "Accept: application/json")
+}
+
+func TestRenderRequestErrors(t *testing.T) {
+	secret := "creds"
+	_, err := RenderRequest(v2alpha2.MetricSpec{}, testEnvironment())
+	assert.EqualError(t, err, "urlTemplate is required")
+
+	_, err = RenderRequest(v2alpha2.MetricSpec{URLTemplate: stringPointer("https://{{.host}}"), Secret: &secret}, Environment{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "urlTemplate")
+
+	authType := v2alpha2.BasicAuthType
+	_, err = RenderRequest(v2alpha2.MetricSpec{URLTemplate: stringPointer("https://host"), AuthType: &authType}, Environment{})
+	assert.EqualError(t, err, "basic authentication requires the keys username and password in the secret")
+
+	// without a secret, templates are not rendered
+	req, err := RenderRequest(v2alpha2.MetricSpec{URLTemplate: stringPointer("https://host/{{.path}}")}, Environment{})
+	assert.NoError(t, err)
+	assert.Contains(t, req.URL, "%7B%7B.path%7D%7D")
+}
+
+func TestRequestDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"parse error"}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"result":[{"value":[1600000000,"3.5"]}]}}`))
+	}))
+	defer server.Close()
+
+	spec := v2alpha2.MetricSpec{URLTemplate: &server.URL, Params: []v2alpha2.NamedValue{{Name: "query", Value: "good"}}}
+	req, err := RenderRequest(spec, testEnvironment())
+	assert.NoError(t, err)
+	body, err := req.Do(context.Background(), nil)
+	assert.NoError(t, err)
+	value, err := ExtractValue(".data.result[0].value[1] | tonumber", body)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.5, value)
+
+	spec.Params[0].Value = "bad"
+	req, _ = RenderRequest(spec, testEnvironment())
+	_, err = req.Do(context.Background(), nil)
+	assert.EqualError(t, err, `query failed with status 400: {"error":"parse error"}`)
+}
+
+func TestMeasure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"result":[{"value":[1600000000,"0.02"]}]}}`))
+	}))
+	defer server.Close()
+
+	jq := ".data.result[0].value[1] | tonumber"
+	metric := v2alpha2.NewMetric("error-rate", "default").WithURLTemplate(&server.URL).WithJQExpression(&jq).Build()
+	assert.True(t, IsMeasurable(metric.Spec))
+	value, err := Measure(context.Background(), *metric, testEnvironment())
+	assert.NoError(t, err)
+	assert.Equal(t, 0.02, value)
+
+	// without a jq expression, only the analytics service can evaluate the metric
+	metric.Spec.JQExpression = nil
+	assert.False(t, IsMeasurable(metric.Spec))
+	_, err = Measure(context.Background(), *metric, testEnvironment())
+	assert.Error(t, err)
+}
+
+func TestExtractValue(t *testing.T) {
+	value, err := ExtractValue(".count", []byte(`{"count": 7}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 7.0, value)
+
+	_, err = ExtractValue(".missing", []byte(`{"count": 7}`))
+	assert.EqualError(t, err, "jqExpression produced null")
+
+	_, err = ExtractValue(".[", []byte(`{}`))
+	assert.Error(t, err)
+
+	_, err = ExtractValue(".count", []byte(`not json`))
+	assert.Error(t, err)
+
+	_, err = ExtractValue(".name", []byte(`{"name": "abc"}`))
+	assert.Error(t, err)
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
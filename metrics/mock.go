@@ -0,0 +1,75 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// ErrMockFailure is returned when the mock provider injects a failure
+var ErrMockFailure = errors.New("mock failure injected")
+
+// Mock generates metric values from the mock levels of the versions; it needs no metrics backend
+// A Counter with level x has value x*y, where y is the number of seconds since the start of the experiment.
+// A Gauge with level x has value x. The level changes over time by the trend and each value is perturbed by the noise.
+type Mock struct{}
+
+// Query generates the value of the metric for the version
+func (p *Mock) Query(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) (float64, error) {
+	level, ok := mockLevel(spec, env.Version)
+	if !ok {
+		return 0, fmt.Errorf("no mock level for version %s", env.Version)
+	}
+	elapsed := math.Max(math.Floor(env.Now.Sub(env.StartTime).Seconds()), 0)
+
+	// the same version at the same time always has the same value
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s/%v", spec.GetMockSeed(), env.Version, elapsed)
+	rnd := rand.New(rand.NewSource(int64(h.Sum64())))
+	if rnd.Float64() < spec.GetMockFailureRate() {
+		return 0, ErrMockFailure
+	}
+
+	trend := spec.GetMockTrend()
+	value := level + trend*elapsed
+	counter := spec.Type != nil && *spec.Type == v2alpha2.CounterMetricType
+	if counter {
+		value = level*elapsed + trend*elapsed*elapsed/2
+	}
+	if noise := spec.GetMockNoise(); noise > 0 {
+		value *= 1 + noise*rnd.NormFloat64()
+	}
+	if counter {
+		value = math.Max(value, 0)
+	}
+	return value, nil
+}
+
+// mockLevel returns the mock level of the version
+func mockLevel(spec v2alpha2.MetricSpec, version string) (float64, bool) {
+	for _, level := range spec.Mock {
+		if level.Name == version {
+			return level.Level.AsApproximateFloat64(), true
+		}
+	}
+	return 0, false
+}
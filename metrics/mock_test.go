@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func mockMetric(t v2alpha2.MetricType, options v2alpha2.MockOptions) v2alpha2.Metric {
+	return *v2alpha2.NewMetric("mocked", "default").
+		WithType(t).
+		WithMock([]v2alpha2.NamedLevel{{Name: "v1", Level: resource.MustParse("20")}}).
+		WithMockOptions(options).
+		Build()
+}
+
+func TestMockLevels(t *testing.T) {
+	env := testEnvironment()
+	value, err := Evaluate(context.Background(), mockMetric(v2alpha2.GaugeMetricType, v2alpha2.MockOptions{}), env)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value)
+
+	value, err = Evaluate(context.Background(), mockMetric(v2alpha2.CounterMetricType, v2alpha2.MockOptions{}), env)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0*90, value)
+
+	env.Version = "v2"
+	_, err = Evaluate(context.Background(), mockMetric(v2alpha2.GaugeMetricType, v2alpha2.MockOptions{}), env)
+	assert.EqualError(t, err, "no mock level for version v2")
+}
+
+func TestMockPrecedence(t *testing.T) {
+	m := v2alpha2.NewMetric("mocked", "default").
+		WithProvider(v2alpha2.ProviderPrometheus).
+		WithPrometheus(v2alpha2.PrometheusProvider{URL: "http://unreachable"}).
+		WithMock([]v2alpha2.NamedLevel{{Name: "v1", Level: resource.MustParse("20")}}).
+		Build()
+	assert.Equal(t, v2alpha2.ProviderMock, *m.Spec.GetBuiltinProvider())
+	assert.Equal(t, "mock: level 20", DescribeQuery(m.Spec, testEnvironment()))
+}
+
+func TestMockTrend(t *testing.T) {
+	trend := resource.MustParse("0.5")
+	env := testEnvironment()
+	value, err := Evaluate(context.Background(), mockMetric(v2alpha2.GaugeMetricType, v2alpha2.MockOptions{Trend: &trend}), env)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0+0.5*90, value)
+
+	value, err = Evaluate(context.Background(), mockMetric(v2alpha2.CounterMetricType, v2alpha2.MockOptions{Trend: &trend}), env)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0*90+0.5*90*90/2, value)
+}
+
+func TestMockNoise(t *testing.T) {
+	noise := resource.MustParse("0.1")
+	m := mockMetric(v2alpha2.GaugeMetricType, v2alpha2.MockOptions{Noise: &noise})
+	env := testEnvironment()
+
+	// values are noisy over time but repeatable at a given time
+	first, err := Evaluate(context.Background(), m, env)
+	assert.NoError(t, err)
+	again, _ := Evaluate(context.Background(), m, env)
+	assert.Equal(t, first, again)
+
+	sum, n := 0.0, 1000
+	distinct := map[float64]bool{}
+	for i := 0; i < n; i++ {
+		env.Now = env.StartTime.Add(time.Duration(i) * time.Second)
+		value, err := Evaluate(context.Background(), m, env)
+		assert.NoError(t, err)
+		sum += value
+		distinct[value] = true
+	}
+	assert.InDelta(t, 20.0, sum/float64(n), 0.5)
+	assert.Greater(t, len(distinct), n/2)
+}
+
+func TestMockFailures(t *testing.T) {
+	always, half := resource.MustParse("1"), resource.MustParse("0.5")
+	env := testEnvironment()
+	_, err := Evaluate(context.Background(), mockMetric(v2alpha2.GaugeMetricType, v2alpha2.MockOptions{FailureRate: &always}), env)
+	assert.Equal(t, ErrMockFailure, err)
+
+	failures, n := 0, 1000
+	m := mockMetric(v2alpha2.GaugeMetricType, v2alpha2.MockOptions{FailureRate: &half})
+	for i := 0; i < n; i++ {
+		env.Now = env.StartTime.Add(time.Duration(i) * time.Second)
+		if _, err := Evaluate(context.Background(), m, env); err != nil {
+			failures++
+		}
+	}
+	assert.InDelta(t, n/2, failures, float64(n)/10)
+}
@@ -0,0 +1,123 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// newRelicQuery is the NerdGraph query used to run an NRQL query
+const newRelicQuery = `query($accountId: Int!, $nrql: Nrql!) { actor { account(id: $accountId) { nrql(query: $nrql) { results } } } }`
+
+// NewRelic evaluates metrics using NRQL queries sent to the New Relic NerdGraph API
+// The user API key is read from the key apiKey of the secret of the metric.
+type NewRelic struct {
+	// Client is the HTTP client; if nil, a default client is used
+	Client *http.Client
+}
+
+// newRelicResponse is the subset of a NerdGraph response used by the provider
+type newRelicResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+	Data struct {
+		Actor struct {
+			Account struct {
+				Nrql struct {
+					Results []map[string]interface{} `json:"results"`
+				} `json:"nrql"`
+			} `json:"account"`
+		} `json:"actor"`
+	} `json:"data"`
+}
+
+// Query evaluates an NRQL query
+// The value is the numeric value of the first result; the query should select a single value.
+func (p *NewRelic) Query(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) (float64, error) {
+	if spec.NewRelic == nil {
+		return 0, errors.New("newrelic configuration missing")
+	}
+	accountID, err := strconv.Atoi(spec.NewRelic.AccountID)
+	if err != nil {
+		return 0, fmt.Errorf("invalid New Relic account id %s", spec.NewRelic.AccountID)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"query": newRelicQuery,
+		"variables": map[string]interface{}{
+			"accountId": accountID,
+			"nrql":      Interpolate(spec.NewRelic.Query, env),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, spec.NewRelic.GetURL(), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("API-Key", string(env.Secret["apiKey"]))
+	resp, err := httpClient(p.Client).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	response := newRelicResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("unable to decode response from newrelic: %s", err.Error())
+	}
+	if len(response.Errors) > 0 {
+		messages := []string{}
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+		}
+		return 0, fmt.Errorf("newrelic query failed: %s", strings.Join(messages, "; "))
+	}
+	if resp.StatusCode >= 400 {
+		return 0, fmt.Errorf("newrelic query failed with status %d", resp.StatusCode)
+	}
+
+	results := response.Data.Actor.Account.Nrql.Results
+	if len(results) == 0 {
+		return 0, ErrNoData
+	}
+	// select the first numeric field, in a deterministic order, ignoring time buckets
+	keys := []string{}
+	for k := range results[0] {
+		if k != "beginTimeSeconds" && k != "endTimeSeconds" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v, ok := results[0][k].(float64); ok {
+			return v, nil
+		}
+	}
+	return 0, ErrNoData
+}
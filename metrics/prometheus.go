@@ -0,0 +1,147 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// Prometheus evaluates metrics using the Prometheus HTTP API
+type Prometheus struct {
+	// Client is the HTTP client; if nil, a default client is used
+	Client *http.Client
+}
+
+// prometheusResponse is the subset of a Prometheus query response used by the provider
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// prometheusSample is an element of a vector result
+type prometheusSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+// instantQuery evaluates the instant query of the metric
+func (p *Prometheus) instantQuery(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) (*prometheusResponse, error) {
+	if spec.Prometheus == nil {
+		return nil, errors.New("prometheus configuration missing")
+	}
+	params := url.Values{}
+	params.Set("query", Interpolate(spec.Prometheus.Query, env))
+	params.Set("time", strconv.FormatInt(env.Now.Unix(), 10))
+	u := strings.TrimSuffix(spec.Prometheus.URL, "/") + "/api/v1/query?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient(p.Client).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := prometheusResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("unable to decode response from prometheus: %s", err.Error())
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", response.Error)
+	}
+	return &response, nil
+}
+
+// Query evaluates an instant query; the query must evaluate to a scalar or to a vector with a single element
+func (p *Prometheus) Query(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) (float64, error) {
+	response, err := p.instantQuery(ctx, spec, env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch response.Data.ResultType {
+	case "scalar":
+		sample := []interface{}{}
+		if err := json.Unmarshal(response.Data.Result, &sample); err != nil || len(sample) != 2 {
+			return 0, errors.New("unexpected scalar result from prometheus")
+		}
+		return parseValue(sample[1])
+	case "vector":
+		vector := []prometheusSample{}
+		if err := json.Unmarshal(response.Data.Result, &vector); err != nil {
+			return 0, errors.New("unexpected vector result from prometheus")
+		}
+		if len(vector) == 0 {
+			return 0, ErrNoData
+		}
+		if len(vector) > 1 || len(vector[0].Value) != 2 {
+			return 0, fmt.Errorf("prometheus query returned %d series; expected 1", len(vector))
+		}
+		return parseValue(vector[0].Value[1])
+	}
+	return 0, fmt.Errorf("unsupported prometheus result type %s", response.Data.ResultType)
+}
+
+// QueryHistogram evaluates an instant query that returns the cumulative count of observations per bucket
+// Each series in the resulting vector must have an le label with the upper bound of its bucket,
+// e.g. sum(increase(request_duration_seconds_bucket{version='$name'}[${elapsedTime}s])) by (le)
+func (p *Prometheus) QueryHistogram(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) ([]Bucket, error) {
+	response, err := p.instantQuery(ctx, spec, env)
+	if err != nil {
+		return nil, err
+	}
+	if response.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("unsupported prometheus result type %s for a histogram", response.Data.ResultType)
+	}
+	vector := []prometheusSample{}
+	if err := json.Unmarshal(response.Data.Result, &vector); err != nil {
+		return nil, errors.New("unexpected vector result from prometheus")
+	}
+	if len(vector) == 0 {
+		return nil, ErrNoData
+	}
+	cumulative := map[float64]float64{}
+	for _, sample := range vector {
+		le, ok := sample.Metric["le"]
+		if !ok || len(sample.Value) != 2 {
+			return nil, errors.New("prometheus histogram series require an le label")
+		}
+		upper, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid le label %s", le)
+		}
+		count, err := parseValue(sample.Value[1])
+		if err != nil {
+			return nil, err
+		}
+		cumulative[upper] = count
+	}
+	return cumulativeBuckets(cumulative), nil
+}
@@ -0,0 +1,200 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics evaluates metrics using the metric providers built into iter8.
+// A metric is evaluated by a built-in provider when spec.provider names the provider and
+// the configuration of the provider is present in the metric spec.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+)
+
+// ErrNoData is returned by a provider when the query succeeded but returned no value
+var ErrNoData = errors.New("no data")
+
+// Environment is the information about a version available to a provider when it evaluates a metric
+type Environment struct {
+	// Version is the name of the version
+	Version string
+
+	// Variables are the variables of the version
+	Variables map[string]string
+
+	// Secret is the data of the secret referenced by the metric, if any
+	Secret map[string][]byte
+
+	// StartTime is the time the experiment started
+	StartTime time.Time
+
+	// Now is the time of the evaluation
+	Now time.Time
+
+	// BuiltinHists are the latency histograms collected by the builtin metrics/collect task, if any
+	// cf. status.analysis.aggregatedBuiltinHists.data
+	BuiltinHists []byte
+}
+
+// Provider evaluates a metric for a version
+type Provider interface {
+	Query(ctx context.Context, spec v2alpha2.MetricSpec, env Environment) (float64, error)
+}
+
+// providers are the built-in providers, by name
+var providers = map[string]Provider{
+	v2alpha2.ProviderPrometheus: &Prometheus{},
+	v2alpha2.ProviderDatadog:    &Datadog{},
+	v2alpha2.ProviderNewRelic:   &NewRelic{},
+	v2alpha2.ProviderFile:       &File{},
+	v2alpha2.ProviderFortio:     &Fortio{},
+	v2alpha2.ProviderMock:       &Mock{},
+}
+
+// Register adds a provider or replaces the provider with the same name
+func Register(name string, provider Provider) {
+	providers[name] = provider
+}
+
+// GetProvider returns the provider that evaluates a metric
+// It returns nil if the metric is not evaluated by a built-in provider.
+func GetProvider(spec v2alpha2.MetricSpec) Provider {
+	name := spec.GetBuiltinProvider()
+	if name == nil {
+		return nil
+	}
+	return providers[*name]
+}
+
+// IsBuiltin returns true if the metric is evaluated by a built-in provider
+func IsBuiltin(spec v2alpha2.MetricSpec) bool {
+	return GetProvider(spec) != nil
+}
+
+// Evaluate evaluates a metric for a version using its built-in provider
+func Evaluate(ctx context.Context, metric v2alpha2.Metric, env Environment) (float64, error) {
+	provider := GetProvider(metric.Spec)
+	if provider == nil {
+		return 0, fmt.Errorf("metric %s is not evaluated by a built-in provider", metric.Name)
+	}
+	return provider.Query(ctx, metric.Spec, env)
+}
+
+// IsMeasurable returns true if a metric can be evaluated without the analytics service:
+// it uses a built-in provider or it has a urlTemplate and a jqExpression. Histogram metrics are not measurable.
+func IsMeasurable(spec v2alpha2.MetricSpec) bool {
+	if spec.IsHistogram() {
+		return false
+	}
+	if IsBuiltin(spec) {
+		return true
+	}
+	return !spec.IsDerived() && spec.URLTemplate != nil && spec.JQExpression != nil
+}
+
+// Measure evaluates a metric for a version without the analytics service
+// A metric that uses a built-in provider is evaluated by the provider; otherwise, the HTTP request of the metric
+// is sent and its jqExpression is applied to the response.
+func Measure(ctx context.Context, metric v2alpha2.Metric, env Environment) (float64, error) {
+	if !IsMeasurable(metric.Spec) {
+		return 0, fmt.Errorf("metric %s cannot be evaluated without the analytics service", metric.Name)
+	}
+	if IsBuiltin(metric.Spec) {
+		return Evaluate(ctx, metric, env)
+	}
+	req, err := RenderRequest(metric.Spec, env)
+	if err != nil {
+		return 0, err
+	}
+	body, err := req.Do(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return ExtractValue(*metric.Spec.JQExpression, body)
+}
+
+// Interpolate substitutes the placeholders in a query
+// The placeholders are $name (the name of the version), $elapsedTime (seconds since the start of the
+// experiment), the variables of the version and the keys of the secret. Unknown placeholders are left as is.
+func Interpolate(s string, env Environment) string {
+	return os.Expand(s, func(key string) string {
+		switch key {
+		case "name":
+			return env.Version
+		case "elapsedTime":
+			return strconv.Itoa(int(env.Now.Sub(env.StartTime).Seconds()))
+		}
+		if v, ok := env.Variables[key]; ok {
+			return v
+		}
+		if v, ok := env.Secret[key]; ok {
+			return string(v)
+		}
+		return "$" + key
+	})
+}
+
+// httpClient returns the client if set; otherwise a client with a timeout
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// parseValue converts a value returned by a provider to a float64
+func parseValue(v interface{}) (float64, error) {
+	switch value := v.(type) {
+	case float64:
+		return value, nil
+	case string:
+		return strconv.ParseFloat(value, 64)
+	case nil:
+		return 0, ErrNoData
+	}
+	return 0, fmt.Errorf("unexpected value %v", v)
+}
+
+// DescribeQuery returns the query sent by the built-in provider of a metric for a version
+func DescribeQuery(spec v2alpha2.MetricSpec, env Environment) string {
+	name := spec.GetBuiltinProvider()
+	if name == nil {
+		return ""
+	}
+	switch *name {
+	case v2alpha2.ProviderPrometheus:
+		return fmt.Sprintf("prometheus %s: %s", spec.Prometheus.URL, Interpolate(spec.Prometheus.Query, env))
+	case v2alpha2.ProviderDatadog:
+		return fmt.Sprintf("datadog %s: %s", spec.Datadog.GetURL(), Interpolate(spec.Datadog.Query, env))
+	case v2alpha2.ProviderNewRelic:
+		return fmt.Sprintf("newrelic %s (account %s): %s", spec.NewRelic.GetURL(), spec.NewRelic.AccountID, Interpolate(spec.NewRelic.Query, env))
+	case v2alpha2.ProviderFile:
+		return fmt.Sprintf("file %s: columns %s, %s", Interpolate(spec.File.Path, env), spec.File.GetVersionColumn(), spec.File.GetValueColumn())
+	case v2alpha2.ProviderFortio:
+		return "fortio: latency histogram collected by the metrics/collect task"
+	case v2alpha2.ProviderMock:
+		if level, ok := mockLevel(spec, env.Version); ok {
+			return fmt.Sprintf("mock: level %v", level)
+		}
+		return "mock: no level"
+	}
+	return *name
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEnvironment() Environment {
+	now := time.Now()
+	return Environment{
+		Version:   "v1",
+		Variables: map[string]string{"revision": "rev-1"},
+		Secret:    map[string][]byte{"apiKey": []byte("key"), "appKey": []byte("app")},
+		StartTime: now.Add(-90 * time.Second),
+		Now:       now,
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	env := testEnvironment()
+	assert.Equal(t, "rev-1 v1 90 $unknown", Interpolate("$revision $name $elapsedTime $unknown", env))
+	assert.Equal(t, "sum(x{revision='rev-1'}[90s])", Interpolate("sum(x{revision='$revision'}[${elapsedTime}s])", env))
+}
+
+func TestGetProvider(t *testing.T) {
+	spec := v2alpha2.NewMetric("m", "default").Build().Spec
+	assert.Nil(t, GetProvider(spec))
+
+	provider := v2alpha2.ProviderPrometheus
+	spec.Provider = &provider
+	assert.Nil(t, GetProvider(spec))
+	assert.False(t, IsBuiltin(spec))
+
+	spec.Prometheus = &v2alpha2.PrometheusProvider{}
+	assert.True(t, IsBuiltin(spec))
+}
+
+func TestPrometheus(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		query = r.URL.Query().Get("query")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1600000000,"12.5"]}]}}`))
+	}))
+	defer server.Close()
+
+	m := v2alpha2.NewMetric("m", "default").WithPrometheus(v2alpha2.PrometheusProvider{
+		URL:   server.URL,
+		Query: "sum(requests{revision='$revision'})",
+	}).Build()
+	value, err := Evaluate(context.Background(), *m, testEnvironment())
+	assert.NoError(t, err)
+	assert.Equal(t, 12.5, value)
+	assert.Equal(t, "sum(requests{revision='rev-1'})", query)
+}
+
+func TestPrometheusNoData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer server.Close()
+
+	m := v2alpha2.NewMetric("m", "default").WithPrometheus(v2alpha2.PrometheusProvider{URL: server.URL}).Build()
+	_, err := Evaluate(context.Background(), *m, testEnvironment())
+	assert.Equal(t, ErrNoData, err)
+}
+
+func TestDatadog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("DD-API-KEY"))
+		assert.Equal(t, "app", r.Header.Get("DD-APPLICATION-KEY"))
+		assert.Equal(t, "avg:latency{version:v1}", r.URL.Query().Get("query"))
+		w.Write([]byte(`{"status":"ok","series":[{"pointlist":[[1600000000000,3.0],[1600000010000,4.5],[1600000020000,null]]}]}`))
+	}))
+	defer server.Close()
+
+	m := v2alpha2.NewMetric("m", "default").WithDatadog(v2alpha2.DatadogProvider{
+		URL:   &server.URL,
+		Query: "avg:latency{version:$name}",
+	}).Build()
+	value, err := Evaluate(context.Background(), *m, testEnvironment())
+	assert.NoError(t, err)
+	assert.Equal(t, 4.5, value)
+}
+
+func TestNewRelic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("API-Key"))
+		body, _ := ioutil.ReadAll(r.Body)
+		request := struct {
+			Variables struct {
+				AccountID int    `json:"accountId"`
+				Nrql      string `json:"nrql"`
+			} `json:"variables"`
+		}{}
+		assert.NoError(t, json.Unmarshal(body, &request))
+		assert.Equal(t, 123, request.Variables.AccountID)
+		assert.Equal(t, "SELECT average(duration) FROM Transaction WHERE version = 'v1'", request.Variables.Nrql)
+		w.Write([]byte(`{"data":{"actor":{"account":{"nrql":{"results":[{"average.duration":0.25}]}}}}}`))
+	}))
+	defer server.Close()
+
+	m := v2alpha2.NewMetric("m", "default").WithNewRelic(v2alpha2.NewRelicProvider{
+		URL:       &server.URL,
+		AccountID: "123",
+		Query:     "SELECT average(duration) FROM Transaction WHERE version = '$name'",
+	}).Build()
+	value, err := Evaluate(context.Background(), *m, testEnvironment())
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, value)
+}
+
+func TestNewRelicError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":[{"message":"invalid nrql"}]}`))
+	}))
+	defer server.Close()
+
+	m := v2alpha2.NewMetric("m", "default").WithNewRelic(v2alpha2.NewRelicProvider{URL: &server.URL, AccountID: "123"}).Build()
+	_, err := Evaluate(context.Background(), *m, testEnvironment())
+	assert.EqualError(t, err, "newrelic query failed: invalid nrql")
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics.csv")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("time,version,latency\n1,v1,10\n1,v2,20\n2,v1,12\n"), 0644))
+
+	latency := "latency"
+	m := v2alpha2.NewMetric("m", "default").WithFile(v2alpha2.FileProvider{Path: path, ValueColumn: &latency}).Build()
+	env := testEnvironment()
+	value, err := Evaluate(context.Background(), *m, env)
+	assert.NoError(t, err)
+	assert.Equal(t, 12.0, value)
+
+	env.Version = "v3"
+	_, err = Evaluate(context.Background(), *m, env)
+	assert.Equal(t, ErrNoData, err)
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,3 +32,22 @@ func TestEnv(t *testing.T) {
 	_, err = getExperimentNN()
 	assert.Error(t, err)
 }
+
+func TestWriteTerminationMessage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "termination")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	original := terminationLogPath
+	defer func() { terminationLogPath = original }()
+
+	terminationLogPath = filepath.Join(dir, "termination-log")
+	writeTerminationMessage(errors.New("task failed"))
+	b, err := ioutil.ReadFile(terminationLogPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "task failed", string(b))
+
+	// unwritable termination log is ignored
+	terminationLogPath = filepath.Join(dir, "missing", "termination-log")
+	writeTerminationMessage(errors.New("task failed"))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 
 	"github.com/iter8-tools/etc3/api/v2alpha2"
@@ -19,6 +20,18 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// terminationLogPath is the file from which kubernetes reads the termination message of the handler container.
+// The controller reports the termination message when a handler fails.
+var terminationLogPath = "/dev/termination-log"
+
+// writeTerminationMessage writes the error that terminated the task runner to the termination log.
+func writeTerminationMessage(err error) {
+	if werr := ioutil.WriteFile(terminationLogPath, []byte(err.Error()), 0644); werr != nil {
+		// not fatal; for example, the task runner may not be running in a container
+		log.Trace("unable to write termination log: ", werr)
+	}
+}
+
 // getExperimentNN gets the name and namespace of the experiment from environment variables.
 // Returns error if unsuccessful.
 func getExperimentNN() (*types.NamespacedName, error) {
@@ -80,7 +93,8 @@ func run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err != nil {
+	// the experiment is unknown if it could not be read; there is no one to attribute an Iter8Log to
+	if err != nil && exp != nil {
 		il := controllers.Iter8Log{
 			IsIter8Log:          true,
 			ExperimentName:      exp.Name,
@@ -103,6 +117,7 @@ var runCmd = &cobra.Command{
 	Long:  `Sequentially execute all tasks in the specified action; if any task run results in an error, exit immediately with error.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := run(cmd, args); err != nil {
+			writeTerminationMessage(err)
 			log.Error("Exiting with error: ", err)
 			os.Exit(1)
 		}
@@ -0,0 +1,250 @@
+// Package executor executes lightweight actions of experiments within the controller process.
+// It is an alternative to launching a handler job to run the task runner for actions that
+// consist only of quick tasks such as notifications.
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/iter8-tools/etc3/controllers"
+	"github.com/iter8-tools/etc3/taskrunner/core"
+	"github.com/iter8-tools/etc3/taskrunner/tasks/http"
+	"github.com/iter8-tools/etc3/taskrunner/tasks/slack"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultWorkers is the default number of actions executed concurrently
+	DefaultWorkers int = 4
+	// DefaultQueueSize is the default number of actions that can wait to be executed
+	DefaultQueueSize int = 100
+	// retention is how long the result of a finished execution is remembered
+	retention time.Duration = time.Hour
+)
+
+var log *logrus.Logger
+
+func init() {
+	log = core.GetLogger()
+}
+
+// makers are the tasks that can be executed in-process, by task name
+// Tasks that take a long time or need the tools in the task runner image, such as
+// metrics/collect and run, are not supported.
+var makers = map[string]func(*v2alpha2.TaskSpec) (core.Task, error){
+	http.TaskName:  http.Make,
+	slack.TaskName: slack.Make,
+}
+
+// Executor executes actions in a pool of goroutines.
+// It implements controllers.ActionExecutor.
+// It is also a manager.Runnable; the goroutines run from the time Start is called until its context is done.
+type Executor struct {
+	workers    int
+	queue      chan *execution
+	mu         sync.Mutex
+	executions map[string]*execution
+}
+
+var _ controllers.ActionExecutor = &Executor{}
+
+// execution is a single execution of an action
+type execution struct {
+	id       string
+	exp      *core.Experiment
+	name     string
+	action   core.Action
+	onDone   func()
+	status   controllers.HandlerStatusType
+	err      error
+	cancel   context.CancelFunc
+	finished time.Time
+}
+
+// New returns an Executor with the given number of workers and queue size
+func New(workers int, queueSize int) *Executor {
+	return &Executor{
+		workers:    workers,
+		queue:      make(chan *execution, queueSize),
+		executions: map[string]*execution{},
+	}
+}
+
+// Start the workers and wait until the context is done
+func (e *Executor) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < e.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.work(ctx)
+		}()
+	}
+	<-ctx.Done()
+	wg.Wait()
+	return nil
+}
+
+// Supports returns true if every task in the action can be executed in-process
+func (e *Executor) Supports(action v2alpha2.Action) bool {
+	for i := range action {
+		if !core.IsATask(&action[i]) {
+			return false
+		}
+		if _, ok := makers[*action[i].Task]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Execute queues an action for execution
+func (e *Executor) Execute(id string, instance *v2alpha2.Experiment, action string, onDone func()) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.forgetFinished()
+	if _, ok := e.executions[id]; ok {
+		return nil
+	}
+
+	exp := &core.Experiment{Experiment: *instance}
+	actionSpec, err := exp.GetActionSpec(action)
+	if err != nil {
+		return err
+	}
+	tasks, err := makeAction(actionSpec)
+	if err != nil {
+		return err
+	}
+
+	ex := &execution{
+		id:     id,
+		exp:    exp,
+		name:   action,
+		action: tasks,
+		onDone: onDone,
+		status: controllers.HandlerStatusRunning,
+	}
+	select {
+	case e.queue <- ex:
+	default:
+		return errors.New("too many actions waiting to be executed")
+	}
+	e.executions[id] = ex
+	log.Trace("queued action ", action, " as ", id)
+	return nil
+}
+
+// Status returns the status of an execution and, if it failed, the error
+func (e *Executor) Status(id string) (controllers.HandlerStatusType, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ex, ok := e.executions[id]
+	if !ok {
+		return controllers.HandlerStatusNotLaunched, nil
+	}
+	return ex.status, ex.err
+}
+
+// Cancel terminates an execution; it is treated as failed
+func (e *Executor) Cancel(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ex, ok := e.executions[id]
+	if !ok || ex.status != controllers.HandlerStatusRunning {
+		return
+	}
+	if ex.cancel != nil {
+		ex.cancel()
+	}
+	e.finish(ex, errors.New("execution cancelled"))
+}
+
+// work executes queued actions until the context is done
+func (e *Executor) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ex := <-e.queue:
+			e.run(ctx, ex)
+		}
+	}
+}
+
+// run an execution
+func (e *Executor) run(ctx context.Context, ex *execution) {
+	e.mu.Lock()
+	if ex.status != controllers.HandlerStatusRunning {
+		// cancelled while queued
+		e.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ex.cancel = cancel
+	e.mu.Unlock()
+
+	log.Trace("executing action ", ex.name, " as ", ex.id)
+	ctx = context.WithValue(ctx, core.ContextKey("experiment"), ex.exp)
+	ctx = context.WithValue(ctx, core.ContextKey("action"), ex.name)
+	err := ex.action.Run(ctx)
+
+	e.mu.Lock()
+	if ex.status != controllers.HandlerStatusRunning {
+		// cancelled while running; the controller has already moved on
+		e.mu.Unlock()
+		return
+	}
+	e.finish(ex, err)
+	e.mu.Unlock()
+
+	ex.onDone()
+}
+
+// finish records the result of an execution; must be called with the lock held
+func (e *Executor) finish(ex *execution, err error) {
+	ex.status, ex.err = controllers.HandlerStatusComplete, nil
+	if err != nil {
+		log.Error("action ", ex.name, " failed: ", err)
+		ex.status, ex.err = controllers.HandlerStatusFailed, err
+	}
+	ex.finished = time.Now()
+}
+
+// forgetFinished removes executions that finished long ago; must be called with the lock held
+func (e *Executor) forgetFinished() {
+	for id, ex := range e.executions {
+		if ex.status != controllers.HandlerStatusRunning && time.Since(ex.finished) > retention {
+			delete(e.executions, id)
+		}
+	}
+}
+
+// makeAction converts an action spec into an action
+func makeAction(actionSpec v2alpha2.Action) (core.Action, error) {
+	action := make(core.Action, len(actionSpec))
+	for i := range actionSpec {
+		if !core.IsATask(&actionSpec[i]) {
+			return nil, errors.New("only tasks can be executed in-process")
+		}
+		maker, ok := makers[*actionSpec[i].Task]
+		if !ok {
+			return nil, fmt.Errorf("task %s cannot be executed in-process", *actionSpec[i].Task)
+		}
+		task, err := maker(&actionSpec[i])
+		if err != nil {
+			return nil, err
+		}
+		action[i] = task
+	}
+	return action, nil
+}
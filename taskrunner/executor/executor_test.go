@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/iter8-tools/etc3/api/v2alpha2"
+	"github.com/iter8-tools/etc3/controllers"
+	"github.com/iter8-tools/etc3/taskrunner/core"
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// httpTask returns a notification/http task that posts to url
+func httpTask(url string) v2alpha2.TaskSpec {
+	u, _ := json.Marshal(url)
+	ignoreFailure, _ := json.Marshal(false)
+	return v2alpha2.TaskSpec{
+		Task: core.StringPointer("notification/http"),
+		With: map[string]apiextensionsv1.JSON{
+			"URL":           {Raw: u},
+			"ignoreFailure": {Raw: ignoreFailure},
+		},
+	}
+}
+
+// wait for an execution to finish
+func wait(t *testing.T, e *Executor, id string) (controllers.HandlerStatusType, error) {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if status, err := e.Status(id); status != controllers.HandlerStatusRunning {
+			return status, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("execution did not finish")
+	return controllers.HandlerStatusRunning, nil
+}
+
+func TestSupports(t *testing.T) {
+	e := New(1, 1)
+	assert.True(t, e.Supports(v2alpha2.Action{httpTask("http://example.com"), {Task: core.StringPointer("notification/slack")}}))
+	assert.False(t, e.Supports(v2alpha2.Action{httpTask("http://example.com"), {Task: core.StringPointer("metrics/collect")}}))
+	assert.False(t, e.Supports(v2alpha2.Action{{Run: core.StringPointer("echo hello")}}))
+}
+
+func TestExecute(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	experiment := v2alpha2.NewExperiment("executor", "default").
+		WithAction("start", []v2alpha2.TaskSpec{httpTask(ok.URL)}).
+		WithAction("finish", []v2alpha2.TaskSpec{httpTask(ok.URL), httpTask(broken.URL)}).
+		Build()
+
+	e := New(2, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Start(ctx)
+
+	done := make(chan string, 2)
+	assert.NoError(t, e.Execute("start", experiment, "start", func() { done <- "start" }))
+	// executing the same id again has no effect
+	assert.NoError(t, e.Execute("start", experiment, "start", func() { done <- "again" }))
+	status, err := wait(t, e, "start")
+	assert.Equal(t, controllers.HandlerStatusComplete, status)
+	assert.NoError(t, err)
+	assert.Equal(t, "start", <-done)
+
+	assert.NoError(t, e.Execute("finish", experiment, "finish", func() { done <- "finish" }))
+	status, err = wait(t, e, "finish")
+	assert.Equal(t, controllers.HandlerStatusFailed, status)
+	assert.Error(t, err)
+	assert.Equal(t, "finish", <-done)
+
+	// unknown actions and executions
+	assert.Error(t, e.Execute("loop", experiment, "loop", func() {}))
+	status, _ = e.Status("loop")
+	assert.Equal(t, controllers.HandlerStatusNotLaunched, status)
+}
+
+func TestCancel(t *testing.T) {
+	experiment := v2alpha2.NewExperiment("executor", "default").
+		WithAction("start", []v2alpha2.TaskSpec{httpTask("http://example.com")}).
+		Build()
+
+	// not started; executions stay queued
+	e := New(1, 1)
+	assert.NoError(t, e.Execute("start", experiment, "start", func() {}))
+	status, _ := e.Status("start")
+	assert.Equal(t, controllers.HandlerStatusRunning, status)
+
+	// the queue is full
+	assert.Error(t, e.Execute("other", experiment, "start", func() {}))
+
+	e.Cancel("start")
+	status, err := e.Status("start")
+	assert.Equal(t, controllers.HandlerStatusFailed, status)
+	assert.Error(t, err)
+}